@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// backlog reports whether each repository keeps up with its inflow of
+// issues.
+func backlog(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("backlog", flag.ContinueOnError)
+	period := flags.Duration("period", 0, "length of the periods issues are counted in, e.g. 168h")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"period": func(config *internal.Config) { config.Backlog.Period = *period },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			issues, err := internal.GetBacklogIssues(run.client, repo, run.now.Add(-run.config.Window))
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			report, err := internal.NewBacklogReport(repo.Name, issues, run.now, run.config.Window, run.config.Backlog.Period)
+			if err != nil {
+				return nil, err
+			}
+			run.logger.Info("measured backlog", "repo", repo.Name, "issues", len(issues))
+			return report, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			reports := make([]internal.BacklogReport, 0, len(results))
+			for _, result := range results {
+				reports = append(reports, result.(internal.BacklogReport))
+			}
+			return internal.NewBacklogSummary(reports)
+		},
+	}.run(args, stdout)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// busFactor reports how concentrated first responses, reviews and merges are
+// among the people who do them, and which repositories depend on too few.
+func busFactor(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("bus-factor", flag.ContinueOnError)
+	topN := flags.Int("top-n", 0, "number of most active people the top share is taken over")
+	threshold := flags.Float64("threshold", 0, "top share above which a repository is at risk, e.g. 0.5")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose activity is ignored")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"top-n":         func(config *internal.Config) { config.BusFactor.TopN = *topN },
+			"threshold":     func(config *internal.Config) { config.BusFactor.Threshold = *threshold },
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			results, err := measureRepo(run.forge, run.clock, run.logger, repo, run.config)
+			if err != nil {
+				return nil, err
+			}
+			reviews, err := internal.GetReviewActivity(run.client, repo, run.now.Add(-run.config.Window), run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			responders := internal.FirstResponders(results)
+			run.logger.Info("gathered maintainer activity", "repo", repo.Name, "first_responses", len(responders), "reviews_and_merges", len(reviews))
+			return internal.CombineMaintainerActivity(responders, reviews), nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var activity []internal.MaintainerActivity
+			for _, result := range results {
+				activity = append(activity, result.([]internal.MaintainerActivity)...)
+			}
+			return internal.NewBusFactorSummary(activity, run.config.BusFactor)
+		},
+	}.run(args, stdout)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// cadence reports how often code lands on the repositories' default branches
+// and how often they ship releases.
+func cadence(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("cadence", flag.ContinueOnError)
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins not counted as committers")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			result, err := internal.GetCadence(run.client, repo, run.now, run.config.Window, run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("measured cadence", "repo", repo.Name, "commits", result.Commits, "releases", result.Releases)
+			return result, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			cadences := make([]internal.RepoCadence, 0, len(results))
+			for _, result := range results {
+				cadences = append(cadences, result.(internal.RepoCadence))
+			}
+			return internal.NewCadenceReport(cadences, run.config.Window)
+		},
+	}.run(args, stdout)
+}
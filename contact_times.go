@@ -1 +1,278 @@
-package contact_times
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+	"strings"
+	"time"
+)
+
+func contactTimes(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("contact-times", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	orgs := flags.String("orgs", "", "comma-separated organizations to measure")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose issues and replies are ignored")
+	includeBots := flags.Bool("include-bots", false, "count issues opened by bots")
+	window := flags.Duration("window", 0, "how far back to look for issues, e.g. 720h")
+	format := flags.String("output", "", "output format: text or json")
+	concurrency := flags.Int("concurrency", 0, "number of repositories to measure at once")
+	repoNames := flags.String("repos", "", "comma-separated globs; only measure repositories matching one")
+	excludeRepos := flags.String("exclude-repos", "", "comma-separated globs of repositories to skip")
+	topics := flags.String("topics", "", "comma-separated topics; only measure repositories with one")
+	excludeTopics := flags.String("exclude-topics", "", "comma-separated topics of repositories to skip")
+	languages := flags.String("languages", "", "comma-separated languages; only measure repositories in one")
+	excludeLanguages := flags.String("exclude-languages", "", "comma-separated languages of repositories to skip")
+	archived := flags.Bool("archived", false, "measure archived repositories")
+	forks := flags.Bool("forks", false, "measure forks")
+	templates := flags.Bool("templates", false, "measure template repositories")
+	visibility := flags.String("visibility", "", "only measure repositories with this visibility: all, public or private")
+	segmentByLabel := flags.Bool("segment-by-label", false, "break first contact times down by issue label")
+	responders := flags.Bool("responders", false, "break first contacts down by responder")
+	showResponderLogins := flags.Bool("show-responder-logins", false, "show responder logins instead of pseudonyms")
+	discussions := flags.Bool("discussions", false, "also measure GitHub Discussions")
+	tokenFile := flags.String("token-file", "", "read the API token from this file")
+	backend := flags.String("backend", "", "API to fetch issues with: rest or graphql")
+	forgeName := flags.String("forge", "", "forge to measure: github, gitlab or gitea")
+	server := flags.String("server", "", "API URL of a self-hosted forge, e.g. https://ghe.example.com/api/v3")
+	pushedWithin := flags.Duration("pushed-within", 0, "only measure repositories pushed to within this duration")
+	continueOnError := flags.Bool("continue-on-error", false, "report issues that cannot be measured instead of stopping")
+	errorBudget := flags.Float64("error-budget", 0, "fraction of issues that may fail to be measured before the run fails, e.g. 0.05")
+	record := flags.String("record", "", "record every API request and response to this cassette file")
+	replay := flags.String("replay", "", "answer API requests from this cassette file instead of the network")
+	verbose := flags.Bool("verbose", false, "log every API request and how each issue was measured; short for --log-level debug")
+	logLevel := flags.String("log-level", "warn", "lowest level logged to stderr: debug, info, warn or error")
+	logFormat := flags.String("log-format", "text", "format of the log written to stderr: text or json")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	config := internal.DefaultConfig()
+	if *configPath != "" {
+		config, err = internal.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Only flags given on the command line override the config file.
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "orgs":
+			config.Orgs = splitList(*orgs)
+		case "ignored-users":
+			config.IgnoredUsers = splitList(*ignoredUsers)
+		case "include-bots":
+			config.Bots.Include = *includeBots
+		case "window":
+			config.Window = *window
+		case "output":
+			config.Output.Format = *format
+		case "concurrency":
+			config.Concurrency = *concurrency
+		case "repos":
+			config.Repos.Names = splitList(*repoNames)
+		case "exclude-repos":
+			config.Repos.Exclude = splitList(*excludeRepos)
+		case "topics":
+			config.Repos.Topics = splitList(*topics)
+		case "exclude-topics":
+			config.Repos.ExcludeTopics = splitList(*excludeTopics)
+		case "languages":
+			config.Repos.Languages = splitList(*languages)
+		case "exclude-languages":
+			config.Repos.ExcludeLanguages = splitList(*excludeLanguages)
+		case "archived":
+			config.Repos.Archived = *archived
+		case "forks":
+			config.Repos.Forks = *forks
+		case "templates":
+			config.Repos.Templates = *templates
+		case "visibility":
+			config.Repos.Visibility = *visibility
+		case "pushed-within":
+			config.Repos.PushedWithin = *pushedWithin
+		case "continue-on-error":
+			config.Errors.Continue = *continueOnError
+		case "error-budget":
+			config.Errors.Budget = *errorBudget
+		case "segment-by-label":
+			config.Segments.ByLabel = *segmentByLabel
+		case "responders":
+			config.Responders.Breakdown = *responders
+		case "show-responder-logins":
+			config.Responders.ShowLogins = *showResponderLogins
+		case "discussions":
+			config.Discussions.Enabled = *discussions
+		case "token-file":
+			config.Auth = internal.AuthConfig{TokenFile: *tokenFile}
+		case "server":
+			config.Server.Host = ""
+			config.Server.URL = *server
+		case "backend":
+			config.Backend = *backend
+		case "forge":
+			config.Server.Forge = *forgeName
+		}
+	})
+
+	err = config.Validate()
+	if err != nil {
+		return err
+	}
+
+	logger, err := newLogger(*logLevel, *verbose, *logFormat)
+	if err != nil {
+		return err
+	}
+
+	httpClient, clock, err := transport(config, *record, *replay, logger)
+	if err != nil {
+		return err
+	}
+
+	forge, err := newForge(config, httpClient, clock)
+	if err != nil {
+		return err
+	}
+
+	repos, err := selectRepos(forge, config)
+	if err != nil {
+		return err
+	}
+	logger.Info("selected repositories", "count", len(repos))
+
+	report, err := measureRepos(forge, clock, logger, repos, config)
+	if err != nil {
+		return err
+	}
+	if config.Discussions.Enabled {
+		report.Discussions, err = measureDiscussions(forge.(*internal.GitHubForge), clock, logger, repos, config)
+		if err != nil {
+			return err
+		}
+	}
+	err = report.Write(stdout, config.Output.Format)
+	if err != nil {
+		return err
+	}
+	if report.Errors != nil {
+		return report.Errors.Err()
+	}
+	return nil
+}
+
+// selectRepos lists the filtered repositories of every configured org and adds
+// the explicitly included repositories.
+func selectRepos(forge internal.Forge, config internal.Config) ([]internal.Repository, error) {
+	var repos []internal.Repository
+	for _, name := range config.Orgs {
+		orgRepos, err := forge.GetRepos(name, config.Repos.RepoFilter)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, orgRepos...)
+	}
+	for _, name := range config.Repos.Include {
+		repos = append(repos, internal.Repository{Name: name})
+	}
+
+	seen := make(map[string]struct{})
+	var selected []internal.Repository
+	for _, repo := range repos {
+		if _, duplicate := seen[repo.Name]; duplicate {
+			continue
+		}
+		seen[repo.Name] = struct{}{}
+		selected = append(selected, repo)
+	}
+	return selected, nil
+}
+
+// measureRepos measures up to config.Concurrency repositories at a time and
+// returns a report ordered by repository name.
+func measureRepos(forge internal.Forge, clock internal.Clock, logger *internal.Logger, repos []internal.Repository, config internal.Config) (internal.Report, error) {
+	results := make([]internal.RepoResults, len(repos))
+	err := eachRepo(repos, config.Concurrency, func(i int) error {
+		var err error
+		results[i], err = measureRepo(forge, clock, logger, repos[i], config)
+		return err
+	})
+	if err != nil {
+		return internal.Report{}, err
+	}
+	return internal.NewReport(results, config.ReportOptions()), nil
+}
+
+// measureDiscussions measures the discussions of each repository in turn.
+func measureDiscussions(forge *internal.GitHubForge, clock internal.Clock, logger *internal.Logger, repos []internal.Repository, config internal.Config) ([]internal.DiscussionReport, error) {
+	now := clock.Now().UTC()
+	options := config.ContactOptions()
+	results := make(map[string][]internal.DiscussionResult)
+	for _, repo := range repos {
+		discussions, err := forge.GetRecentDiscussions(repo, config.Window)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+		}
+		results[repo.Name], err = internal.MeasureDiscussions(repo.Name, discussions, now, options)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("measured discussions", "repo", repo.Name, "discussions", len(results[repo.Name]))
+	}
+	return internal.NewDiscussionReports(results), nil
+}
+
+func measureRepo(forge internal.Forge, clock internal.Clock, logger *internal.Logger, repo internal.Repository, config internal.Config) (internal.RepoResults, error) {
+	start := time.Now()
+	issues, err := forge.GetRecentIssues(repo, config.Window)
+	if err != nil && config.Errors.Continue {
+		logger.Warn("could not measure repository", "repo", repo.Name, "error", err)
+		return internal.RepoResults{Name: repo.Name, Errors: []internal.IssueError{{Repo: repo.Name, Err: err}}}, nil
+	}
+	if err != nil {
+		logger.Error("measuring repository failed", "repo", repo.Name, "error", err)
+		return internal.RepoResults{}, fmt.Errorf("measuring %s: %s", repo.Name, err)
+	}
+
+	getters := make([]internal.CommentGetter, 0, len(issues))
+	for i := range issues {
+		getters = append(getters, &issues[i])
+	}
+
+	output := make(chan internal.FirstContact)
+	options := config.ContactOptions()
+	options.Logger = logger
+	go repo.GetFirstContactTimes(forge.Client(), getters, clock, options, output)
+
+	var results []internal.FirstContact
+	var failures []internal.IssueError
+	for result := range output {
+		if result.Status != internal.StatusFailed {
+			results = append(results, result)
+			continue
+		}
+		failure := internal.IssueError{Repo: repo.Name, Number: result.Issue.Number, MergeRequest: result.Issue.MergeRequest, Err: result.Error}
+		if !config.Errors.Continue {
+			logger.Error("measuring repository failed", "repo", repo.Name, "error", failure)
+			return internal.RepoResults{}, fmt.Errorf("measuring %s", failure)
+		}
+		failures = append(failures, failure)
+	}
+	logger.Info("measured repository", "repo", repo.Name, "issues", len(issues), "measured", len(results),
+		"failed", len(failures), "duration", time.Since(start).Round(time.Millisecond))
+	return internal.RepoResults{Name: repo.Name, Results: results, Errors: failures}, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
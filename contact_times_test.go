@@ -1,4 +1,4 @@
-package contact_times_test
+package main_test
 
 import "testing"
 
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// contributors reports how many people contribute to the repositories, how
+// established they are and whether they come back.
+func contributors(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("contributors", flag.ContinueOnError)
+	period := flags.Duration("period", 0, "length of the periods contributors are counted in, e.g. 720h")
+	maintainers := flags.String("maintainers", "", "comma-separated logins to count as core contributors")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose activity is ignored")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"period":        func(config *internal.Config) { config.Contributors.Period = *period },
+			"maintainers":   func(config *internal.Config) { config.Contributors.Maintainers = splitList(*maintainers) },
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			activity, err := internal.GetContributorActivity(run.client, repo, run.now.Add(-run.config.Window), run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("gathered contributor activity", "repo", repo.Name, "activity", len(activity))
+			return activity, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var activity []internal.Activity
+			for _, result := range results {
+				activity = append(activity, result.([]internal.Activity)...)
+			}
+			return internal.NewContributorSummary(activity, run.now, run.config.Window, run.config.Contributors)
+		},
+	}.run(args, stdout)
+}
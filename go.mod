@@ -5,4 +5,5 @@ go 1.15
 require (
 	github.com/onsi/gomega v1.10.3
 	github.com/sclevine/spec v1.4.0
+	gopkg.in/yaml.v2 v2.3.0
 )
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// goodFirstIssues reports whether issues labeled for newcomers get picked up,
+// and whether newcomers are the ones completing them.
+func goodFirstIssues(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("good-first-issues", flag.ContinueOnError)
+	labels := flags.String("labels", "", "comma-separated labels that mark issues for newcomers")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"labels": func(config *internal.Config) { config.GoodFirst.Labels = splitList(*labels) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			issues, err := internal.GetGoodFirstIssues(run.client, repo, run.now.Add(-run.config.Window), run.config.GoodFirst.Labels)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("measured good first issues", "repo", repo.Name, "issues", len(issues))
+			return issues, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var issues []internal.GoodFirstIssue
+			for _, result := range results {
+				issues = append(issues, result.([]internal.GoodFirstIssue)...)
+			}
+			return internal.NewGoodFirstIssueSummary(issues, run.now)
+		},
+	}.run(args, stdout)
+}
@@ -1,11 +1,13 @@
 package internal
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"os"
+	"strings"
 )
 
 //go:generate faux --interface HTTPClient --output fakes/http_client.go
@@ -16,43 +18,163 @@ type HTTPClient interface {
 //go:generate faux --interface Client --output fakes/client.go
 type Client interface {
 	Get(path string, params ...string) ([]byte, error)
+	GetNext(path string, params ...string) ([]byte, []string, error)
 }
 
-//TODO: make this store a URL with a scheme and Host instead of re-parsing every time
+// APIClient makes requests against the API at ServerURL. The server URL may
+// carry a base path, as GitHub Enterprise Server's https://HOST/api/v3 does,
+// which request paths are joined onto. AuthScheme is the scheme of the
+// Authorization header, "token" unless the API expects something else.
+// Responses with an error status are returned as a *StatusError.
 type APIClient struct {
-	ServerURL string
-	client    HTTPClient
+	ServerURL  *url.URL
+	AuthScheme string
+	client     HTTPClient
+	tokens     TokenSource
 }
 
-func NewAPIClient(serverURL string, httpClient HTTPClient) APIClient {
-	return APIClient{ServerURL: serverURL,
-		client: httpClient}
+func NewAPIClient(serverURL string, httpClient HTTPClient, tokens TokenSource) (APIClient, error) {
+	uri, err := ParseServerURL(serverURL)
+	if err != nil {
+		return APIClient{}, err
+	}
+	return APIClient{ServerURL: uri,
+		AuthScheme: "token",
+		client:     httpClient,
+		tokens:     tokens}, nil
 }
 
-func (c *APIClient) Get(path string, params ...string) ([]byte, error) {
-
-	uri, err := url.Parse(c.ServerURL)
+// ParseServerURL parses an API server URL, requiring a scheme and host.
+func ParseServerURL(serverURL string) (*url.URL, error) {
+	uri, err := url.Parse(serverURL)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse server URL: %s", err)
 	}
+	if uri.Scheme == "" || uri.Host == "" {
+		return nil, fmt.Errorf("could not parse server URL: %q needs a scheme and host", serverURL)
+	}
+	return uri, nil
+}
+
+// JoinPath returns the URL for path on the server at base. Paths taken from
+// URLs the API returned, such as an issue's comments_url, already start with
+// the base path and are used unchanged. path may contain escapes, such as the
+// %2F GitLab uses in project paths, which are kept as they are.
+func JoinPath(base *url.URL, path string) *url.URL {
+	uri := *base
+	basePath := strings.TrimSuffix(base.EscapedPath(), "/")
+	if basePath == "" || !(path == basePath || strings.HasPrefix(path, basePath+"/")) {
+		path = basePath + "/" + strings.TrimPrefix(path, "/")
+	}
 
 	uri.Path = path
-	if len(params) > 0 {
-		uri.RawQuery = params[0]
-		for i := range params {
-			if i != 0 {
-				uri.RawQuery = fmt.Sprintf("%s&%s", uri.RawQuery, params[i])
-			}
-		}
+	uri.RawPath = ""
+	if unescaped, err := url.PathUnescape(path); err == nil && unescaped != path {
+		uri.Path = unescaped
+		uri.RawPath = path
+	}
+	uri.RawQuery = ""
+	return &uri
+}
+
+func (c *APIClient) Get(path string, params ...string) ([]byte, error) {
+	body, _, err := c.get(path, params)
+	return body, err
+}
+
+// GetNext is Get for lists that page by cursor rather than by page number,
+// which getAllPages cannot follow. Along with the body it returns the query
+// parameters of the next page from the Link header, or none on the last page.
+func (c *APIClient) GetNext(path string, params ...string) ([]byte, []string, error) {
+	body, header, err := c.get(path, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return body, nextPageParams(header.Get("Link")), nil
+}
+
+func (c *APIClient) get(path string, params []string) ([]byte, http.Header, error) {
+	uri := JoinPath(c.ServerURL, path)
+	uri.RawQuery = strings.Join(params, "&")
+
+	token, err := c.tokens.Token()
+	if err != nil {
+		return nil, nil, fmt.Errorf("getting auth token: %s", err)
 	}
 
 	request, _ := http.NewRequest("GET", uri.String(), nil)
-	request.Header.Add("Authorization", fmt.Sprintf("token %s", os.Getenv("GITHUB_TOKEN")))
+	if token != "" {
+		request.Header.Add("Authorization", fmt.Sprintf("%s %s", c.AuthScheme, token))
+	}
 
 	response, err := c.client.Do(request)
 	if err != nil {
-		return nil, fmt.Errorf("client couldn't make HTTP request: %s", err)
+		return nil, nil, fmt.Errorf("client couldn't make HTTP request: %s", err)
 	}
+	defer response.Body.Close()
 
-	return ioutil.ReadAll(response.Body)
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if response.StatusCode >= 400 {
+		return nil, nil, &StatusError{Path: uri.Path, StatusCode: response.StatusCode, Body: body}
+	}
+	return body, response.Header, nil
+}
+
+// StatusError is a response with an error status. Body usually says why, as
+// a JSON object with a message.
+type StatusError struct {
+	Path       string
+	StatusCode int
+	Body       []byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s: %s", e.Path, e.StatusCode, http.StatusText(e.StatusCode), strings.TrimSpace(string(e.Body)))
+}
+
+// Message is the message of the error's JSON body, if it has one.
+func (e *StatusError) Message() string {
+	var message struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(e.Body, &message) != nil {
+		return ""
+	}
+	return message.Message
+}
+
+// statusCode is the status of a *StatusError, or 0 for any other error.
+func statusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	return 0
+}
+
+// nextPageParams returns the query parameters of the rel="next" URL in a Link
+// header.
+func nextPageParams(link string) []string {
+	for _, rel := range strings.Split(link, ",") {
+		parts := strings.Split(rel, ";")
+		next := false
+		for _, part := range parts[1:] {
+			if strings.TrimSpace(part) == `rel="next"` {
+				next = true
+			}
+		}
+		if !next {
+			continue
+		}
+
+		uri, err := url.Parse(strings.Trim(strings.TrimSpace(parts[0]), "<>"))
+		if err != nil || uri.RawQuery == "" {
+			return nil
+		}
+		return strings.Split(uri.RawQuery, "&")
+	}
+	return nil
 }
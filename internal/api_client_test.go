@@ -16,14 +16,37 @@ import (
 func testAPIClient(t *testing.T, context spec.G, it spec.S) {
 	var apiClient APIClient
 	var httpClient *fakes.HTTPClient
+	var tokens *fakes.TokenSource
 	var Expect = NewWithT(t).Expect
 
 	httpClient = &fakes.HTTPClient{}
+	tokens = &fakes.TokenSource{}
+	tokens.TokenCall.Returns.String = "some-token"
 
-	apiClient = NewAPIClient("https://test-server.com", httpClient)
+	apiClient, _ = NewAPIClient("https://test-server.com", httpClient, tokens)
+
+	context("NewAPIClient", func() {
+		context("failure cases", func() {
+			context("when server URL cannot be parsed", func() {
+				it("returns the error", func() {
+					_, err := NewAPIClient("some-garbage\n", httpClient, tokens)
+
+					Expect(err).To(HaveOccurred())
+					Expect(err).To(MatchError(`could not parse server URL: parse "some-garbage\n": net/url: invalid control character in URL`))
+				})
+			})
+
+			context("when server URL has no host", func() {
+				it("returns the error", func() {
+					_, err := NewAPIClient("/api/v3", httpClient, tokens)
+
+					Expect(err).To(MatchError(`could not parse server URL: "/api/v3" needs a scheme and host`))
+				})
+			})
+		})
+	})
 
 	context("Get", func() {
-		//TODO: Add test that auth token is added
 		context("when an endpoint is provided", func() {
 			it.Before(func() {
 				doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
@@ -38,6 +61,14 @@ func testAPIClient(t *testing.T, context spec.G, it spec.S) {
 				Expect(httpClient.DoCall.Receives.Req.URL.Path).To(Equal("/my/test/endpoint"))
 			})
 
+			it("authenticates with the token from the token source", func() {
+				_, err := apiClient.Get("/my/test/endpoint")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(tokens.TokenCall.CallCount).To(Equal(1))
+				Expect(httpClient.DoCall.Receives.Req.Header.Get("Authorization")).To(Equal("token some-token"))
+			})
+
 			it("returns the httpClient's response", func() {
 				body, _ := apiClient.Get("/my/test/endpoint")
 
@@ -45,6 +76,50 @@ func testAPIClient(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the server URL has a base path", func() {
+			it.Before(func() {
+				apiClient, _ = NewAPIClient("https://ghe.example.com/api/v3/", httpClient, tokens)
+				doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
+				httpClient.DoCall.Returns.Response = &http.Response{StatusCode: 200, Body: doBody}
+			})
+
+			it("joins the request path onto the base path", func() {
+				_, err := apiClient.Get("orgs/example-org/repos")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(httpClient.DoCall.Receives.Req.URL.String()).To(Equal("https://ghe.example.com/api/v3/orgs/example-org/repos"))
+			})
+
+			it("keeps paths that already start with the base path", func() {
+				_, err := apiClient.Get("/api/v3/repos/example-org/example-repo/issues/1/comments")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(httpClient.DoCall.Receives.Req.URL.String()).To(Equal("https://ghe.example.com/api/v3/repos/example-org/example-repo/issues/1/comments"))
+			})
+
+			it("keeps escaped slashes in the request path", func() {
+				_, err := apiClient.Get("/projects/example-group%2Fexample-project/issues")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(httpClient.DoCall.Receives.Req.URL.String()).To(Equal("https://ghe.example.com/api/v3/projects/example-group%2Fexample-project/issues"))
+			})
+		})
+
+		context("when the auth scheme is changed", func() {
+			it.Before(func() {
+				apiClient.AuthScheme = "Bearer"
+				doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
+				httpClient.DoCall.Returns.Response = &http.Response{StatusCode: 200, Body: doBody}
+			})
+
+			it("authenticates with that scheme", func() {
+				_, err := apiClient.Get("/my/test/endpoint")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(httpClient.DoCall.Receives.Req.Header.Get("Authorization")).To(HavePrefix("Bearer "))
+			})
+		})
+
 		context("when params are provided", func() {
 			it.Before(func() {
 				doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
@@ -59,17 +134,47 @@ func testAPIClient(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the token source has no token", func() {
+			it.Before(func() {
+				tokens.TokenCall.Returns.String = ""
+				doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
+				httpClient.DoCall.Returns.Response = &http.Response{StatusCode: 200, Body: doBody}
+			})
+
+			it("makes an unauthenticated request", func() {
+				_, err := apiClient.Get("/my/test/endpoint")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(httpClient.DoCall.Receives.Req.Header).NotTo(HaveKey("Authorization"))
+			})
+		})
+
 		context("failure cases", func() {
-			context("when server URL cannot be parsed", func() {
+			context("when the token source fails", func() {
 				it.Before(func() {
-					apiClient.ServerURL = "some-garbage\n"
+					tokens.TokenCall.Returns.Error = fmt.Errorf("token expired")
 				})
-
 				it("returns the error", func() {
-					_, err := apiClient.Get("")
+					_, err := apiClient.Get("/my/endpoint")
 
-					Expect(err).To(HaveOccurred())
-					Expect(err).To(MatchError(`could not parse server URL: parse "some-garbage\n": net/url: invalid control character in URL`))
+					Expect(err).To(MatchError("getting auth token: token expired"))
+					Expect(httpClient.DoCall.CallCount).To(Equal(0))
+				})
+			})
+
+			context("when the response has an error status", func() {
+				it.Before(func() {
+					doBody := ioutil.NopCloser(bytes.NewReader([]byte(`{"message":"Not Found"}`)))
+					httpClient.DoCall.Returns.Response = &http.Response{StatusCode: 404, Body: doBody}
+				})
+				it("returns a StatusError", func() {
+					_, err := apiClient.Get("/my/endpoint")
+
+					Expect(err).To(MatchError(`/my/endpoint returned 404 Not Found: {"message":"Not Found"}`))
+					statusErr, ok := err.(*StatusError)
+					Expect(ok).To(BeTrue())
+					Expect(statusErr.StatusCode).To(Equal(404))
+					Expect(statusErr.Message()).To(Equal("Not Found"))
 				})
 			})
 
@@ -86,4 +191,38 @@ func testAPIClient(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("GetNext", func() {
+		it.Before(func() {
+			tokens.TokenCall.Returns.String = "some-token"
+			tokens.TokenCall.Returns.Error = nil
+			httpClient.DoCall.Returns.Error = nil
+			header := http.Header{}
+			header.Set("Link", `<https://test-server.com/my/test/endpoint?per_page=100&before=abc>; rel="prev", `+
+				`<https://test-server.com/my/test/endpoint?per_page=100&after=Y3Vyc29y%3D>; rel="next"`)
+			doBody := ioutil.NopCloser(bytes.NewReader([]byte("some body")))
+			httpClient.DoCall.Returns.Response = &http.Response{StatusCode: 200, Header: header, Body: doBody}
+		})
+
+		it("returns the params of the next page from the Link header", func() {
+			body, next, err := apiClient.GetNext("/my/test/endpoint", "per_page=100")
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(body)).To(Equal("some body"))
+			Expect(next).To(Equal([]string{"per_page=100", "after=Y3Vyc29y%3D"}))
+		})
+
+		context("on the last page", func() {
+			it.Before(func() {
+				httpClient.DoCall.Returns.Response.Header = http.Header{}
+			})
+
+			it("returns no params", func() {
+				_, next, err := apiClient.GetNext("/my/test/endpoint", "per_page=100")
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(next).To(BeEmpty())
+			})
+		})
+	})
 }
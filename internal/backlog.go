@@ -0,0 +1,276 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// BacklogConfig sets the length of the periods a backlog report counts opened
+// and closed issues in.
+type BacklogConfig struct {
+	Period time.Duration `yaml:"period"`
+}
+
+func (b BacklogConfig) Validate() error {
+	if b.Period <= 0 {
+		return fmt.Errorf("period: must be positive, got %s", b.Period)
+	}
+	return nil
+}
+
+const day = 24 * time.Hour
+
+// ageBuckets group open issues by age; the last bucket has no upper bound.
+var ageBuckets = []struct {
+	name  string
+	under time.Duration
+}{
+	{"< 1 week", 7 * day},
+	{"1-4 weeks", 28 * day},
+	{"1-3 months", 91 * day},
+	{"3-12 months", 365 * day},
+	{"> 1 year", 0},
+}
+
+// BacklogPeriod counts the issues opened and closed in a period, and the
+// backlog of open issues at its end.
+type BacklogPeriod struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Opened  int       `json:"opened"`
+	Closed  int       `json:"closed"`
+	Net     int       `json:"net"`
+	Backlog int       `json:"backlog"`
+}
+
+type AgeCount struct {
+	Age    string `json:"age"`
+	Issues int    `json:"issues"`
+}
+
+// BacklogReport says whether a repository, or an org's repositories together,
+// keep up with their inflow of issues: how many were opened and closed over
+// the window, how the backlog grew, and how old the open issues are.
+type BacklogReport struct {
+	Name          string          `json:"name"`
+	Opened        int             `json:"opened"`
+	Closed        int             `json:"closed"`
+	Net           int             `json:"net"`
+	Open          int             `json:"open"`
+	MedianOpenAge float64         `json:"median_open_age_days"`
+	Periods       []BacklogPeriod `json:"periods"`
+	Ages          []AgeCount      `json:"ages"`
+
+	openAges []float64
+}
+
+type BacklogSummary struct {
+	Repositories []BacklogReport `json:"repositories"`
+	Orgs         []BacklogReport `json:"orgs"`
+}
+
+// splitPeriods divides the window before now into periods of the given length,
+// as start and end times. The last period is cut short at now.
+func splitPeriods(now time.Time, window, period time.Duration) [][2]time.Time {
+	var periods [][2]time.Time
+	for start := now.Add(-window); start.Before(now); start = start.Add(period) {
+		end := start.Add(period)
+		if end.After(now) {
+			end = now
+		}
+		periods = append(periods, [2]time.Time{start, end})
+	}
+	return periods
+}
+
+// GetBacklogIssues returns the repository's issues that were open at some
+// point since since: those updated since then, which includes every issue
+// opened or closed since, and those still open.
+func GetBacklogIssues(client Client, repo Repository, since time.Time) ([]Issue, error) {
+	recent, err := repo.GetIssues(client, "all", since)
+	if err != nil {
+		return nil, err
+	}
+	open, err := repo.GetIssues(client, "open", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]struct{})
+	var issues []Issue
+	for _, issue := range append(recent, open...) {
+		if _, duplicate := seen[issue.Number]; duplicate {
+			continue
+		}
+		seen[issue.Number] = struct{}{}
+		issues = append(issues, issue)
+	}
+	return issues, nil
+}
+
+// NewBacklogReport counts a repository's issues, leaving out pull requests, in
+// periods of the given length from now-window until now.
+func NewBacklogReport(name string, issues []Issue, now time.Time, window, period time.Duration) (BacklogReport, error) {
+	report := BacklogReport{Name: name}
+	for _, bounds := range splitPeriods(now, window, period) {
+		report.Periods = append(report.Periods, BacklogPeriod{Start: bounds[0], End: bounds[1]})
+	}
+	for _, bucket := range ageBuckets {
+		report.Ages = append(report.Ages, AgeCount{Age: bucket.name})
+	}
+
+	for _, issue := range issues {
+		if issue.IsPullRequest() {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return BacklogReport{}, fmt.Errorf("could not parse creation time of %s#%d: %s", name, issue.Number, err)
+		}
+		var closed time.Time
+		if issue.ClosedAt != "" {
+			closed, err = time.Parse(time.RFC3339, issue.ClosedAt)
+			if err != nil {
+				return BacklogReport{}, fmt.Errorf("could not parse close time of %s#%d: %s", name, issue.Number, err)
+			}
+		}
+
+		for i := range report.Periods {
+			p := &report.Periods[i]
+			if !created.Before(p.Start) && created.Before(p.End) {
+				p.Opened++
+			}
+			if !closed.IsZero() && !closed.Before(p.Start) && closed.Before(p.End) {
+				p.Closed++
+			}
+			if created.Before(p.End) && (closed.IsZero() || !closed.Before(p.End)) {
+				p.Backlog++
+			}
+		}
+
+		if closed.IsZero() {
+			age := now.Sub(created)
+			report.Open++
+			report.openAges = append(report.openAges, age.Hours()/24)
+			for i, bucket := range ageBuckets {
+				if bucket.under == 0 || age < bucket.under {
+					report.Ages[i].Issues++
+					break
+				}
+			}
+		}
+	}
+
+	report.total()
+	return report, nil
+}
+
+// total sums the periods and takes the median age of the open issues.
+func (r *BacklogReport) total() {
+	r.Opened, r.Closed = 0, 0
+	for i := range r.Periods {
+		r.Periods[i].Net = r.Periods[i].Opened - r.Periods[i].Closed
+		r.Opened += r.Periods[i].Opened
+		r.Closed += r.Periods[i].Closed
+	}
+	r.Net = r.Opened - r.Closed
+	r.MedianOpenAge = Median(r.openAges)
+}
+
+// NewBacklogSummary orders the repository reports by name and rolls them up
+// into one report per org, the owner part of their names. The reports must
+// have been made with the same now, window and period.
+func NewBacklogSummary(repos []BacklogReport) BacklogSummary {
+	summary := BacklogSummary{Repositories: repos, Orgs: []BacklogReport{}}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+
+	orgs := make(map[string]*BacklogReport)
+	var names []string
+	for _, repo := range summary.Repositories {
+		name := ownerOf(repo.Name)
+
+		org, ok := orgs[name]
+		if !ok {
+			org = &BacklogReport{Name: name}
+			org.Periods = append(org.Periods, repo.Periods...)
+			for i := range org.Periods {
+				org.Periods[i].Opened, org.Periods[i].Closed, org.Periods[i].Backlog = 0, 0, 0
+			}
+			for _, age := range repo.Ages {
+				org.Ages = append(org.Ages, AgeCount{Age: age.Age})
+			}
+			orgs[name] = org
+			names = append(names, name)
+		}
+
+		for i, period := range repo.Periods {
+			org.Periods[i].Opened += period.Opened
+			org.Periods[i].Closed += period.Closed
+			org.Periods[i].Backlog += period.Backlog
+		}
+		for i, age := range repo.Ages {
+			org.Ages[i].Issues += age.Issues
+		}
+		org.Open += repo.Open
+		org.openAges = append(org.openAges, repo.openAges...)
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		orgs[name].total()
+		summary.Orgs = append(summary.Orgs, *orgs[name])
+	}
+	return summary
+}
+
+func (s BacklogSummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		header := "REPOSITORY\tOPENED\tCLOSED\tNET\tOPEN\tMEDIAN OPEN AGE (DAYS)"
+		for _, bucket := range ageBuckets {
+			header += "\t" + strings.ToUpper(bucket.name)
+		}
+		fmt.Fprintln(table, header)
+		for _, repo := range s.Repositories {
+			writeBacklogRow(table, repo.Name, repo)
+		}
+		for _, org := range s.Orgs {
+			writeBacklogRow(table, org.Name+" (org)", org)
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w)
+		table = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ORG\tPERIOD\tOPENED\tCLOSED\tNET\tBACKLOG")
+		for _, org := range s.Orgs {
+			for _, period := range org.Periods {
+				fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%+d\t%d\n", org.Name, period.Start.Format("2006-01-02"),
+					period.Opened, period.Closed, period.Net, period.Backlog)
+			}
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeBacklogRow(w io.Writer, name string, report BacklogReport) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%+d\t%d\t%.0f", name, report.Opened, report.Closed, report.Net, report.Open, report.MedianOpenAge)
+	for _, age := range report.Ages {
+		fmt.Fprintf(w, "\t%d", age.Issues)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,139 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testBacklog(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var issues []Issue
+
+	issue := func(number int, created, closed string) Issue {
+		issue := Issue{Number: number, CreatedAt: created, ClosedAt: closed, State: "open"}
+		if closed != "" {
+			issue.State = "closed"
+		}
+		return issue
+	}
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+
+		pullRequest := issue(4, "2001-01-20T20:20:20Z", "")
+		pullRequest.PullRequest = &PullRequestLinks{}
+		issues = []Issue{
+			issue(1, "2000-12-01T20:20:20Z", ""),
+			issue(2, "2001-01-18T20:20:20Z", "2001-01-25T20:20:20Z"),
+			issue(3, "2001-01-26T20:20:20Z", ""),
+			pullRequest,
+			issue(5, "2001-01-01T20:20:20Z", "2001-01-19T20:20:20Z"),
+		}
+	})
+
+	context("NewBacklogReport", func() {
+		it("counts opened and closed issues and the backlog per period", func() {
+			report, err := NewBacklogReport("example-org/example-repo", issues, now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report.Periods).To(Equal([]BacklogPeriod{
+				{Start: now.Add(-14 * 24 * time.Hour), End: now.Add(-7 * 24 * time.Hour), Opened: 1, Closed: 1, Net: 0, Backlog: 2},
+				{Start: now.Add(-7 * 24 * time.Hour), End: now, Opened: 1, Closed: 1, Net: 0, Backlog: 2},
+			}))
+			Expect(report.Opened).To(Equal(2))
+			Expect(report.Closed).To(Equal(2))
+			Expect(report.Open).To(Equal(2))
+		})
+
+		it("breaks the open issues down by age", func() {
+			report, err := NewBacklogReport("example-org/example-repo", issues, now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(report.MedianOpenAge).To(Equal(33.0))
+			Expect(report.Ages).To(Equal([]AgeCount{
+				{Age: "< 1 week", Issues: 1},
+				{Age: "1-4 weeks", Issues: 0},
+				{Age: "1-3 months", Issues: 1},
+				{Age: "3-12 months", Issues: 0},
+				{Age: "> 1 year", Issues: 0},
+			}))
+		})
+
+		it("ends the last period now", func() {
+			report, err := NewBacklogReport("example-org/example-repo", nil, now, 10*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(report.Periods).To(HaveLen(2))
+			Expect(report.Periods[1].End).To(Equal(now))
+		})
+
+		it("fails on a malformed time", func() {
+			_, err := NewBacklogReport("example-org/example-repo", []Issue{issue(7, "yesterday", "")}, now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).To(MatchError(ContainSubstring("could not parse creation time of example-org/example-repo#7")))
+		})
+	})
+
+	context("NewBacklogSummary", func() {
+		it("rolls the repositories up per org", func() {
+			some, err := NewBacklogReport("example-org/some-repo", issues, now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			other, err := NewBacklogReport("example-org/other-repo", issues[2:3], now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			summary := NewBacklogSummary([]BacklogReport{some, other})
+			Expect(summary.Repositories[0].Name).To(Equal("example-org/other-repo"))
+			Expect(summary.Orgs).To(HaveLen(1))
+
+			org := summary.Orgs[0]
+			Expect(org.Name).To(Equal("example-org"))
+			Expect(org.Opened).To(Equal(3))
+			Expect(org.Closed).To(Equal(2))
+			Expect(org.Net).To(Equal(1))
+			Expect(org.Open).To(Equal(3))
+			Expect(org.MedianOpenAge).To(Equal(5.0))
+			Expect(org.Periods[1].Backlog).To(Equal(3))
+			Expect(org.Ages[0].Issues).To(Equal(2))
+		})
+
+		it("writes a text report", func() {
+			report, err := NewBacklogReport("example-org/example-repo", issues, now, 14*24*time.Hour, 7*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			var output bytes.Buffer
+			Expect(NewBacklogSummary([]BacklogReport{report}).Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  2       2       +0   2     33"))
+			Expect(output.String()).To(ContainSubstring("example-org  2001-01-24  1       1       +0   2"))
+		})
+	})
+
+	context("GetBacklogIssues", func() {
+		it("fetches the issues updated in the window and every open one", func() {
+			fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server := fakegithub.NewServer(fixture)
+			defer server.Close()
+
+			client, err := NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			issues, err := GetBacklogIssues(&client, Repository{Name: "example-org/example-repo"}, time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC))
+			Expect(err).NotTo(HaveOccurred())
+
+			var numbers []int
+			for _, issue := range issues {
+				numbers = append(numbers, issue.Number)
+			}
+			Expect(numbers).To(ConsistOf(1, 2, 3, 4, 5))
+			Expect(server.Handler.Requests()).To(ContainElement("GET /repos/example-org/example-repo/issues?per_page=100&state=all&since=2001-01-01T00:00:00Z&page=1"))
+		})
+	})
+}
@@ -0,0 +1,335 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// BusFactorConfig sets how many of the most active people the top share is
+// taken over, and the top share above which a repository is at risk.
+type BusFactorConfig struct {
+	TopN      int     `yaml:"top_n"`
+	Threshold float64 `yaml:"threshold"`
+}
+
+func (b BusFactorConfig) Validate() error {
+	if b.TopN < 1 {
+		return fmt.Errorf("top_n: must be at least 1, got %d", b.TopN)
+	}
+	if b.Threshold <= 0 || b.Threshold > 1 {
+		return fmt.Errorf("threshold: must be greater than 0 and at most 1, got %g", b.Threshold)
+	}
+	return nil
+}
+
+// MaintainerRole is the kind of maintainer work a person did.
+type MaintainerRole string
+
+const (
+	RoleFirstResponse MaintainerRole = "first_response"
+	RoleReview        MaintainerRole = "review"
+	RoleMerge         MaintainerRole = "merge"
+)
+
+// MaintainerActivity is one first response, review or merge, done on the
+// issue or pull request Number at At.
+type MaintainerActivity struct {
+	Repo   string
+	Number int
+	Login  string
+	Role   MaintainerRole
+	At     time.Time
+}
+
+// PersonActivity counts what one person did.
+type PersonActivity struct {
+	Login          string `json:"login"`
+	FirstResponses int    `json:"first_responses"`
+	Reviews        int    `json:"reviews"`
+	Merges         int    `json:"merges"`
+	Total          int    `json:"total"`
+}
+
+// BusFactorReport says how concentrated the maintainer work on a repository,
+// or on an org's repositories together, is. TopShare is the share of the work
+// done by the TopN most active people, and Gini the Gini coefficient of the
+// work of everyone who did any: 0 when they all did as much, approaching 1
+// when one of them did nearly all of it. Cover50 and Cover80 are the fewest
+// people who did at least half and 80% of the work, most active first. A
+// repository is at risk when its TopShare is above the threshold; an org
+// lists its repositories at risk.
+type BusFactorReport struct {
+	Name        string           `json:"name"`
+	Activity    int              `json:"activity"`
+	People      []PersonActivity `json:"people"`
+	TopN        int              `json:"top_n"`
+	TopShare    float64          `json:"top_share"`
+	Gini        float64          `json:"gini"`
+	Cover50     []string         `json:"cover_50"`
+	Cover80     []string         `json:"cover_80"`
+	AtRisk      bool             `json:"at_risk"`
+	AtRiskRepos []string         `json:"at_risk_repos,omitempty"`
+}
+
+type BusFactorSummary struct {
+	Repositories []BusFactorReport `json:"repositories"`
+	Orgs         []BusFactorReport `json:"orgs"`
+}
+
+// FirstResponders returns who responded first to each of the repository's
+// issues that have a response.
+func FirstResponders(results RepoResults) []MaintainerActivity {
+	var activity []MaintainerActivity
+	for _, result := range results.Results {
+		if result.Status == StatusResponded {
+			activity = append(activity, MaintainerActivity{Repo: results.Name, Number: result.Issue.Number, Login: result.Responder(),
+				Role: RoleFirstResponse, At: result.Response.At})
+		}
+	}
+	return activity
+}
+
+type restReview struct {
+	User struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"user"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+}
+
+// GetReviewActivity returns the reviews and merges of the repository's pull
+// requests updated since since that were submitted or done since then.
+// Reviews by a pull request's own author and pending reviews are left out, as
+// is the work of ignored users and bots.
+func GetReviewActivity(client Client, repo Repository, since time.Time, options ContactOptions) ([]MaintainerActivity, error) {
+	issues, err := repo.GetIssues(client, "all", since)
+	if err != nil {
+		return nil, err
+	}
+
+	var activity []MaintainerActivity
+	add := func(number int, login, userType, at string, role MaintainerRole) error {
+		if ignoredActor(login, userType, options.IgnoredUsers) || options.Bots.IsBot(login) {
+			return nil
+		}
+		acted, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("could not parse activity time on %s#%d: %s", repo.Name, number, err)
+		}
+		if !acted.Before(since) {
+			activity = append(activity, MaintainerActivity{Repo: repo.Name, Number: number, Login: login, Role: role, At: acted})
+		}
+		return nil
+	}
+
+	for _, issue := range issues {
+		if !issue.IsPullRequest() {
+			continue
+		}
+
+		reviews := []restReview{}
+		err = getAllPages(client, fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo.Name, issue.Number), func(body []byte) (int, error) {
+			page := []restReview{}
+			err := json.Unmarshal(body, &page)
+			reviews = append(reviews, page...)
+			return len(page), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting reviews of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		for _, review := range reviews {
+			if review.State == "PENDING" || review.User.Login == issue.User.Login {
+				continue
+			}
+			if err := add(issue.Number, review.User.Login, review.User.Type, review.SubmittedAt, RoleReview); err != nil {
+				return nil, err
+			}
+		}
+
+		events := []restIssueEvent{}
+		err = getAllPages(client, fmt.Sprintf("/repos/%s/issues/%d/events", repo.Name, issue.Number), func(body []byte) (int, error) {
+			page := []restIssueEvent{}
+			err := json.Unmarshal(body, &page)
+			events = append(events, page...)
+			return len(page), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting events of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		for _, event := range events {
+			if event.Event != "merged" {
+				continue
+			}
+			if err := add(issue.Number, event.Actor.Login, event.Actor.Type, event.CreatedAt, RoleMerge); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return activity, nil
+}
+
+// CombineMaintainerActivity adds reviews and merges to first responses,
+// leaving out the reviews that were a pull request's first response, which
+// are already counted as that.
+func CombineMaintainerActivity(responses, reviews []MaintainerActivity) []MaintainerActivity {
+	type work struct {
+		repo   string
+		number int
+		login  string
+		at     int64
+	}
+	responded := make(map[work]bool)
+	for _, response := range responses {
+		responded[work{response.Repo, response.Number, response.Login, response.At.Unix()}] = true
+	}
+
+	combined := append([]MaintainerActivity{}, responses...)
+	for _, review := range reviews {
+		if review.Role == RoleReview && responded[work{review.Repo, review.Number, review.Login, review.At.Unix()}] {
+			continue
+		}
+		combined = append(combined, review)
+	}
+	return combined
+}
+
+// NewBusFactorSummary reports on the maintainer work of each repository and
+// of each org, whose people are counted together across its repositories.
+func NewBusFactorSummary(activity []MaintainerActivity, config BusFactorConfig) BusFactorSummary {
+	byRepo := make(map[string][]MaintainerActivity)
+	byOrg := make(map[string][]MaintainerActivity)
+	for _, item := range activity {
+		byRepo[item.Repo] = append(byRepo[item.Repo], item)
+		byOrg[ownerOf(item.Repo)] = append(byOrg[ownerOf(item.Repo)], item)
+	}
+
+	summary := BusFactorSummary{Repositories: []BusFactorReport{}, Orgs: []BusFactorReport{}}
+	for name, items := range byRepo {
+		summary.Repositories = append(summary.Repositories, NewBusFactorReport(name, items, config))
+	}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+
+	for name, items := range byOrg {
+		org := NewBusFactorReport(name, items, config)
+		for _, repo := range summary.Repositories {
+			if repo.AtRisk && ownerOf(repo.Name) == name {
+				org.AtRiskRepos = append(org.AtRiskRepos, repo.Name)
+			}
+		}
+		summary.Orgs = append(summary.Orgs, org)
+	}
+	sort.Slice(summary.Orgs, func(i, j int) bool { return summary.Orgs[i].Name < summary.Orgs[j].Name })
+	return summary
+}
+
+// NewBusFactorReport counts everyone's work and how concentrated it is.
+func NewBusFactorReport(name string, activity []MaintainerActivity, config BusFactorConfig) BusFactorReport {
+	report := BusFactorReport{Name: name, TopN: config.TopN, People: []PersonActivity{}, Cover50: []string{}, Cover80: []string{}}
+
+	people := make(map[string]*PersonActivity)
+	for _, item := range activity {
+		person, ok := people[item.Login]
+		if !ok {
+			person = &PersonActivity{Login: item.Login}
+			people[item.Login] = person
+		}
+		switch item.Role {
+		case RoleFirstResponse:
+			person.FirstResponses++
+		case RoleReview:
+			person.Reviews++
+		case RoleMerge:
+			person.Merges++
+		}
+		person.Total++
+		report.Activity++
+	}
+	for _, person := range people {
+		report.People = append(report.People, *person)
+	}
+	sort.Slice(report.People, func(i, j int) bool {
+		if report.People[i].Total != report.People[j].Total {
+			return report.People[i].Total > report.People[j].Total
+		}
+		return report.People[i].Login < report.People[j].Login
+	})
+	if report.Activity == 0 {
+		return report
+	}
+
+	total := float64(report.Activity)
+	covered := 0
+	for i, person := range report.People {
+		if i < config.TopN {
+			report.TopShare += float64(person.Total) / total
+		}
+		if float64(covered) < 0.5*total {
+			report.Cover50 = append(report.Cover50, person.Login)
+		}
+		if float64(covered) < 0.8*total {
+			report.Cover80 = append(report.Cover80, person.Login)
+		}
+		covered += person.Total
+	}
+
+	// With the people in ascending order of work x_i, i from 1 to n, the Gini
+	// coefficient is 2 Σ i·x_i / (n Σ x_i) - (n+1)/n.
+	n := float64(len(report.People))
+	var weighted float64
+	for i := range report.People {
+		weighted += float64(len(report.People)-i) * float64(report.People[i].Total)
+	}
+	report.Gini = 2*weighted/(n*total) - (n+1)/n
+
+	report.AtRisk = report.TopShare > config.Threshold
+	return report
+}
+
+func (s BusFactorSummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tACTIVITY\tPEOPLE\tTOP SHARE\tGINI\t50% BY\t80% BY\tAT RISK")
+		for _, repo := range s.Repositories {
+			writeBusFactorRow(table, repo.Name, repo)
+		}
+		for _, org := range s.Orgs {
+			writeBusFactorRow(table, org.Name+" (org)", org)
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+
+		for _, org := range s.Orgs {
+			fmt.Fprintf(w, "\n%s: %d repositories at risk", org.Name, len(org.AtRiskRepos))
+			if len(org.AtRiskRepos) > 0 {
+				fmt.Fprintf(w, ": %s", strings.Join(org.AtRiskRepos, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeBusFactorRow(w io.Writer, name string, report BusFactorReport) {
+	atRisk := "no"
+	if report.AtRisk {
+		atRisk = "yes"
+	}
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.0f%%\t%.2f\t%d (%s)\t%d (%s)\t%s\n", name, report.Activity, len(report.People),
+		report.TopShare*100, report.Gini, len(report.Cover50), strings.Join(report.Cover50, ", "),
+		len(report.Cover80), strings.Join(report.Cover80, ", "), atRisk)
+}
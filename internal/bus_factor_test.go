@@ -0,0 +1,151 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testBusFactor(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var config BusFactorConfig
+	var activity []MaintainerActivity
+
+	work := func(repo, login string, role MaintainerRole, times int) []MaintainerActivity {
+		var items []MaintainerActivity
+		for i := 0; i < times; i++ {
+			items = append(items, MaintainerActivity{Repo: repo, Login: login, Role: role})
+		}
+		return items
+	}
+
+	it.Before(func() {
+		config = BusFactorConfig{TopN: 1, Threshold: 0.5}
+		activity = nil
+		activity = append(activity, work("example-org/example-repo", "alice", RoleFirstResponse, 3)...)
+		activity = append(activity, work("example-org/example-repo", "alice", RoleReview, 2)...)
+		activity = append(activity, work("example-org/example-repo", "alice", RoleMerge, 1)...)
+		activity = append(activity, work("example-org/example-repo", "bob", RoleReview, 3)...)
+		activity = append(activity, work("example-org/example-repo", "carol", RoleFirstResponse, 1)...)
+		activity = append(activity, work("example-org/other-repo", "dave", RoleMerge, 1)...)
+		activity = append(activity, work("example-org/other-repo", "erin", RoleReview, 1)...)
+	})
+
+	context("NewBusFactorReport", func() {
+		it("measures how concentrated the work is", func() {
+			report := NewBusFactorReport("example-org/example-repo", activity[:10], config)
+
+			Expect(report.Activity).To(Equal(10))
+			Expect(report.People).To(Equal([]PersonActivity{
+				{Login: "alice", FirstResponses: 3, Reviews: 2, Merges: 1, Total: 6},
+				{Login: "bob", Reviews: 3, Total: 3},
+				{Login: "carol", FirstResponses: 1, Total: 1},
+			}))
+			Expect(report.TopShare).To(BeNumerically("~", 0.6))
+			Expect(report.Gini).To(BeNumerically("~", 1.0/3))
+			Expect(report.Cover50).To(Equal([]string{"alice"}))
+			Expect(report.Cover80).To(Equal([]string{"alice", "bob"}))
+			Expect(report.AtRisk).To(BeTrue())
+		})
+
+		it("takes the top share over the configured number of people", func() {
+			config.TopN = 2
+			report := NewBusFactorReport("example-org/example-repo", activity[:10], config)
+			Expect(report.TopShare).To(BeNumerically("~", 0.9))
+		})
+
+		it("has no concentration when the work is shared equally", func() {
+			report := NewBusFactorReport("example-org/other-repo", activity[10:], config)
+			Expect(report.TopShare).To(BeNumerically("~", 0.5))
+			Expect(report.Gini).To(BeNumerically("~", 0))
+			Expect(report.AtRisk).To(BeFalse())
+		})
+
+		it("is not at risk without any work", func() {
+			report := NewBusFactorReport("example-org/quiet-repo", nil, config)
+			Expect(report.Activity).To(Equal(0))
+			Expect(report.Cover50).To(BeEmpty())
+			Expect(report.AtRisk).To(BeFalse())
+		})
+	})
+
+	context("NewBusFactorSummary", func() {
+		it("flags the repositories at risk in the org report", func() {
+			summary := NewBusFactorSummary(activity, config)
+
+			Expect(summary.Repositories).To(HaveLen(2))
+			Expect(summary.Repositories[0].AtRisk).To(BeTrue())
+			Expect(summary.Repositories[1].AtRisk).To(BeFalse())
+			Expect(summary.Orgs).To(HaveLen(1))
+			Expect(summary.Orgs[0].Activity).To(Equal(12))
+			Expect(summary.Orgs[0].AtRiskRepos).To(Equal([]string{"example-org/example-repo"}))
+		})
+
+		it("writes a text report", func() {
+			var output bytes.Buffer
+			Expect(NewBusFactorSummary(activity, config).Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  10        3       60%        0.33  1 (alice)"))
+			Expect(output.String()).To(ContainSubstring("example-org: 1 repositories at risk: example-org/example-repo"))
+		})
+	})
+
+	context("FirstResponders", func() {
+		it("returns who responded first to each issue", func() {
+			at := time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC)
+			results := RepoResults{Name: "example-org/example-repo", Results: []FirstContact{
+				{Issue: IssueRef{Number: 7}, Status: StatusResponded, Response: &Response{Actor: "alice", At: at}},
+				{Status: StatusAwaiting},
+			}}
+			Expect(FirstResponders(results)).To(Equal([]MaintainerActivity{
+				{Repo: "example-org/example-repo", Number: 7, Login: "alice", Role: RoleFirstResponse, At: at},
+			}))
+		})
+	})
+
+	context("CombineMaintainerActivity", func() {
+		it("does not count a review that was the first response again", func() {
+			at := time.Date(2001, time.January, 11, 20, 20, 20, 0, time.UTC)
+			responses := []MaintainerActivity{
+				{Repo: "example-org/example-repo", Number: 5, Login: "alice", Role: RoleFirstResponse, At: at},
+			}
+			reviews := []MaintainerActivity{
+				{Repo: "example-org/example-repo", Number: 5, Login: "alice", Role: RoleReview, At: at},
+				{Repo: "example-org/example-repo", Number: 5, Login: "alice", Role: RoleReview, At: at.Add(time.Hour)},
+				{Repo: "example-org/example-repo", Number: 5, Login: "bob", Role: RoleMerge, At: at},
+			}
+			Expect(CombineMaintainerActivity(responses, reviews)).To(Equal([]MaintainerActivity{
+				responses[0], reviews[1], reviews[2],
+			}))
+		})
+	})
+
+	context("GetReviewActivity", func() {
+		it("gathers reviews and merges, leaving out the author's own reviews", func() {
+			fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server := fakegithub.NewServer(fixture)
+			defer server.Close()
+
+			client, err := NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			activity, err := GetReviewActivity(&client, Repository{Name: "example-org/example-repo"},
+				time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC), ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(activity).To(ConsistOf(
+				MaintainerActivity{Repo: "example-org/example-repo", Number: 5, Login: "other-maintainer", Role: RoleReview,
+					At: time.Date(2001, time.January, 11, 20, 20, 20, 0, time.UTC)},
+				MaintainerActivity{Repo: "example-org/example-repo", Number: 5, Login: "maintainer", Role: RoleMerge,
+					At: time.Date(2001, time.January, 12, 20, 20, 20, 0, time.UTC)},
+			))
+		})
+	})
+}
@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BusinessHours restricts elapsed time measurements to working hours. The
+// zero value is disabled, in which case all wall-clock time counts.
+type BusinessHours struct {
+	Start    string   `yaml:"start"`
+	End      string   `yaml:"end"`
+	Days     []string `yaml:"days"`
+	Timezone string   `yaml:"timezone"`
+}
+
+var defaultBusinessDays = []string{"monday", "tuesday", "wednesday", "thursday", "friday"}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+func (b BusinessHours) Enabled() bool {
+	return b.Start != "" || b.End != ""
+}
+
+func (b BusinessHours) Validate() error {
+	if !b.Enabled() {
+		return nil
+	}
+	_, err := b.compile()
+	return err
+}
+
+// Elapsed returns how much of the time between from and to falls within
+// business hours.
+func (b BusinessHours) Elapsed(from, to time.Time) (time.Duration, error) {
+	hours, err := b.compile()
+	if err != nil {
+		return 0, err
+	}
+
+	from = from.In(hours.location)
+	to = to.In(hours.location)
+
+	var elapsed time.Duration
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, hours.location)
+	for day.Before(to) {
+		if _, ok := hours.days[day.Weekday()]; ok {
+			open := hours.at(day, hours.open)
+			close := hours.at(day, hours.close)
+			if open.Before(from) {
+				open = from
+			}
+			if close.After(to) {
+				close = to
+			}
+			if close.After(open) {
+				elapsed += close.Sub(open)
+			}
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, hours.location)
+	}
+	return elapsed, nil
+}
+
+type compiledHours struct {
+	open     time.Duration
+	close    time.Duration
+	days     map[time.Weekday]struct{}
+	location *time.Location
+}
+
+func (b BusinessHours) compile() (compiledHours, error) {
+	open, err := parseClock(b.Start)
+	if err != nil {
+		return compiledHours{}, fmt.Errorf("start: %s", err)
+	}
+	close, err := parseClock(b.End)
+	if err != nil {
+		return compiledHours{}, fmt.Errorf("end: %s", err)
+	}
+	if close <= open {
+		return compiledHours{}, fmt.Errorf("end %q must be later than start %q", b.End, b.Start)
+	}
+
+	names := b.Days
+	if len(names) == 0 {
+		names = defaultBusinessDays
+	}
+	days := make(map[time.Weekday]struct{})
+	for _, name := range names {
+		day, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return compiledHours{}, fmt.Errorf("days: %q is not a day of the week", name)
+		}
+		days[day] = struct{}{}
+	}
+
+	location, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return compiledHours{}, fmt.Errorf("timezone: %s", err)
+	}
+
+	return compiledHours{open: open, close: close, days: days, location: location}, nil
+}
+
+// at returns the wall-clock time of day on the given date, which unlike
+// day.Add stays correct across daylight saving transitions.
+func (h compiledHours) at(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, int(clock.Minutes()), 0, 0, h.location)
+}
+
+func parseClock(value string) (time.Duration, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a time of day in HH:MM form", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
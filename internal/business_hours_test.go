@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testBusinessHours(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var hours BusinessHours
+
+	it.Before(func() {
+		hours = BusinessHours{Start: "09:00", End: "17:00", Timezone: "UTC"}
+	})
+
+	context("Elapsed", func() {
+		context("when both times fall on the same business day", func() {
+			it("returns the time between them", func() {
+				elapsed, err := hours.Elapsed(
+					time.Date(2001, time.January, 1, 10, 0, 0, 0, time.UTC),
+					time.Date(2001, time.January, 1, 12, 30, 0, 0, time.UTC))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(elapsed).To(Equal(150 * time.Minute))
+			})
+		})
+
+		context("when the times span a night", func() {
+			it("only counts business hours", func() {
+				elapsed, err := hours.Elapsed(
+					time.Date(2001, time.January, 1, 16, 0, 0, 0, time.UTC),
+					time.Date(2001, time.January, 2, 10, 0, 0, 0, time.UTC))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(elapsed).To(Equal(2 * time.Hour))
+			})
+		})
+
+		context("when the times span a weekend", func() {
+			it("skips the weekend", func() {
+				elapsed, err := hours.Elapsed(
+					time.Date(2001, time.January, 5, 16, 0, 0, 0, time.UTC),
+					time.Date(2001, time.January, 8, 10, 0, 0, 0, time.UTC))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(elapsed).To(Equal(2 * time.Hour))
+			})
+		})
+
+		context("when business days are configured", func() {
+			it.Before(func() {
+				hours.Days = []string{"Saturday"}
+			})
+
+			it("only counts those days", func() {
+				elapsed, err := hours.Elapsed(
+					time.Date(2001, time.January, 5, 9, 0, 0, 0, time.UTC),
+					time.Date(2001, time.January, 8, 17, 0, 0, 0, time.UTC))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(elapsed).To(Equal(8 * time.Hour))
+			})
+		})
+	})
+
+	context("Validate", func() {
+		it("accepts disabled business hours", func() {
+			Expect(BusinessHours{}.Validate()).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("rejects malformed times", func() {
+				hours.Start = "9am"
+				Expect(hours.Validate()).To(MatchError(`start: "9am" is not a time of day in HH:MM form`))
+			})
+
+			it("rejects unknown days", func() {
+				hours.Days = []string{"funday"}
+				Expect(hours.Validate()).To(MatchError(`days: "funday" is not a day of the week`))
+			})
+
+			it("rejects unknown timezones", func() {
+				hours.Timezone = "Mars/Olympus_Mons"
+				Expect(hours.Validate()).To(MatchError(ContainSubstring("timezone:")))
+			})
+		})
+	})
+}
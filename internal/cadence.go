@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type Commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Author struct {
+			Name string `json:"name"`
+		} `json:"author"`
+		Committer struct {
+			Date string `json:"date"`
+		} `json:"committer"`
+	} `json:"commit"`
+	// Author is nil when GitHub cannot match the commit's email to a user.
+	Author *struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"author"`
+}
+
+// Time is when the commit was committed, which for merged and rebased pull
+// requests is when it landed on the branch.
+func (c Commit) Time() (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, c.Commit.Committer.Date)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse commit time of %s: %s", c.SHA, err)
+	}
+	return at, nil
+}
+
+type Tag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+type Release struct {
+	TagName     string `json:"tag_name"`
+	Draft       bool   `json:"draft"`
+	PublishedAt string `json:"published_at"`
+}
+
+// ReleaseSource says what a repository's releases were counted from.
+type ReleaseSource string
+
+const (
+	SourceReleases ReleaseSource = "releases"
+	// SourceTags is used for repositories that tag versions without
+	// publishing GitHub releases.
+	SourceTags ReleaseSource = "tags"
+)
+
+// RepoCadence describes how often code lands on a repository's default branch
+// and how often it ships over the window. Committers are the people behind
+// its commits, by login or else by author name, leaving out ignored users and
+// bots, whose commits still count. DaysSinceLastCommit is nil if the
+// repository has no commits at all, and MedianDaysBetweenReleases is 0 with
+// fewer than two releases in the window.
+type RepoCadence struct {
+	Name                      string        `json:"name"`
+	Commits                   int           `json:"commits"`
+	CommitsPerWeek            float64       `json:"commits_per_week"`
+	Committers                int           `json:"committers"`
+	DaysSinceLastCommit       *float64      `json:"days_since_last_commit"`
+	Releases                  int           `json:"releases"`
+	ReleaseSource             ReleaseSource `json:"release_source"`
+	ReleasesPerMonth          float64       `json:"releases_per_month"`
+	MedianDaysBetweenReleases float64       `json:"median_days_between_releases"`
+}
+
+type CadenceReport struct {
+	Window       string        `json:"window"`
+	Repositories []RepoCadence `json:"repositories"`
+}
+
+// GetCadence fetches the repository's commits and releases over the window
+// before now and measures their cadence. Repositories without releases in the
+// window are measured by their tags instead. When no commit landed in the
+// window, the last one before it is fetched to tell how long ago that was.
+func GetCadence(client Client, repo Repository, now time.Time, window time.Duration, options ContactOptions) (RepoCadence, error) {
+	since := now.Add(-window)
+	commits, err := repo.GetCommits(client, since)
+	if err != nil {
+		return RepoCadence{}, err
+	}
+	if len(commits) == 0 {
+		last, err := repo.GetLastCommit(client, "")
+		if err != nil {
+			return RepoCadence{}, err
+		}
+		if last != nil {
+			commits = append(commits, *last)
+		}
+	}
+
+	source := SourceReleases
+	releases, err := repo.GetReleases(client, since)
+	if err != nil {
+		return RepoCadence{}, err
+	}
+	if len(releases) == 0 {
+		source = SourceTags
+		releases, err = repo.GetTags(client, since)
+		if err != nil {
+			return RepoCadence{}, err
+		}
+	}
+
+	cadence, err := NewRepoCadence(repo.Name, commits, releases, now, window, options)
+	if err != nil {
+		return RepoCadence{}, err
+	}
+	cadence.ReleaseSource = source
+	return cadence, nil
+}
+
+// NewRepoCadence measures the commits and releases made in the window before
+// now. Commits made before the window only count towards
+// DaysSinceLastCommit.
+func NewRepoCadence(name string, commits []Commit, releases []Release, now time.Time, window time.Duration, options ContactOptions) (RepoCadence, error) {
+	cadence := RepoCadence{Name: name, ReleaseSource: SourceReleases}
+	since := now.Add(-window)
+	weeks := window.Hours() / 24 / 7
+
+	committers := make(map[string]struct{})
+	var last time.Time
+	for _, commit := range commits {
+		at, err := commit.Time()
+		if err != nil {
+			return RepoCadence{}, err
+		}
+		if at.After(last) {
+			last = at
+		}
+		if at.Before(since) {
+			continue
+		}
+		cadence.Commits++
+
+		login, userType := commit.Commit.Author.Name, "User"
+		if commit.Author != nil {
+			login, userType = commit.Author.Login, commit.Author.Type
+		}
+		if !ignoredActor(login, userType, options.IgnoredUsers) && !options.Bots.IsBot(login) {
+			committers[login] = struct{}{}
+		}
+	}
+	cadence.Committers = len(committers)
+	cadence.CommitsPerWeek = float64(cadence.Commits) / weeks
+	if !last.IsZero() {
+		days := now.Sub(last).Hours() / 24
+		cadence.DaysSinceLastCommit = &days
+	}
+
+	var published []time.Time
+	for _, release := range releases {
+		at, err := time.Parse(time.RFC3339, release.PublishedAt)
+		if err != nil {
+			return RepoCadence{}, fmt.Errorf("could not parse publish time of %s %s: %s", name, release.TagName, err)
+		}
+		if !at.Before(since) {
+			published = append(published, at)
+		}
+	}
+	sort.Slice(published, func(i, j int) bool { return published[i].Before(published[j]) })
+	var gaps []float64
+	for i := 1; i < len(published); i++ {
+		gaps = append(gaps, published[i].Sub(published[i-1]).Hours()/24)
+	}
+	cadence.Releases = len(published)
+	cadence.ReleasesPerMonth = float64(cadence.Releases) / (weeks * 7 / 30)
+	cadence.MedianDaysBetweenReleases = Median(gaps)
+	return cadence, nil
+}
+
+// NewCadenceReport orders the repositories by name.
+func NewCadenceReport(repos []RepoCadence, window time.Duration) CadenceReport {
+	report := CadenceReport{Window: window.String(), Repositories: append([]RepoCadence{}, repos...)}
+	sort.Slice(report.Repositories, func(i, j int) bool { return report.Repositories[i].Name < report.Repositories[j].Name })
+	return report
+}
+
+func (r CadenceReport) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tCOMMITS\tCOMMITS/WEEK\tCOMMITTERS\tLAST COMMIT (DAYS)\tRELEASES\tRELEASES/MONTH\tMEDIAN BETWEEN RELEASES (DAYS)")
+		for _, repo := range r.Repositories {
+			lastCommit := "-"
+			if repo.DaysSinceLastCommit != nil {
+				lastCommit = fmt.Sprintf("%.0f", *repo.DaysSinceLastCommit)
+			}
+			releases := fmt.Sprintf("%d", repo.Releases)
+			if repo.ReleaseSource == SourceTags {
+				releases += " (tags)"
+			}
+			fmt.Fprintf(table, "%s\t%d\t%.1f\t%d\t%s\t%s\t%.1f\t%.0f\n", repo.Name, repo.Commits, repo.CommitsPerWeek,
+				repo.Committers, lastCommit, releases, repo.ReleasesPerMonth, repo.MedianDaysBetweenReleases)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,169 @@
+package internal_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testCadence(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var server *fakegithub.Server
+	var client APIClient
+	var repo Repository
+	var now time.Time
+
+	it.Before(func() {
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+
+		client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+		Expect(err).NotTo(HaveOccurred())
+		repo = Repository{Name: "example-org/example-repo", DefaultBranch: "main"}
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("Repository", func() {
+		it("gets the commits on the default branch", func() {
+			commits, err := repo.GetCommits(&client, now.Add(-30*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+
+			var shas []string
+			for _, commit := range commits {
+				shas = append(shas, commit.SHA)
+			}
+			Expect(shas).To(Equal([]string{"c5", "c4", "c3", "c2"}))
+			Expect(commits[0].Author).To(BeNil())
+			Expect(commits[0].Commit.Author.Name).To(Equal("Some Person"))
+			Expect(server.Handler.Requests()).To(ContainElement(
+				"GET /repos/example-org/example-repo/commits?per_page=100&sha=main&since=2001-01-01T20:20:20Z&page=1"))
+		})
+
+		it("gets the last commit of a tag", func() {
+			commit, err := repo.GetLastCommit(&client, "v1.1.0")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commit.SHA).To(Equal("c3"))
+		})
+
+		it("gets published releases, leaving out drafts", func() {
+			releases, err := repo.GetReleases(&client, now.Add(-30*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(Equal([]Release{
+				{TagName: "v1.2.0", PublishedAt: "2001-01-26T20:20:20Z"},
+				{TagName: "v1.1.0", PublishedAt: "2001-01-12T20:20:20Z"},
+			}))
+		})
+
+		it("dates tags by their commits", func() {
+			releases, err := repo.GetTags(&client, now.Add(-30*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(Equal([]Release{{TagName: "v1.1.0", PublishedAt: "2001-01-12T20:20:20Z"}}))
+		})
+
+		it("stops paging at the first release from before since", func() {
+			pages := &fakes.Client{}
+			pages.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+				releases := make([]string, 100)
+				for i := range releases {
+					published := now.Add(-time.Duration(i) * 24 * time.Hour).Format(time.RFC3339)
+					releases[i] = fmt.Sprintf(`{"tag_name": "v1.%d.0", "published_at": "%s"}`, 100-i, published)
+				}
+				return []byte("[" + strings.Join(releases, ",") + "]"), nil
+			}
+
+			releases, err := repo.GetReleases(pages, now.Add(-30*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(HaveLen(31))
+			Expect(pages.GetCall.CallCount).To(Equal(1))
+		})
+
+		it("finds recent tags listed after older ones", func() {
+			tags := &fakes.Client{}
+			tags.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+				switch params[len(params)-1] {
+				case "sha=v1.9.0":
+					return []byte(`[{"sha": "c1", "commit": {"committer": {"date": "2000-12-01T20:20:20Z"}}}]`), nil
+				case "sha=v1.10.0":
+					return []byte(`[{"sha": "c2", "commit": {"committer": {"date": "2001-01-30T20:20:20Z"}}}]`), nil
+				}
+				return []byte(`[{"name": "v1.9.0"}, {"name": "v1.10.0"}]`), nil
+			}
+
+			releases, err := repo.GetTags(tags, now.Add(-10*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(releases).To(Equal([]Release{{TagName: "v1.10.0", PublishedAt: "2001-01-30T20:20:20Z"}}))
+		})
+
+		it("finds no commits in an empty repository", func() {
+			empty := Repository{Name: "example-org/archived-repo"}
+			commits, err := empty.GetCommits(&client, now.Add(-30*24*time.Hour))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commits).To(BeEmpty())
+
+			commit, err := empty.GetLastCommit(&client, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(commit).To(BeNil())
+		})
+	})
+
+	context("GetCadence", func() {
+		it("measures commits and releases over the window", func() {
+			cadence, err := GetCadence(&client, repo, now, 30*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cadence.Commits).To(Equal(4))
+			Expect(cadence.CommitsPerWeek).To(BeNumerically("~", 4.0/30*7))
+			Expect(cadence.Committers).To(Equal(3))
+			Expect(*cadence.DaysSinceLastCommit).To(Equal(6.0))
+			Expect(cadence.Releases).To(Equal(2))
+			Expect(cadence.ReleaseSource).To(Equal(SourceReleases))
+			Expect(cadence.ReleasesPerMonth).To(Equal(2.0))
+			Expect(cadence.MedianDaysBetweenReleases).To(Equal(14.0))
+		})
+
+		it("falls back to the last commit and to tags", func() {
+			now = time.Date(2001, time.March, 31, 20, 20, 20, 0, time.UTC)
+			cadence, err := GetCadence(&client, repo, now, 30*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cadence.Commits).To(Equal(0))
+			Expect(*cadence.DaysSinceLastCommit).To(Equal(65.0))
+			Expect(cadence.Releases).To(Equal(0))
+			Expect(cadence.ReleaseSource).To(Equal(SourceTags))
+		})
+
+		it("has no commits or last commit for an empty repository", func() {
+			cadence, err := GetCadence(&client, Repository{Name: "example-org/archived-repo"}, now, 30*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cadence.Commits).To(Equal(0))
+			Expect(cadence.DaysSinceLastCommit).To(BeNil())
+		})
+	})
+
+	context("CadenceReport", func() {
+		it("writes a text report", func() {
+			cadence, err := GetCadence(&client, repo, now, 30*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			var output bytes.Buffer
+			Expect(NewCadenceReport([]RepoCadence{cadence}, 30*24*time.Hour).Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  4        0.9           3           6                   2         2.0             14"))
+		})
+	})
+}
@@ -0,0 +1,275 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// Cassette holds the HTTP interactions of a run so that the run can be
+// replayed offline. RecordedAt is the time the recorded run used as now; a
+// replay uses it as the current time, so that requests relative to now, such
+// as since=, ask for exactly what was recorded.
+//
+// On disk a cassette is the Cassette object followed by one Interaction object
+// per line, so that a recording only ever appends to it.
+type Cassette struct {
+	RecordedAt   time.Time     `json:"recorded_at"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// secretHeaders are recorded as "REDACTED" so that cassettes can be shared.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "Private-Token"}
+
+// secretFields are redacted in recorded JSON response bodies. The GitHub App
+// token exchange answers with the installation token in the body.
+var secretFields = []string{"token"}
+
+// LoadCassette reads a cassette written by a RecordingClient.
+func LoadCassette(path string) (Cassette, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Cassette{}, fmt.Errorf("reading cassette: %s", err)
+	}
+
+	var cassette Cassette
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	err = decoder.Decode(&cassette)
+	for err == nil {
+		var interaction Interaction
+		err = decoder.Decode(&interaction)
+		if err == nil {
+			cassette.Interactions = append(cassette.Interactions, interaction)
+		}
+	}
+	if err != io.EOF {
+		return Cassette{}, fmt.Errorf("parsing cassette %s: %s", path, err)
+	}
+	return cassette, nil
+}
+
+// RecordingClient passes requests on to another HTTPClient and appends every
+// request and response to the cassette file at path as they happen. The run
+// being recorded should use FixedClock(recordedAt) as its clock, so that a
+// replay asks for exactly what was recorded.
+type RecordingClient struct {
+	client     HTTPClient
+	path       string
+	recordedAt time.Time
+	mutex      sync.Mutex
+	started    bool
+}
+
+func NewRecordingClient(client HTTPClient, path string, recordedAt time.Time) *RecordingClient {
+	return &RecordingClient{client: client, path: path, recordedAt: recordedAt.UTC()}
+}
+
+func (c *RecordingClient) Do(req *http.Request) (*http.Response, error) {
+	request, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("recording response: %s", err)
+	}
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = c.append(Interaction{
+		Request: request,
+		Response: RecordedResponse{
+			StatusCode: response.StatusCode,
+			Header:     scrub(response.Header),
+			Body:       scrubBody(body),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// append writes an interaction to the end of the cassette, starting a new
+// cassette on the first one.
+func (c *RecordingClient) append(interaction Interaction) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	var contents []byte
+	if !c.started {
+		header, err := json.Marshal(Cassette{RecordedAt: c.recordedAt, Interactions: []Interaction{}})
+		if err != nil {
+			return fmt.Errorf("recording response: %s", err)
+		}
+		contents = append(header, '\n')
+	}
+	line, err := json.Marshal(interaction)
+	if err != nil {
+		return fmt.Errorf("recording response: %s", err)
+	}
+	contents = append(contents, line...)
+	contents = append(contents, '\n')
+
+	mode := os.O_WRONLY | os.O_APPEND
+	if !c.started {
+		mode = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	file, err := os.OpenFile(c.path, mode, 0600)
+	if err != nil {
+		return fmt.Errorf("writing cassette: %s", err)
+	}
+	_, err = file.Write(contents)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing cassette: %s", err)
+	}
+	c.started = true
+	return nil
+}
+
+// ReplayingClient answers requests from a cassette without touching the
+// network. A request matches a recorded one with the same method, path, query
+// and body; headers are ignored. Matching recordings are replayed in the order
+// they were recorded, and the last one is repeated once they run out.
+type ReplayingClient struct {
+	cassette Cassette
+	mutex    sync.Mutex
+	used     []bool
+}
+
+func NewReplayingClient(cassette Cassette) *ReplayingClient {
+	return &ReplayingClient{cassette: cassette, used: make([]bool, len(cassette.Interactions))}
+}
+
+func (c *ReplayingClient) Do(req *http.Request) (*http.Response, error) {
+	request, err := recordRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	match := -1
+	for i, interaction := range c.cassette.Interactions {
+		if !interaction.Request.matches(request) {
+			continue
+		}
+		match = i
+		if !c.used[i] {
+			break
+		}
+	}
+	if match == -1 {
+		uri := request.Path
+		if request.Query != "" {
+			uri = fmt.Sprintf("%s?%s", uri, request.Query)
+		}
+		return nil, fmt.Errorf("no recorded response for %s %s", request.Method, uri)
+	}
+	c.used[match] = true
+
+	recorded := c.cassette.Interactions[match].Response
+	return &http.Response{
+		StatusCode: recorded.StatusCode,
+		Status:     fmt.Sprintf("%d %s", recorded.StatusCode, http.StatusText(recorded.StatusCode)),
+		Header:     recorded.Header.Clone(),
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(recorded.Body))),
+		Request:    req,
+	}, nil
+}
+
+// recordRequest captures what a request is matched by, putting its body back
+// so that it can still be sent.
+func recordRequest(req *http.Request) (RecordedRequest, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return RecordedRequest{}, fmt.Errorf("recording request: %s", err)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	return RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.EscapedPath(),
+		Query:  req.URL.Query().Encode(),
+		Header: scrub(req.Header),
+		Body:   string(body),
+	}, nil
+}
+
+func (r RecordedRequest) matches(other RecordedRequest) bool {
+	return r.Method == other.Method && r.Path == other.Path && r.Query == other.Query && r.Body == other.Body
+}
+
+func scrub(header http.Header) http.Header {
+	scrubbed := header.Clone()
+	for _, name := range secretHeaders {
+		if scrubbed.Get(name) != "" {
+			scrubbed.Set(name, "REDACTED")
+		}
+	}
+	return scrubbed
+}
+
+// scrubBody redacts secretFields in a JSON object body. Other bodies, and
+// objects without secrets, are recorded as they are.
+func scrubBody(body []byte) string {
+	var object map[string]json.RawMessage
+	if json.Unmarshal(body, &object) != nil {
+		return string(body)
+	}
+
+	scrubbed := false
+	for _, name := range secretFields {
+		if _, ok := object[name]; ok {
+			object[name] = json.RawMessage(`"REDACTED"`)
+			scrubbed = true
+		}
+	}
+	if !scrubbed {
+		return string(body)
+	}
+	contents, err := json.Marshal(object)
+	if err != nil {
+		return string(body)
+	}
+	return string(contents)
+}
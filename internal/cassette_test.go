@@ -0,0 +1,200 @@
+package internal_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testCassette(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var dir string
+	var path string
+	var server *fakegithub.Server
+	var recordedAt time.Time
+
+	get := func(httpClient HTTPClient, uri string) (*http.Response, string) {
+		req, err := http.NewRequest("GET", uri, nil)
+		Expect(err).NotTo(HaveOccurred())
+		req.Header.Set("Authorization", "token some-token")
+
+		resp, err := httpClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return resp, string(body)
+	}
+
+	it.Before(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "cassette")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "cassette.json")
+
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+		recordedAt = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+	})
+
+	it.After(func() {
+		server.Close()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	context("RecordingClient", func() {
+		it("passes responses through and writes them to the cassette", func() {
+			recorder := NewRecordingClient(http.DefaultClient, path, recordedAt)
+			resp, body := get(recorder, server.URL+"/orgs/example-org/repos?per_page=2")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(ContainSubstring("example-org/example-repo"))
+
+			cassette, err := LoadCassette(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cassette.RecordedAt).To(Equal(recordedAt))
+			Expect(cassette.Interactions).To(HaveLen(1))
+
+			interaction := cassette.Interactions[0]
+			Expect(interaction.Request.Method).To(Equal("GET"))
+			Expect(interaction.Request.Path).To(Equal("/orgs/example-org/repos"))
+			Expect(interaction.Request.Query).To(Equal("per_page=2"))
+			Expect(interaction.Response.StatusCode).To(Equal(http.StatusOK))
+			Expect(interaction.Response.Header.Get("Link")).To(ContainSubstring(`rel="next"`))
+			Expect(interaction.Response.Body).To(Equal(body))
+		})
+
+		it("appends each interaction to the cassette", func() {
+			recorder := NewRecordingClient(http.DefaultClient, path, recordedAt)
+			get(recorder, server.URL+"/orgs/example-org/repos?per_page=2")
+			get(recorder, server.URL+"/orgs/example-org/repos?per_page=2&page=2")
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(strings.Split(strings.TrimSpace(string(contents)), "\n")).To(HaveLen(3))
+
+			cassette, err := LoadCassette(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cassette.RecordedAt).To(Equal(recordedAt))
+			Expect(cassette.Interactions).To(HaveLen(2))
+			Expect(cassette.Interactions[1].Request.Query).To(Equal("page=2&per_page=2"))
+		})
+
+		it("scrubs the Authorization header", func() {
+			recorder := NewRecordingClient(http.DefaultClient, path, recordedAt)
+			get(recorder, server.URL+"/orgs/example-org/repos")
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).NotTo(ContainSubstring("some-token"))
+
+			cassette, err := LoadCassette(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cassette.Interactions[0].Request.Header.Get("Authorization")).To(Equal("REDACTED"))
+		})
+
+		it("scrubs installation tokens from response bodies", func() {
+			key, err := rsa.GenerateKey(rand.Reader, 2048)
+			Expect(err).NotTo(HaveOccurred())
+			app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprint(w, `{"token": "installation-token", "expires_at": "2001-01-31T21:20:20Z"}`)
+			}))
+			defer app.Close()
+
+			recorder := NewRecordingClient(http.DefaultClient, path, recordedAt)
+			clock := &fakes.Clock{}
+			clock.NowCall.Returns.Time = recordedAt
+			token, err := NewGitHubAppToken(app.URL, 123, 456, key, recorder, clock).Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("installation-token"))
+
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(contents)).NotTo(ContainSubstring("installation-token"))
+
+			cassette, err := LoadCassette(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cassette.Interactions[0].Response.Body).To(MatchJSON(`{"token": "REDACTED", "expires_at": "2001-01-31T21:20:20Z"}`))
+		})
+	})
+
+	context("ReplayingClient", func() {
+		var replayer *ReplayingClient
+
+		it.Before(func() {
+			recorder := NewRecordingClient(http.DefaultClient, path, recordedAt)
+			get(recorder, server.URL+"/orgs/example-org/repos?per_page=2")
+			get(recorder, server.URL+"/orgs/example-org/repos?per_page=2&page=2")
+			server.Close()
+
+			cassette, err := LoadCassette(path)
+			Expect(err).NotTo(HaveOccurred())
+			replayer = NewReplayingClient(cassette)
+		})
+
+		it("answers matching requests from the cassette", func() {
+			resp, body := get(replayer, server.URL+"/orgs/example-org/repos?page=2&per_page=2")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(body).To(ContainSubstring("example-org/example-fork"))
+			Expect(body).NotTo(ContainSubstring("example-org/example-repo"))
+			Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("4998"))
+		})
+
+		it("replays a request as often as it is made", func() {
+			_, first := get(replayer, server.URL+"/orgs/example-org/repos?per_page=2")
+			_, second := get(replayer, server.URL+"/orgs/example-org/repos?per_page=2")
+			Expect(second).To(Equal(first))
+		})
+
+		context("failure cases", func() {
+			context("when nothing was recorded for the request", func() {
+				it("returns the error", func() {
+					req, err := http.NewRequest("GET", server.URL+"/orgs/example-org/repos?per_page=100", nil)
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = replayer.Do(req)
+					Expect(err).To(MatchError("no recorded response for GET /orgs/example-org/repos?per_page=100"))
+				})
+			})
+		})
+	})
+
+	context("LoadCassette", func() {
+		context("failure cases", func() {
+			context("when the cassette is not JSON", func() {
+				it.Before(func() {
+					Expect(ioutil.WriteFile(path, []byte("not json"), 0600)).To(Succeed())
+				})
+
+				it("returns the error", func() {
+					_, err := LoadCassette(path)
+					Expect(err).To(MatchError(HavePrefix("parsing cassette " + path)))
+				})
+			})
+
+			context("when the cassette does not exist", func() {
+				it("returns the error", func() {
+					_, err := LoadCassette(filepath.Join(dir, "missing.json"))
+					Expect(err).To(MatchError(ContainSubstring("reading cassette:")))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,306 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Config describes which repositories gloss measures and how. It is read from
+// a YAML file; since JSON is a subset of YAML, JSON files work too.
+type Config struct {
+	Orgs          []string              `yaml:"orgs"`
+	Repos         RepoSelection         `yaml:"repos"`
+	IgnoredUsers  []string              `yaml:"ignored_users"`
+	Bots          BotPolicy             `yaml:"bots"`
+	Window        time.Duration         `yaml:"window"`
+	BusinessHours BusinessHours         `yaml:"business_hours"`
+	Segments      Segmentation          `yaml:"segments"`
+	Responders    ResponderOptions      `yaml:"responders"`
+	Discussions   DiscussionOptions     `yaml:"discussions"`
+	Output        OutputConfig          `yaml:"output"`
+	Concurrency   int                   `yaml:"concurrency"`
+	Auth          AuthConfig            `yaml:"auth"`
+	Server        ServerConfig          `yaml:"server"`
+	Backend       string                `yaml:"backend"`
+	Errors        ErrorPolicy           `yaml:"errors"`
+	Backlog       BacklogConfig         `yaml:"backlog"`
+	Stale         StaleConfig           `yaml:"stale"`
+	Contributors  ContributorsConfig    `yaml:"contributors"`
+	BusFactor     BusFactorConfig       `yaml:"bus_factor"`
+	GoodFirst     GoodFirstIssuesConfig `yaml:"good_first_issues"`
+	Triage        TriageConfig          `yaml:"triage"`
+	Security      SecurityConfig        `yaml:"security"`
+}
+
+// RepoSelection lists repositories, by full name, to measure in addition to
+// the repositories of the configured orgs, and filters the orgs' repositories.
+// Repositories listed in Include are always measured.
+type RepoSelection struct {
+	Include    []string `yaml:"include"`
+	RepoFilter `yaml:",inline"`
+}
+
+// BotPolicy decides whether issues opened by bots count towards metrics. Bots
+// are accounts GitHub marks as bots and logins matching Patterns.
+// Replies from accounts GitHub marks as bots never count as first contact.
+type BotPolicy struct {
+	Include  bool     `yaml:"include"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// ResponderOptions turns on the breakdown of first contacts by responder.
+// Responders are shown by pseudonym unless ShowLogins opts into their logins.
+type ResponderOptions struct {
+	Breakdown  bool `yaml:"breakdown"`
+	ShowLogins bool `yaml:"show_logins"`
+}
+
+// AuthConfig picks where API credentials come from. At most one source may be
+// set; with none, the token is read from the forge's usual environment
+// variable, GITHUB_TOKEN or GITLAB_TOKEN.
+type AuthConfig struct {
+	Token     string           `yaml:"token"`
+	TokenEnv  string           `yaml:"token_env"`
+	TokenFile string           `yaml:"token_file"`
+	App       *GitHubAppConfig `yaml:"app"`
+}
+
+type GitHubAppConfig struct {
+	AppID          int64  `yaml:"app_id"`
+	InstallationID int64  `yaml:"installation_id"`
+	PrivateKeyFile string `yaml:"private_key_file"`
+}
+
+type OutputConfig struct {
+	Format string `yaml:"format"`
+}
+
+var outputFormats = []string{"text", "json"}
+
+var backends = []string{"rest", "graphql"}
+
+func DefaultConfig() Config {
+	return Config{
+		Bots:         BotPolicy{Patterns: []string{"bot"}},
+		Window:       30 * 24 * time.Hour,
+		Output:       OutputConfig{Format: "text"},
+		Concurrency:  runtime.NumCPU(),
+		Backend:      "rest",
+		Backlog:      BacklogConfig{Period: 7 * 24 * time.Hour},
+		Stale:        StaleConfig{After: 90 * 24 * time.Hour},
+		Contributors: ContributorsConfig{Period: 7 * 24 * time.Hour},
+		BusFactor:    BusFactorConfig{TopN: 1, Threshold: 0.5},
+		GoodFirst:    GoodFirstIssuesConfig{Labels: []string{"good first issue", "good-first-issue"}},
+		Triage:       TriageConfig{Within: 48 * time.Hour},
+		Security:     SecurityConfig{Labels: []string{"security"}, Acknowledge: 72 * time.Hour, Publish: 90 * 24 * time.Hour},
+	}
+}
+
+// LoadConfig reads the file at path on top of DefaultConfig, so any setting
+// left out of the file keeps its default value.
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config file: %s", err)
+	}
+
+	err = yaml.UnmarshalStrict(contents, &config)
+	if err != nil {
+		return Config{}, fmt.Errorf("parsing config file %s: %s", path, err)
+	}
+	return config, nil
+}
+
+// Validate reports every problem with the config at once, so that a broken
+// file can be fixed in a single pass.
+func (c Config) Validate() error {
+	return c.validate(true)
+}
+
+// ValidateWebhook is Validate for the webhook server, which measures whichever
+// repositories send it events and so needs no orgs or repos.include.
+func (c Config) ValidateWebhook() error {
+	return c.validate(false)
+}
+
+func (c Config) validate(requireRepos bool) error {
+	var problems []string
+
+	if requireRepos && len(c.Orgs) == 0 && len(c.Repos.Include) == 0 {
+		problems = append(problems, "at least one of orgs or repos.include must be set")
+	}
+	// GitLab groups nest, so only its names may have more than one part.
+	nested := c.Server.ForgeName() == "gitlab"
+	for _, org := range c.Orgs {
+		if org == "" || (!nested && strings.Contains(org, "/")) {
+			problems = append(problems, fmt.Sprintf("orgs: %q is not an organization name", org))
+		}
+	}
+	for _, name := range c.Repos.Include {
+		parts := strings.Split(name, "/")
+		if len(parts) < 2 || (!nested && len(parts) != 2) || contains(parts, "") {
+			problems = append(problems, fmt.Sprintf("repos.include: %q must have the form owner/repo", name))
+		}
+	}
+	if err := c.Repos.RepoFilter.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("repos: %s", err))
+	}
+	if c.Window <= 0 {
+		problems = append(problems, fmt.Sprintf("window: must be positive, got %s", c.Window))
+	}
+	if err := c.BusinessHours.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("business_hours: %s", err))
+	}
+	if err := c.Segments.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("segments: %s", err))
+	}
+	if !contains(outputFormats, c.Output.Format) {
+		problems = append(problems, fmt.Sprintf("output.format: %q is not one of %s", c.Output.Format, strings.Join(outputFormats, ", ")))
+	}
+	if c.Concurrency < 1 {
+		problems = append(problems, fmt.Sprintf("concurrency: must be at least 1, got %d", c.Concurrency))
+	}
+	if err := c.Errors.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("errors.%s", err))
+	}
+	if err := c.Backlog.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("backlog.%s", err))
+	}
+	if err := c.Stale.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("stale.%s", err))
+	}
+	if err := c.Contributors.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("contributors.%s", err))
+	}
+	if err := c.BusFactor.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("bus_factor.%s", err))
+	}
+	if err := c.GoodFirst.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("good_first_issues.%s", err))
+	}
+	if err := c.Triage.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("triage.%s", err))
+	}
+	if err := c.Security.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("security.%s", err))
+	}
+
+	if !contains(backends, c.Backend) {
+		problems = append(problems, fmt.Sprintf("backend: %q is not one of %s", c.Backend, strings.Join(backends, ", ")))
+	}
+	if err := c.Auth.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("auth: %s", err))
+	}
+	if err := c.Server.Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("server: %s", err))
+	}
+	if !c.Server.IsGitHub() && c.Backend == "graphql" {
+		problems = append(problems, "backend: graphql is only available for GitHub")
+	}
+	if !c.Server.IsGitHub() && c.Auth.App != nil {
+		problems = append(problems, "auth: app is only available for GitHub")
+	}
+	if !c.Server.IsGitHub() && c.Discussions.Enabled {
+		problems = append(problems, "discussions: only available for GitHub")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config:\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+func (a AuthConfig) Validate() error {
+	sources := 0
+	for _, set := range []bool{a.Token != "", a.TokenEnv != "", a.TokenFile != "", a.App != nil} {
+		if set {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("only one of token, token_env, token_file and app may be set")
+	}
+
+	if a.App != nil {
+		if a.App.AppID <= 0 {
+			return fmt.Errorf("app.app_id must be set")
+		}
+		if a.App.InstallationID <= 0 {
+			return fmt.Errorf("app.installation_id must be set")
+		}
+		if a.App.PrivateKeyFile == "" {
+			return fmt.Errorf("app.private_key_file must be set")
+		}
+	}
+	return nil
+}
+
+// TokenSource builds the configured credential source. The GitHub App source
+// exchanges its tokens with the configured server.
+func (a AuthConfig) TokenSource(server ServerConfig, httpClient HTTPClient, clock Clock) (TokenSource, error) {
+	switch {
+	case a.Token != "":
+		return StaticToken(a.Token), nil
+	case a.TokenEnv != "":
+		return EnvToken{Name: a.TokenEnv}, nil
+	case a.TokenFile != "":
+		return FileToken{Path: a.TokenFile}, nil
+	case a.App != nil:
+		contents, err := ioutil.ReadFile(a.App.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading app private key: %s", err)
+		}
+		key, err := ParsePrivateKey(contents)
+		if err != nil {
+			return nil, err
+		}
+		return NewGitHubAppToken(server.APIURL(), a.App.AppID, a.App.InstallationID, key, httpClient, clock), nil
+	default:
+		return EnvToken{Name: server.TokenEnv()}, nil
+	}
+}
+
+func (c Config) ContactOptions() ContactOptions {
+	return ContactOptions{
+		IgnoredUsers:    c.IgnoredUsers,
+		Bots:            c.Bots,
+		BusinessHours:   c.BusinessHours,
+		ContinueOnError: c.Errors.Continue,
+	}
+}
+
+func (c Config) ReportOptions() ReportOptions {
+	return ReportOptions{
+		Segments:   c.Segments,
+		Responders: c.Responders.Breakdown,
+		ShowLogins: c.Responders.ShowLogins,
+		Errors:     c.Errors,
+	}
+}
+
+// IsBot reports whether login belongs to a bot according to the policy's
+// login patterns.
+func (b BotPolicy) IsBot(login string) bool {
+	for _, pattern := range b.Patterns {
+		if strings.Contains(login, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
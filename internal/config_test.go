@@ -0,0 +1,227 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testConfig(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var dir string
+
+	it.Before(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "config")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	context("LoadConfig", func() {
+		context("when given a YAML file", func() {
+			it.Before(func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "gloss.yml"), []byte(`
+orgs: [example-org]
+repos:
+  include: [other-org/some-repo]
+  exclude: [example-org/old-*]
+  forks: true
+ignored_users: [some-maintainer-bot]
+window: 168h
+business_hours:
+  start: "09:00"
+  end: "17:00"
+  timezone: UTC
+responders:
+  breakdown: true
+  show_logins: true
+output:
+  format: json
+concurrency: 2
+`), 0644)).To(Succeed())
+			})
+
+			it("returns the config from the file", func() {
+				config, err := LoadConfig(filepath.Join(dir, "gloss.yml"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(config.Orgs).To(Equal([]string{"example-org"}))
+				Expect(config.Repos.Include).To(Equal([]string{"other-org/some-repo"}))
+				Expect(config.Repos.Exclude).To(Equal([]string{"example-org/old-*"}))
+				Expect(config.Repos.Forks).To(BeTrue())
+				Expect(config.IgnoredUsers).To(Equal([]string{"some-maintainer-bot"}))
+				Expect(config.Window).To(Equal(7 * 24 * time.Hour))
+				Expect(config.BusinessHours.Start).To(Equal("09:00"))
+				Expect(config.Output.Format).To(Equal("json"))
+				Expect(config.Concurrency).To(Equal(2))
+				Expect(config.ReportOptions().ShowLogins).To(BeTrue())
+			})
+
+			it("keeps the defaults for settings left out of the file", func() {
+				config, err := LoadConfig(filepath.Join(dir, "gloss.yml"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(config.Bots).To(Equal(BotPolicy{Patterns: []string{"bot"}}))
+				Expect(DefaultConfig().Responders.ShowLogins).To(BeFalse())
+			})
+		})
+
+		context("when given a JSON file", func() {
+			it.Before(func() {
+				Expect(ioutil.WriteFile(filepath.Join(dir, "gloss.json"), []byte(`{"orgs": ["example-org"], "window": "24h"}`), 0644)).To(Succeed())
+			})
+
+			it("returns the config from the file", func() {
+				config, err := LoadConfig(filepath.Join(dir, "gloss.json"))
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(config.Orgs).To(Equal([]string{"example-org"}))
+				Expect(config.Window).To(Equal(24 * time.Hour))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns the error", func() {
+					_, err := LoadConfig(filepath.Join(dir, "missing.yml"))
+					Expect(err).To(MatchError(ContainSubstring("reading config file:")))
+				})
+			})
+
+			context("when the file has an unknown key", func() {
+				it.Before(func() {
+					Expect(ioutil.WriteFile(filepath.Join(dir, "gloss.yml"), []byte("org: example-org\n"), 0644)).To(Succeed())
+				})
+
+				it("returns the error", func() {
+					_, err := LoadConfig(filepath.Join(dir, "gloss.yml"))
+					Expect(err).To(MatchError(ContainSubstring("field org not found")))
+				})
+			})
+		})
+	})
+
+	context("Validate", func() {
+		var config Config
+
+		it.Before(func() {
+			config = DefaultConfig()
+			config.Orgs = []string{"example-org"}
+		})
+
+		it("accepts the default config with an org", func() {
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		it("defaults to several contributor periods in the window, so that retention is measured", func() {
+			Expect(config.Window).To(BeNumerically(">=", 2*config.Contributors.Period))
+		})
+
+		context("when there are several problems", func() {
+			it.Before(func() {
+				config.Orgs = []string{"not/an-org"}
+				config.Repos.Include = []string{"not-a-repo"}
+				config.Repos.Visibility = "secret"
+				config.Window = 0
+				config.BusinessHours = BusinessHours{Start: "17:00", End: "09:00"}
+				config.Output.Format = "xml"
+				config.Concurrency = 0
+				config.Errors.Budget = 2
+				config.Backlog.Period = 0
+				config.Stale.After = -time.Hour
+				config.Contributors.Period = 0
+				config.BusFactor.TopN = 0
+				config.GoodFirst.Labels = nil
+				config.Triage.Within = 0
+				config.Security.Publish = 0
+				config.Auth = AuthConfig{Token: "some-token", TokenEnv: "SOME_TOKEN"}
+			})
+
+			it("reports all of them", func() {
+				err := config.Validate()
+				Expect(err).To(MatchError(`invalid config:
+  orgs: "not/an-org" is not an organization name
+  repos.include: "not-a-repo" must have the form owner/repo
+  repos: visibility "secret" is not one of all, public, private
+  window: must be positive, got 0s
+  business_hours: end "09:00" must be later than start "17:00"
+  output.format: "xml" is not one of text, json
+  concurrency: must be at least 1, got 0
+  errors.budget: must be between 0 and 1, got 2
+  backlog.period: must be positive, got 0s
+  stale.after: must be positive, got -1h0m0s
+  contributors.period: must be positive, got 0s
+  bus_factor.top_n: must be at least 1, got 0
+  good_first_issues.labels: must not be empty
+  triage.within: must be positive, got 0s
+  security.publish: must be positive, got 0s
+  auth: only one of token, token_env, token_file and app may be set`))
+			})
+		})
+
+		context("when the forge is GitLab", func() {
+			it.Before(func() {
+				config.Server.Forge = "gitlab"
+			})
+
+			it("accepts nested groups and projects", func() {
+				config.Orgs = []string{"example-group/subgroup"}
+				config.Repos.Include = []string{"example-group/subgroup/example-project"}
+				Expect(config.Validate()).To(Succeed())
+			})
+
+			it("rejects the GitHub-only options", func() {
+				config.Backend = "graphql"
+				config.Auth.App = &GitHubAppConfig{AppID: 123, InstallationID: 456, PrivateKeyFile: "key.pem"}
+				config.Discussions.Enabled = true
+				Expect(config.Validate()).To(MatchError(`invalid config:
+  backend: graphql is only available for GitHub
+  auth: app is only available for GitHub
+  discussions: only available for GitHub`))
+			})
+		})
+	})
+
+	context("AuthConfig.TokenSource", func() {
+		it("reads GITHUB_TOKEN by default", func() {
+			source, err := AuthConfig{}.TokenSource(ServerConfig{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(EnvToken{Name: "GITHUB_TOKEN"}))
+		})
+
+		it("reads GITLAB_TOKEN by default for GitLab", func() {
+			source, err := AuthConfig{}.TokenSource(ServerConfig{Forge: "gitlab"}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(EnvToken{Name: "GITLAB_TOKEN"}))
+		})
+
+		it("uses the configured token file", func() {
+			source, err := AuthConfig{TokenFile: "/some/token"}.TokenSource(ServerConfig{}, nil, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(source).To(Equal(FileToken{Path: "/some/token"}))
+		})
+
+		context("failure cases", func() {
+			context("when the app private key cannot be read", func() {
+				it("returns the error", func() {
+					_, err := AuthConfig{App: &GitHubAppConfig{
+						AppID:          123,
+						InstallationID: 456,
+						PrivateKeyFile: filepath.Join(dir, "missing.pem"),
+					}}.TokenSource(ServerConfig{}, nil, nil)
+					Expect(err).To(MatchError(ContainSubstring("reading app private key:")))
+				})
+			})
+		})
+	})
+}
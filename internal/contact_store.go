@@ -0,0 +1,315 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// TrackedIssue is what the webhook knows about an issue: when and by whom it
+// was opened, and its first response once there is one.
+type TrackedIssue struct {
+	Issue      IssueRef  `json:"issue"`
+	CreatedAt  time.Time `json:"created_at"`
+	Author     string    `json:"author"`
+	AuthorType string    `json:"author_type,omitempty"`
+	Labels     []string  `json:"labels,omitempty"`
+	Response   *Response `json:"response,omitempty"`
+}
+
+// ContactStore keeps the first contact state of every issue the webhook has
+// heard of. If it has a path, every change is appended to that file as a line
+// of JSON, so that it survives restarts without rewriting the whole store on
+// each event. A later line for an issue replaces the earlier ones, and the file
+// is compacted once it holds many more lines than issues.
+type ContactStore struct {
+	path   string
+	mutex  sync.Mutex
+	issues map[string]TrackedIssue
+	lines  int
+}
+
+// storeRecord is a line of the store file: the state of an issue, or that it
+// is no longer tracked.
+type storeRecord struct {
+	TrackedIssue
+	Forgotten bool `json:"forgotten,omitempty"`
+}
+
+// minCompactLines keeps small stores from being compacted over and over.
+const minCompactLines = 100
+
+// NewContactStore loads the store at path, starting empty if the file does
+// not exist yet. An empty path keeps the store in memory only.
+func NewContactStore(path string) (*ContactStore, error) {
+	store := &ContactStore{path: path, issues: make(map[string]TrackedIssue)}
+	if path == "" {
+		return store, nil
+	}
+
+	contents, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading contact store: %s", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(contents))
+	for {
+		var record storeRecord
+		err = decoder.Decode(&record)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parsing contact store %s: %s", path, err)
+		}
+		if record.Forgotten {
+			delete(store.issues, record.Issue.String())
+		} else {
+			store.issues[record.Issue.String()] = record.TrackedIssue
+		}
+		store.lines++
+	}
+	return store, nil
+}
+
+// Track records an opened issue. An issue that is already tracked keeps its
+// response and only has its labels updated.
+func (s *ContactStore) Track(issue TrackedIssue) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if tracked, ok := s.issues[issue.Issue.String()]; ok {
+		if equalStrings(tracked.Labels, issue.Labels) {
+			return nil
+		}
+		tracked.Labels = issue.Labels
+		issue = tracked
+	}
+	s.issues[issue.Issue.String()] = issue
+	return s.save(storeRecord{TrackedIssue: issue})
+}
+
+// Respond records a response to a tracked issue. Deliveries can arrive out of
+// order, so only the earliest response is kept.
+func (s *ContactStore) Respond(ref IssueRef, response Response) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tracked, ok := s.issues[ref.String()]
+	if !ok {
+		return fmt.Errorf("%s is not tracked", ref)
+	}
+	if tracked.Response != nil && !response.At.Before(tracked.Response.At) {
+		return nil
+	}
+	tracked.Response = &response
+	s.issues[ref.String()] = tracked
+	return s.save(storeRecord{TrackedIssue: tracked})
+}
+
+// Forget stops tracking an issue, e.g. because it was deleted.
+func (s *ContactStore) Forget(ref IssueRef) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.issues[ref.String()]; !ok {
+		return nil
+	}
+	delete(s.issues, ref.String())
+	return s.save(storeRecord{TrackedIssue: TrackedIssue{Issue: ref}, Forgotten: true})
+}
+
+// Prune stops tracking the issues opened before before, which no report of
+// the store looks at any more, and compacts the file if any were dropped.
+func (s *ContactStore) Prune(before time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	pruned := false
+	for key, tracked := range s.issues {
+		if tracked.CreatedAt.Before(before) {
+			delete(s.issues, key)
+			pruned = true
+		}
+	}
+	if !pruned {
+		return nil
+	}
+	return s.compact()
+}
+
+// Get returns the tracked issue, if there is one.
+func (s *ContactStore) Get(ref IssueRef) (TrackedIssue, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	tracked, ok := s.issues[ref.String()]
+	return tracked, ok
+}
+
+// HasActivityBefore reports whether login opened or first responded to any
+// tracked issue before before.
+func (s *ContactStore) HasActivityBefore(login string, before time.Time) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, tracked := range s.issues {
+		if tracked.Author == login && tracked.CreatedAt.Before(before) {
+			return true
+		}
+		if tracked.Response != nil && tracked.Response.Actor == login && tracked.Response.At.Before(before) {
+			return true
+		}
+	}
+	return false
+}
+
+// Results measures the tracked issues opened within window of now, grouped by
+// repository, with the same rules for bots, ignored users and business hours
+// as a polling run.
+func (s *ContactStore) Results(now time.Time, window time.Duration, options ContactOptions) ([]RepoResults, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	since := now.Add(-window)
+	byRepo := make(map[string][]FirstContact)
+	for _, tracked := range s.issues {
+		if tracked.CreatedAt.Before(since) {
+			continue
+		}
+		if ignoredAuthor(tracked.Author, tracked.AuthorType, options) {
+			continue
+		}
+
+		contact, err := tracked.contact(now, options)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s: %s", tracked.Issue, err)
+		}
+		byRepo[tracked.Issue.Repo] = append(byRepo[tracked.Issue.Repo], contact)
+	}
+
+	var results []RepoResults
+	for name, contacts := range byRepo {
+		sort.Slice(contacts, func(i, j int) bool { return contacts[i].Issue.Number < contacts[j].Issue.Number })
+		results = append(results, RepoResults{Name: name, Results: contacts})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+	return results, nil
+}
+
+func (t TrackedIssue) contact(now time.Time, options ContactOptions) (FirstContact, error) {
+	contact := FirstContact{
+		Issue:     t.Issue,
+		CreatedAt: t.CreatedAt,
+		Labels:    t.Labels,
+		Status:    StatusAwaiting,
+	}
+
+	end := now
+	if t.Response != nil {
+		end = t.Response.At
+		contact.Response = t.Response
+		contact.Status = StatusResponded
+	}
+
+	contact.Elapsed = end.Sub(t.CreatedAt)
+	if options.BusinessHours.Enabled() {
+		var err error
+		contact.Elapsed, err = options.BusinessHours.Elapsed(t.CreatedAt, end)
+		if err != nil {
+			return contact, fmt.Errorf("could not measure business hours: %s", err)
+		}
+	}
+	return contact, nil
+}
+
+// save appends a change to the store's file, compacting the file instead once
+// it has grown to more than twice the lines it needs; the caller holds the
+// mutex.
+func (s *ContactStore) save(record storeRecord) error {
+	if s.path == "" {
+		return nil
+	}
+	if s.lines+1 > minCompactLines && s.lines+1 > 2*len(s.issues) {
+		return s.compact()
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("saving contact store: %s", err)
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("writing contact store: %s", err)
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing contact store: %s", err)
+	}
+	s.lines++
+	return nil
+}
+
+// compact rewrites the store's file with a line per tracked issue, replacing
+// it only once the new file is complete; the caller holds the mutex.
+func (s *ContactStore) compact() error {
+	if s.path == "" {
+		return nil
+	}
+
+	issues := make([]TrackedIssue, 0, len(s.issues))
+	for _, issue := range s.issues {
+		issues = append(issues, issue)
+	}
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Issue.Repo != issues[j].Issue.Repo {
+			return issues[i].Issue.Repo < issues[j].Issue.Repo
+		}
+		return issues[i].Issue.Number < issues[j].Issue.Number
+	})
+
+	var contents []byte
+	for _, issue := range issues {
+		line, err := json.Marshal(storeRecord{TrackedIssue: issue})
+		if err != nil {
+			return fmt.Errorf("saving contact store: %s", err)
+		}
+		contents = append(contents, line...)
+		contents = append(contents, '\n')
+	}
+
+	temporary := s.path + ".tmp"
+	err := ioutil.WriteFile(temporary, contents, 0600)
+	if err == nil {
+		err = os.Rename(temporary, s.path)
+	}
+	if err != nil {
+		return fmt.Errorf("writing contact store: %s", err)
+	}
+	s.lines = len(issues)
+	return nil
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testContactStore(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var dir string
+	var path string
+	var ref IssueRef
+	var created time.Time
+
+	it.Before(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "contact-store")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "store.json")
+
+		ref = IssueRef{Repo: "example-org/example-repo", Number: 7}
+		created = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+	})
+
+	it.After(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("survives a restart", func() {
+		store, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Respond(ref, Response{At: created.Add(time.Hour), Actor: "some-maintainer", Kind: ResponseComment})).To(Succeed())
+
+		reopened, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		tracked, ok := reopened.Get(ref)
+		Expect(ok).To(BeTrue())
+		Expect(tracked.Response.Actor).To(Equal("some-maintainer"))
+	})
+
+	it("appends changes to the file and replays them on a restart", func() {
+		store, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		other := IssueRef{Repo: "example-org/example-repo", Number: 8}
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Track(TrackedIssue{Issue: other, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user", Labels: []string{"bug"}})).To(Succeed())
+		Expect(store.Forget(other)).To(Succeed())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(string(contents), "\n")).To(Equal(4))
+
+		reopened, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		tracked, ok := reopened.Get(ref)
+		Expect(ok).To(BeTrue())
+		Expect(tracked.Labels).To(Equal([]string{"bug"}))
+		_, ok = reopened.Get(other)
+		Expect(ok).To(BeFalse())
+	})
+
+	it("prunes issues opened before a time and compacts the file", func() {
+		store, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		old := IssueRef{Repo: "example-org/example-repo", Number: 1}
+		Expect(store.Track(TrackedIssue{Issue: old, CreatedAt: created.Add(-100 * 24 * time.Hour), Author: "some-user"})).To(Succeed())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Respond(ref, Response{At: created.Add(time.Hour), Actor: "some-maintainer"})).To(Succeed())
+
+		Expect(store.Prune(created.Add(-30 * 24 * time.Hour))).To(Succeed())
+		_, ok := store.Get(old)
+		Expect(ok).To(BeFalse())
+
+		contents, err := ioutil.ReadFile(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(strings.Count(string(contents), "\n")).To(Equal(1))
+
+		reopened, err := NewContactStore(path)
+		Expect(err).NotTo(HaveOccurred())
+		tracked, ok := reopened.Get(ref)
+		Expect(ok).To(BeTrue())
+		Expect(tracked.Response.Actor).To(Equal("some-maintainer"))
+	})
+
+	it("keeps the earliest response", func() {
+		store, err := NewContactStore("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		Expect(store.Respond(ref, Response{At: created.Add(2 * time.Hour), Actor: "late-maintainer"})).To(Succeed())
+		Expect(store.Respond(ref, Response{At: created.Add(time.Hour), Actor: "early-maintainer"})).To(Succeed())
+		Expect(store.Respond(ref, Response{At: created.Add(3 * time.Hour), Actor: "later-maintainer"})).To(Succeed())
+
+		tracked, _ := store.Get(ref)
+		Expect(tracked.Response.Actor).To(Equal("early-maintainer"))
+	})
+
+	it("leaves out issues outside the window and by ignored users", func() {
+		store, err := NewContactStore("")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(store.Track(TrackedIssue{Issue: ref, CreatedAt: created, Author: "some-user"})).To(Succeed())
+		old := IssueRef{Repo: "example-org/example-repo", Number: 1}
+		Expect(store.Track(TrackedIssue{Issue: old, CreatedAt: created.Add(-100 * 24 * time.Hour), Author: "some-user"})).To(Succeed())
+		ignored := IssueRef{Repo: "example-org/example-repo", Number: 2}
+		Expect(store.Track(TrackedIssue{Issue: ignored, CreatedAt: created, Author: "ignored-user"})).To(Succeed())
+
+		results, err := store.Results(created.Add(time.Hour), 30*24*time.Hour, ContactOptions{IgnoredUsers: []string{"ignored-user"}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Results).To(HaveLen(1))
+		Expect(results[0].Results[0].Issue).To(Equal(ref))
+		Expect(results[0].Results[0].Status).To(Equal(StatusAwaiting))
+		Expect(results[0].Results[0].Elapsed).To(Equal(time.Hour))
+	})
+
+	it("rejects a corrupt store", func() {
+		Expect(ioutil.WriteFile(path, []byte("{"), 0600)).To(Succeed())
+		_, err := NewContactStore(path)
+		Expect(err).To(MatchError(ContainSubstring("parsing contact store")))
+	})
+}
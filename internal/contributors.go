@@ -0,0 +1,301 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ContributorsConfig sets the periods contributors are counted in, and the
+// logins counted as core contributors on top of the owners, members and
+// collaborators GitHub reports through author_association.
+type ContributorsConfig struct {
+	Period      time.Duration `yaml:"period"`
+	Maintainers []string      `yaml:"maintainers"`
+}
+
+func (c ContributorsConfig) Validate() error {
+	if c.Period <= 0 {
+		return fmt.Errorf("period: must be positive, got %s", c.Period)
+	}
+	return nil
+}
+
+// ContributorClass is how established a contributor is.
+type ContributorClass string
+
+const (
+	ContributorFirstTime ContributorClass = "first_time"
+	ContributorReturning ContributorClass = "returning"
+	ContributorCore      ContributorClass = "core"
+)
+
+// ActivityKind is what a contributor did.
+type ActivityKind string
+
+const (
+	ActivityIssue       ActivityKind = "issue"
+	ActivityPullRequest ActivityKind = "pull_request"
+	ActivityComment     ActivityKind = "comment"
+)
+
+// Activity is one issue, pull request or comment by a contributor.
+type Activity struct {
+	Repo        string
+	Login       string
+	Association string
+	Kind        ActivityKind
+	At          time.Time
+}
+
+// coreAssociations are the author_associations of people with write access.
+var coreAssociations = []string{"OWNER", "MEMBER", "COLLABORATOR"}
+
+// ContributorPeriod counts the contributors active in a period. A contributor
+// is core if they have write access or are a configured maintainer, first-time
+// if this is the first period they are active in and GitHub does not know them
+// as a contributor, and returning otherwise. Retention is the share of the
+// previous period's first-time and returning contributors that are active
+// again; the first period has none.
+type ContributorPeriod struct {
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	Active    int       `json:"active"`
+	FirstTime int       `json:"first_time"`
+	Returning int       `json:"returning"`
+	Core      int       `json:"core"`
+	Retained  int       `json:"retained"`
+	Retention float64   `json:"retention"`
+}
+
+// ContributorReport describes the community of a repository, or of an org's
+// repositories together, over the window. Each contributor is counted once in
+// the totals: as core, or else by how they were classified when first active.
+// Drive-by contributors are first-time or returning ones with a single issue,
+// pull request or comment.
+type ContributorReport struct {
+	Name         string              `json:"name"`
+	Contributors int                 `json:"contributors"`
+	FirstTime    int                 `json:"first_time"`
+	Returning    int                 `json:"returning"`
+	Core         int                 `json:"core"`
+	DriveBy      int                 `json:"drive_by"`
+	Periods      []ContributorPeriod `json:"periods"`
+}
+
+type ContributorSummary struct {
+	Repositories []ContributorReport `json:"repositories"`
+	Orgs         []ContributorReport `json:"orgs"`
+}
+
+// GetContributorActivity returns the issues and pull requests opened and the
+// comments made on the repository since since, leaving out ignored users and
+// bots.
+func GetContributorActivity(client Client, repo Repository, since time.Time, options ContactOptions) ([]Activity, error) {
+	var activity []Activity
+	add := func(login, userType, association, at string, kind ActivityKind) error {
+		if ignoredAuthor(login, userType, options) {
+			return nil
+		}
+		acted, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("could not parse activity time on %s: %s", repo.Name, err)
+		}
+		if acted.Before(since) {
+			return nil
+		}
+		activity = append(activity, Activity{Repo: repo.Name, Login: login, Association: association, Kind: kind, At: acted})
+		return nil
+	}
+
+	issues, err := repo.GetIssues(client, "all", since)
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		kind := ActivityIssue
+		if issue.IsPullRequest() {
+			kind = ActivityPullRequest
+		}
+		err = add(issue.User.Login, issue.User.Type, issue.AuthorAssociation, issue.CreatedAt, kind)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	comments := []Comment{}
+	err = getAllPages(client, fmt.Sprintf("/repos/%s/issues/comments", repo.Name), func(body []byte) (int, error) {
+		page := []Comment{}
+		err := json.Unmarshal(body, &page)
+		comments = append(comments, page...)
+		return len(page), err
+	}, fmt.Sprintf("since=%s", since.UTC().Format(time.RFC3339)))
+	if err != nil {
+		return nil, fmt.Errorf("getting comments: %s", err)
+	}
+	for _, comment := range comments {
+		err = add(comment.User.Login, comment.User.Type, comment.AuthorAssociation, comment.CreatedAt, ActivityComment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return activity, nil
+}
+
+// NewContributorSummary reports on the contributors of each repository and of
+// each org, whose contributors are counted once however many of its
+// repositories they are active in.
+func NewContributorSummary(activity []Activity, now time.Time, window time.Duration, config ContributorsConfig) ContributorSummary {
+	byRepo := make(map[string][]Activity)
+	byOrg := make(map[string][]Activity)
+	for _, item := range activity {
+		byRepo[item.Repo] = append(byRepo[item.Repo], item)
+		byOrg[ownerOf(item.Repo)] = append(byOrg[ownerOf(item.Repo)], item)
+	}
+
+	summary := ContributorSummary{Repositories: []ContributorReport{}, Orgs: []ContributorReport{}}
+	for name, items := range byRepo {
+		summary.Repositories = append(summary.Repositories, NewContributorReport(name, items, now, window, config))
+	}
+	for name, items := range byOrg {
+		summary.Orgs = append(summary.Orgs, NewContributorReport(name, items, now, window, config))
+	}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+	sort.Slice(summary.Orgs, func(i, j int) bool { return summary.Orgs[i].Name < summary.Orgs[j].Name })
+	return summary
+}
+
+// NewContributorReport classifies the contributors in each period of the
+// window before now.
+func NewContributorReport(name string, activity []Activity, now time.Time, window time.Duration, config ContributorsConfig) ContributorReport {
+	report := ContributorReport{Name: name, Periods: []ContributorPeriod{}}
+
+	counts := make(map[string]int)
+	classes := make(map[string]ContributorClass)
+	var previous map[string]struct{}
+	for _, bounds := range splitPeriods(now, window, config.Period) {
+		period := ContributorPeriod{Start: bounds[0], End: bounds[1]}
+
+		associations := make(map[string][]string)
+		for _, item := range activity {
+			if !item.At.Before(period.Start) && item.At.Before(period.End) {
+				associations[item.Login] = append(associations[item.Login], item.Association)
+				counts[item.Login]++
+			}
+		}
+
+		current := make(map[string]struct{})
+		for login, seen := range associations {
+			period.Active++
+			_, known := classes[login]
+			class := ContributorReturning
+			switch {
+			case contains(config.Maintainers, login) || containsAny(coreAssociations, seen):
+				class = ContributorCore
+			case !known && !contains(seen, "CONTRIBUTOR"):
+				class = ContributorFirstTime
+			}
+			if !known || class == ContributorCore {
+				classes[login] = class
+			}
+
+			switch class {
+			case ContributorCore:
+				period.Core++
+				continue
+			case ContributorFirstTime:
+				period.FirstTime++
+			default:
+				period.Returning++
+			}
+
+			current[login] = struct{}{}
+			if _, ok := previous[login]; ok {
+				period.Retained++
+			}
+		}
+		if len(previous) > 0 {
+			period.Retention = float64(period.Retained) / float64(len(previous))
+		}
+
+		previous = current
+		report.Periods = append(report.Periods, period)
+	}
+
+	for login, class := range classes {
+		report.Contributors++
+		switch class {
+		case ContributorCore:
+			report.Core++
+			continue
+		case ContributorFirstTime:
+			report.FirstTime++
+		default:
+			report.Returning++
+		}
+		if counts[login] == 1 {
+			report.DriveBy++
+		}
+	}
+	return report
+}
+
+func (s ContributorSummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tCONTRIBUTORS\tFIRST-TIME\tRETURNING\tCORE\tDRIVE-BY")
+		for _, repo := range s.Repositories {
+			fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%d\n", repo.Name, repo.Contributors, repo.FirstTime, repo.Returning, repo.Core, repo.DriveBy)
+		}
+		for _, org := range s.Orgs {
+			fmt.Fprintf(table, "%s (org)\t%d\t%d\t%d\t%d\t%d\n", org.Name, org.Contributors, org.FirstTime, org.Returning, org.Core, org.DriveBy)
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w)
+		table = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ORG\tPERIOD\tACTIVE\tFIRST-TIME\tRETURNING\tCORE\tRETENTION")
+		for _, org := range s.Orgs {
+			for i, period := range org.Periods {
+				retention := "-"
+				if i > 0 {
+					retention = fmt.Sprintf("%.0f%%", period.Retention*100)
+				}
+				fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%d\t%s\n", org.Name, period.Start.Format("2006-01-02"),
+					period.Active, period.FirstTime, period.Returning, period.Core, retention)
+			}
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// ownerOf returns the owner part of a repository's full name.
+func ownerOf(repo string) string {
+	if i := strings.LastIndex(repo, "/"); i >= 0 {
+		return repo[:i]
+	}
+	return repo
+}
+
+func containsAny(list, values []string) bool {
+	for _, value := range values {
+		if contains(list, value) {
+			return true
+		}
+	}
+	return false
+}
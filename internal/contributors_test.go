@@ -0,0 +1,120 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testContributors(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var config ContributorsConfig
+	var activity []Activity
+
+	act := func(repo, login, association string, kind ActivityKind, month time.Month, day int) Activity {
+		year := 2001
+		if month == time.December {
+			year = 2000
+		}
+		return Activity{Repo: repo, Login: login, Association: association, Kind: kind, At: time.Date(year, month, day, 12, 0, 0, 0, time.UTC)}
+	}
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		config = ContributorsConfig{Period: 30 * 24 * time.Hour, Maintainers: []string{"erin"}}
+		activity = []Activity{
+			act("example-org/example-repo", "alice", "NONE", ActivityIssue, time.December, 10),
+			act("example-org/example-repo", "alice", "NONE", ActivityComment, time.January, 5),
+			act("example-org/other-repo", "bob", "FIRST_TIME_CONTRIBUTOR", ActivityPullRequest, time.January, 10),
+			act("example-org/example-repo", "carol", "CONTRIBUTOR", ActivityComment, time.December, 5),
+			act("example-org/example-repo", "dave", "MEMBER", ActivityComment, time.December, 6),
+			act("example-org/other-repo", "dave", "MEMBER", ActivityComment, time.January, 6),
+			act("example-org/example-repo", "erin", "NONE", ActivityComment, time.January, 7),
+		}
+	})
+
+	context("NewContributorReport", func() {
+		it("classifies the contributors of each period", func() {
+			report := NewContributorReport("example-org", activity, now, 60*24*time.Hour, config)
+
+			Expect(report.Periods).To(HaveLen(2))
+			Expect(report.Periods[0]).To(Equal(ContributorPeriod{
+				Start: now.Add(-60 * 24 * time.Hour), End: now.Add(-30 * 24 * time.Hour),
+				Active: 3, FirstTime: 1, Returning: 1, Core: 1,
+			}))
+			Expect(report.Periods[1]).To(Equal(ContributorPeriod{
+				Start: now.Add(-30 * 24 * time.Hour), End: now,
+				Active: 4, FirstTime: 1, Returning: 1, Core: 2, Retained: 1, Retention: 0.5,
+			}))
+		})
+
+		it("counts each contributor once in the totals", func() {
+			report := NewContributorReport("example-org", activity, now, 60*24*time.Hour, config)
+
+			Expect(report.Contributors).To(Equal(5))
+			Expect(report.FirstTime).To(Equal(2))
+			Expect(report.Returning).To(Equal(1))
+			Expect(report.Core).To(Equal(2))
+			Expect(report.DriveBy).To(Equal(2))
+		})
+	})
+
+	context("NewContributorSummary", func() {
+		it("reports per repository and per org", func() {
+			summary := NewContributorSummary(activity, now, 60*24*time.Hour, config)
+
+			Expect(summary.Repositories).To(HaveLen(2))
+			Expect(summary.Repositories[0].Name).To(Equal("example-org/example-repo"))
+			Expect(summary.Repositories[0].Contributors).To(Equal(4))
+			Expect(summary.Repositories[1].Contributors).To(Equal(2))
+			Expect(summary.Orgs).To(HaveLen(1))
+			Expect(summary.Orgs[0].Contributors).To(Equal(5))
+		})
+
+		it("writes a text report", func() {
+			var output bytes.Buffer
+			Expect(NewContributorSummary(activity, now, 60*24*time.Hour, config).Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org (org)         5             2           1          2     2"))
+			Expect(output.String()).To(ContainSubstring("example-org  2001-01-01  4       1           1          2     50%"))
+		})
+	})
+
+	context("GetContributorActivity", func() {
+		it("gathers issue and pull request authors and commenters", func() {
+			fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server := fakegithub.NewServer(fixture)
+			defer server.Close()
+
+			client, err := NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+			Expect(err).NotTo(HaveOccurred())
+
+			activity, err := GetContributorActivity(&client, Repository{Name: "example-org/example-repo"},
+				time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC), ContactOptions{Bots: BotPolicy{Patterns: []string{"bot"}}})
+			Expect(err).NotTo(HaveOccurred())
+
+			var kinds []string
+			for _, item := range activity {
+				kinds = append(kinds, item.Login+" "+string(item.Kind))
+			}
+			Expect(kinds).To(ConsistOf(
+				"originalPoster issue",
+				"contributor pull_request",
+				"originalPoster issue",
+				"contributor pull_request",
+				"originalPoster comment",
+				"maintainer comment",
+				"maintainer comment",
+			))
+		})
+	})
+}
@@ -0,0 +1,294 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DiscussionOptions turns on measuring GitHub Discussions alongside issues.
+type DiscussionOptions struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// Discussion is a GitHub Discussion with its comments and their replies,
+// flattened in no particular order. AnswerChosenAt is only set once an answer
+// has been accepted, which only answerable categories, such as Q&A, allow.
+type Discussion struct {
+	Number         int
+	Title          string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Author         string
+	AuthorType     string
+	Category       string
+	Answerable     bool
+	AnswerChosenAt time.Time
+	Comments       []DiscussionComment
+}
+
+type DiscussionComment struct {
+	Login       string
+	Type        string
+	Association string
+	CreatedAt   time.Time
+}
+
+// DiscussionResult is the measurement of one discussion: its time to first
+// maintainer reply, as a FirstContact, and for answerable discussions whether
+// and how soon an answer was accepted.
+type DiscussionResult struct {
+	Contact      FirstContact
+	Answerable   bool
+	Answered     bool
+	TimeToAnswer time.Duration
+}
+
+// DiscussionReport summarizes a repository's discussions. MedianFirstReply is
+// over all of them, waiting ones counted until now, as first contact times
+// are; MedianTimeToAnswer is over the answered ones.
+type DiscussionReport struct {
+	Name               string  `json:"name"`
+	Discussions        int     `json:"discussions"`
+	Replied            int     `json:"replied"`
+	MedianFirstReply   float64 `json:"median_first_reply_minutes"`
+	Answerable         int     `json:"answerable"`
+	Answered           int     `json:"answered"`
+	MedianTimeToAnswer float64 `json:"median_time_to_answer_hours"`
+}
+
+const recentDiscussionsQuery = `query($owner: String!, $name: String!, $cursor: String, $pageSize: Int!, $comments: Int!) {
+  repository(owner: $owner, name: $name) {
+    discussions(first: $pageSize, after: $cursor, orderBy: {field: UPDATED_AT, direction: DESC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number
+        title
+        createdAt
+        updatedAt
+        answerChosenAt
+        author { login __typename }
+        category { name isAnswerable }
+        comments(first: $comments) {
+          nodes {
+            createdAt authorAssociation author { login __typename }
+            replies(first: $comments) {
+              nodes { createdAt authorAssociation author { login __typename } }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+type graphqlDiscussionComment struct {
+	CreatedAt         string        `json:"createdAt"`
+	AuthorAssociation string        `json:"authorAssociation"`
+	Author            *graphqlActor `json:"author"`
+}
+
+type graphqlDiscussions struct {
+	Repository struct {
+		Discussions struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				Number         int           `json:"number"`
+				Title          string        `json:"title"`
+				CreatedAt      string        `json:"createdAt"`
+				UpdatedAt      string        `json:"updatedAt"`
+				AnswerChosenAt string        `json:"answerChosenAt"`
+				Author         *graphqlActor `json:"author"`
+				Category       struct {
+					Name         string `json:"name"`
+					IsAnswerable bool   `json:"isAnswerable"`
+				} `json:"category"`
+				Comments struct {
+					Nodes []struct {
+						graphqlDiscussionComment
+						Replies struct {
+							Nodes []graphqlDiscussionComment `json:"nodes"`
+						} `json:"replies"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"nodes"`
+		} `json:"discussions"`
+	} `json:"repository"`
+}
+
+// GetRecentDiscussions returns the repository's discussions updated within
+// the window, with their first comments and each comment's first replies.
+// The REST API does not cover discussions. Discussions cannot be filtered by
+// date, so they are listed most recently updated first until one is older
+// than the window.
+func (c *GraphQLClient) GetRecentDiscussions(repo Repository, clock Clock, window time.Duration) ([]Discussion, error) {
+	parts := strings.SplitN(repo.Name, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("getting recent discussions: %q is not a full repository name", repo.Name)
+	}
+
+	since := clock.Now().UTC().Add(-window)
+	variables := map[string]interface{}{
+		"owner":    parts[0],
+		"name":     parts[1],
+		"pageSize": c.PageSize,
+		"comments": c.Comments,
+	}
+
+	discussions := []Discussion{}
+	for {
+		var page graphqlDiscussions
+		err := c.Query(recentDiscussionsQuery, variables, &page)
+		if err != nil {
+			return nil, fmt.Errorf("getting recent discussions: %s", err)
+		}
+
+		for _, node := range page.Repository.Discussions.Nodes {
+			discussion := Discussion{
+				Number:     node.Number,
+				Title:      node.Title,
+				Category:   node.Category.Name,
+				Answerable: node.Category.IsAnswerable,
+				Comments:   []DiscussionComment{},
+			}
+			discussion.Author, discussion.AuthorType = node.Author.user()
+
+			times := []struct {
+				value  string
+				parsed *time.Time
+			}{
+				{node.CreatedAt, &discussion.CreatedAt},
+				{node.UpdatedAt, &discussion.UpdatedAt},
+				{node.AnswerChosenAt, &discussion.AnswerChosenAt},
+			}
+			for _, t := range times {
+				if t.value == "" {
+					continue
+				}
+				*t.parsed, err = time.Parse(time.RFC3339, t.value)
+				if err != nil {
+					return nil, fmt.Errorf("getting recent discussions: could not parse time of %s#%d: %s", repo.Name, node.Number, err)
+				}
+			}
+			if discussion.UpdatedAt.Before(since) {
+				return discussions, nil
+			}
+
+			for _, commentNode := range node.Comments.Nodes {
+				for _, item := range append([]graphqlDiscussionComment{commentNode.graphqlDiscussionComment}, commentNode.Replies.Nodes...) {
+					comment := DiscussionComment{Association: item.AuthorAssociation}
+					comment.Login, comment.Type = item.Author.user()
+					comment.CreatedAt, err = time.Parse(time.RFC3339, item.CreatedAt)
+					if err != nil {
+						return nil, fmt.Errorf("getting recent discussions: could not parse comment time on %s#%d: %s", repo.Name, node.Number, err)
+					}
+					discussion.Comments = append(discussion.Comments, comment)
+				}
+			}
+			discussions = append(discussions, discussion)
+		}
+
+		if !page.Repository.Discussions.PageInfo.HasNextPage {
+			return discussions, nil
+		}
+		variables["cursor"] = page.Repository.Discussions.PageInfo.EndCursor
+	}
+}
+
+// MeasureDiscussions measures the repository's discussions, leaving out those
+// opened by bots and ignored users as first contact does for issues.
+func MeasureDiscussions(repo string, discussions []Discussion, now time.Time, options ContactOptions) ([]DiscussionResult, error) {
+	var results []DiscussionResult
+	for _, discussion := range discussions {
+		if ignoredAuthor(discussion.Author, discussion.AuthorType, options) {
+			continue
+		}
+		result, err := NewDiscussionResult(repo, discussion, now, options)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s#%d: %s", repo, discussion.Number, err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// NewDiscussionResult measures a discussion. Its first maintainer reply is
+// the earliest comment or reply by an owner, member or collaborator who is
+// not its author, an ignored user or a bot; business hours apply to it as
+// they do to first contact.
+func NewDiscussionResult(repo string, discussion Discussion, now time.Time, options ContactOptions) (DiscussionResult, error) {
+	result := DiscussionResult{
+		Contact: FirstContact{
+			Issue:     IssueRef{Repo: repo, Number: discussion.Number},
+			CreatedAt: discussion.CreatedAt,
+			Status:    StatusAwaiting,
+		},
+		Answerable: discussion.Answerable,
+	}
+
+	end := now
+	for _, comment := range discussion.Comments {
+		if comment.Login == discussion.Author || !contains(coreAssociations, comment.Association) {
+			continue
+		}
+		if ignoredActor(comment.Login, comment.Type, options.IgnoredUsers) || options.Bots.IsBot(comment.Login) {
+			continue
+		}
+		if result.Contact.Response == nil || comment.CreatedAt.Before(result.Contact.Response.At) {
+			result.Contact.Response = &Response{At: comment.CreatedAt, Actor: comment.Login, Kind: ResponseComment}
+			result.Contact.Status = StatusResponded
+			end = comment.CreatedAt
+		}
+	}
+
+	result.Contact.Elapsed = end.Sub(discussion.CreatedAt)
+	if options.BusinessHours.Enabled() {
+		var err error
+		result.Contact.Elapsed, err = options.BusinessHours.Elapsed(discussion.CreatedAt, end)
+		if err != nil {
+			return DiscussionResult{}, fmt.Errorf("could not measure business hours: %s", err)
+		}
+	}
+
+	if discussion.Answerable && !discussion.AnswerChosenAt.IsZero() {
+		result.Answered = true
+		result.TimeToAnswer = discussion.AnswerChosenAt.Sub(discussion.CreatedAt)
+	}
+	return result, nil
+}
+
+// NewDiscussionReports summarizes the discussion results of each repository,
+// ordered by name.
+func NewDiscussionReports(results map[string][]DiscussionResult) []DiscussionReport {
+	reports := []DiscussionReport{}
+	for name, repoResults := range results {
+		reports = append(reports, NewDiscussionReport(name, repoResults))
+	}
+	sort.Slice(reports, func(i, j int) bool { return reports[i].Name < reports[j].Name })
+	return reports
+}
+
+func NewDiscussionReport(name string, results []DiscussionResult) DiscussionReport {
+	report := DiscussionReport{Name: name, Discussions: len(results)}
+	var replies, answers []float64
+	for _, result := range results {
+		replies = append(replies, result.Contact.Minutes())
+		if result.Contact.Status == StatusResponded {
+			report.Replied++
+		}
+		if result.Answerable {
+			report.Answerable++
+		}
+		if result.Answered {
+			report.Answered++
+			answers = append(answers, result.TimeToAnswer.Hours())
+		}
+	}
+	report.MedianFirstReply = Median(replies)
+	report.MedianTimeToAnswer = Median(answers)
+	return report
+}
@@ -0,0 +1,126 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testDiscussions(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var httpClient *fakes.HTTPClient
+	var clock *fakes.Clock
+	var client GraphQLClient
+	var requests []map[string]interface{}
+	var now time.Time
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		httpClient = &fakes.HTTPClient{}
+		httpClient.DoCall.Stub = func(req *http.Request) (*http.Response, error) {
+			var payload map[string]interface{}
+			body, _ := ioutil.ReadAll(req.Body)
+			Expect(json.Unmarshal(body, &payload)).To(Succeed())
+			requests = append(requests, payload)
+
+			fixtures := []string{"discussions_page_1.json", "discussions_page_2.json"}
+			contents, err := ioutil.ReadFile(filepath.Join("testdata", "graphql", fixtures[len(requests)-1]))
+			Expect(err).NotTo(HaveOccurred())
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(contents))}, nil
+		}
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = now
+		requests = nil
+
+		client = NewGraphQLClient("https://api.github.com/graphql", httpClient, StaticToken("some-token"))
+	})
+
+	context("GetRecentDiscussions", func() {
+		it("pages until discussions were last updated before the window", func() {
+			discussions, err := client.GetRecentDiscussions(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[1]["variables"]).To(HaveKeyWithValue("cursor", "Y3Vyc29yOnYyOpK5MjAwMS0wMS0xNVQyMDoyMDoyMFo="))
+
+			var numbers []int
+			for _, discussion := range discussions {
+				numbers = append(numbers, discussion.Number)
+			}
+			Expect(numbers).To(Equal([]int{7, 6, 5}))
+			Expect(discussions[0].Comments).To(HaveLen(4))
+			Expect(discussions[0].AnswerChosenAt).To(Equal(time.Date(2001, time.January, 21, 20, 20, 20, 0, time.UTC)))
+			Expect(discussions[2].Author).To(Equal("ghost"))
+		})
+	})
+
+	context("MeasureDiscussions", func() {
+		var discussions []Discussion
+
+		it.Before(func() {
+			var err error
+			discussions, err = client.GetRecentDiscussions(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("measures the first maintainer reply, including replies to comments", func() {
+			results, err := MeasureDiscussions("example-org/example-repo", discussions, now, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(3))
+
+			Expect(results[0].Contact.Response).To(Equal(&Response{
+				At:    time.Date(2001, time.January, 20, 22, 20, 20, 0, time.UTC),
+				Actor: "maintainer",
+				Kind:  ResponseComment,
+			}))
+			Expect(results[0].Contact.Minutes()).To(Equal(120.0))
+			Expect(results[0].Answered).To(BeTrue())
+			Expect(results[0].TimeToAnswer).To(Equal(24 * time.Hour))
+			Expect(results[1].Answerable).To(BeFalse())
+			Expect(results[2].Contact.Status).To(Equal(StatusAwaiting))
+		})
+
+		it("leaves out discussions opened by ignored users", func() {
+			results, err := MeasureDiscussions("example-org/example-repo", discussions, now, ContactOptions{IgnoredUsers: []string{"some-user"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+		})
+
+		it("leaves out discussions opened by bot accounts", func() {
+			discussions[0].AuthorType = "Bot"
+			results, err := MeasureDiscussions("example-org/example-repo", discussions, now, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+		})
+
+		it("summarizes them alongside the issue report", func() {
+			results, err := MeasureDiscussions("example-org/example-repo", discussions, now, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			report := Report{Discussions: NewDiscussionReports(map[string][]DiscussionResult{"example-org/example-repo": results})}
+			Expect(report.Discussions).To(Equal([]DiscussionReport{{
+				Name:               "example-org/example-repo",
+				Discussions:        3,
+				Replied:            2,
+				MedianFirstReply:   1440,
+				Answerable:         2,
+				Answered:           1,
+				MedianTimeToAnswer: 24,
+			}}))
+
+			var output bytes.Buffer
+			Expect(report.Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  3            2        1440                      1 of 2    24"))
+		})
+	})
+}
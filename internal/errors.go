@@ -0,0 +1,88 @@
+package internal
+
+import "fmt"
+
+// ErrorPolicy decides what happens when an issue cannot be measured, e.g.
+// because it was deleted or has a malformed timestamp. By default the run
+// stops at the first such issue. With Continue, the issue is left out and
+// reported, and the run only fails if more than Budget, a fraction of all
+// issues, could not be measured. A repository whose issues cannot be listed
+// at all is reported the same way, and counts as one failed issue.
+type ErrorPolicy struct {
+	Continue bool    `yaml:"continue"`
+	Budget   float64 `yaml:"budget"`
+}
+
+func (p ErrorPolicy) Validate() error {
+	if p.Budget < 0 || p.Budget > 1 {
+		return fmt.Errorf("budget: must be between 0 and 1, got %g", p.Budget)
+	}
+	return nil
+}
+
+// IssueError is why an issue could not be measured, or, without a Number, why
+// a whole repository could not be.
+type IssueError struct {
+	Repo         string
+	Number       int
+	MergeRequest bool
+	Err          error
+}
+
+func (e IssueError) Error() string {
+	if e.Number == 0 {
+		return fmt.Sprintf("%s: %s", e.Repo, e.Err)
+	}
+	return fmt.Sprintf("%s: %s", IssueRef{Repo: e.Repo, Number: e.Number, MergeRequest: e.MergeRequest}, e.Err)
+}
+
+// ErrorSummary reports the issues a run could not measure.
+type ErrorSummary struct {
+	Issues   int                `json:"issues"`
+	Failed   int                `json:"failed"`
+	Rate     float64            `json:"rate"`
+	Budget   float64            `json:"budget"`
+	Exceeded bool               `json:"exceeded"`
+	Failures []IssueErrorReport `json:"failures"`
+}
+
+type IssueErrorReport struct {
+	Repo         string `json:"repo"`
+	Number       int    `json:"number,omitempty"`
+	MergeRequest bool   `json:"merge_request,omitempty"`
+	Error        string `json:"error"`
+}
+
+// NewErrorSummary summarizes failures out of issues measured in total,
+// including the failed ones, against the budget.
+func NewErrorSummary(failures []IssueError, issues int, budget float64) ErrorSummary {
+	summary := ErrorSummary{
+		Issues:   issues,
+		Failed:   len(failures),
+		Budget:   budget,
+		Failures: []IssueErrorReport{},
+	}
+	if issues > 0 {
+		summary.Rate = float64(len(failures)) / float64(issues)
+	}
+	summary.Exceeded = summary.Rate > budget
+
+	for _, failure := range failures {
+		summary.Failures = append(summary.Failures, IssueErrorReport{
+			Repo:         failure.Repo,
+			Number:       failure.Number,
+			MergeRequest: failure.MergeRequest,
+			Error:        failure.Err.Error(),
+		})
+	}
+	return summary
+}
+
+// Err returns an error if the failures exceed the budget.
+func (s ErrorSummary) Err() error {
+	if !s.Exceeded {
+		return nil
+	}
+	return fmt.Errorf("%d of %d issues could not be measured (%.1f%%), over the error budget of %.1f%%",
+		s.Failed, s.Issues, s.Rate*100, s.Budget*100)
+}
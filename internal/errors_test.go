@@ -0,0 +1,66 @@
+package internal_test
+
+import (
+	"errors"
+	"testing"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testErrors(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("IssueError", func() {
+		it("names the issue", func() {
+			err := IssueError{Repo: "example-org/example-repo", Number: 7, Err: errors.New("some error")}
+			Expect(err).To(MatchError("example-org/example-repo#7: some error"))
+		})
+
+		it("names GitLab merge requests with !", func() {
+			err := IssueError{Repo: "example-group/example-project", Number: 7, MergeRequest: true, Err: errors.New("some error")}
+			Expect(err).To(MatchError("example-group/example-project!7: some error"))
+		})
+
+		it("names the repository when no issue could be listed", func() {
+			err := IssueError{Repo: "example-org/example-repo", Err: errors.New("some error")}
+			Expect(err).To(MatchError("example-org/example-repo: some error"))
+		})
+	})
+
+	context("NewErrorSummary", func() {
+		var failures []IssueError
+
+		it.Before(func() {
+			failures = []IssueError{{Repo: "example-org/example-repo", Number: 7, Err: errors.New("some error")}}
+		})
+
+		it("accepts failures within the budget", func() {
+			summary := NewErrorSummary(failures, 10, 0.1)
+			Expect(summary.Rate).To(Equal(0.1))
+			Expect(summary.Exceeded).To(BeFalse())
+			Expect(summary.Err()).To(Succeed())
+		})
+
+		it("fails when the failures exceed the budget", func() {
+			summary := NewErrorSummary(failures, 4, 0.1)
+			Expect(summary.Exceeded).To(BeTrue())
+			Expect(summary.Err()).To(MatchError("1 of 4 issues could not be measured (25.0%), over the error budget of 10.0%"))
+		})
+
+		it("accepts a run without issues", func() {
+			summary := NewErrorSummary(nil, 0, 0)
+			Expect(summary.Err()).To(Succeed())
+			Expect(summary.Failures).To(BeEmpty())
+		})
+	})
+
+	context("ErrorPolicy.Validate", func() {
+		it("rejects a budget outside 0 to 1", func() {
+			Expect(ErrorPolicy{Budget: 0.05}.Validate()).To(Succeed())
+			Expect(ErrorPolicy{Budget: 5}.Validate()).To(MatchError("budget: must be between 0 and 1, got 5"))
+		})
+	})
+}
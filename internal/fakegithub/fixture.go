@@ -0,0 +1,140 @@
+package fakegithub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Fixture is the data a Server serves: organizations with their
+// repositories, each repository's issues and pull requests, commits, tags,
+// releases, security advisories and files, and each issue's comments. Times are RFC 3339 strings, as the API returns them.
+type Fixture struct {
+	Orgs []Org `json:"orgs"`
+}
+
+type Org struct {
+	Login string `json:"login"`
+	Repos []Repo `json:"repos"`
+}
+
+// Repo is served with the fields GitHub always sets filled in from Name and
+// the org. Fields holds any others, such as archived or topics, as they
+// should appear in the response.
+type Repo struct {
+	Name       string                 `json:"name"`
+	Fields     map[string]interface{} `json:"fields"`
+	Issues     []Issue                `json:"issues"`
+	Commits    []Commit               `json:"commits"`
+	Tags       []Tag                  `json:"tags"`
+	Releases   []Release              `json:"releases"`
+	Advisories []Advisory             `json:"advisories"`
+	// Files holds the contents of files by their path, such as
+	// ".github/ISSUE_TEMPLATE/bug.md".
+	Files map[string]string `json:"files"`
+}
+
+type Issue struct {
+	Number            int       `json:"number"`
+	Title             string    `json:"title"`
+	Body              string    `json:"body"`
+	State             string    `json:"state"`
+	User              User      `json:"user"`
+	AuthorAssociation string    `json:"author_association"`
+	Labels            []string  `json:"labels"`
+	PullRequest       bool      `json:"pull_request"`
+	CreatedAt         string    `json:"created_at"`
+	UpdatedAt         string    `json:"updated_at"`
+	ClosedAt          string    `json:"closed_at"`
+	MergedAt          string    `json:"merged_at"`
+	Comments          []Comment `json:"comments"`
+	Events            []Event   `json:"events"`
+	Reviews           []Review  `json:"reviews"`
+	Commits           []Commit  `json:"commits"`
+	// CrossReferences are pull requests of the same repository that mention
+	// the issue, listed in its timeline.
+	CrossReferences []CrossReference `json:"cross_references"`
+}
+
+type Comment struct {
+	User              User   `json:"user"`
+	AuthorAssociation string `json:"author_association"`
+	CreatedAt         string `json:"created_at"`
+}
+
+// Event is an entry in an issue's event log, such as "labeled" or "closed".
+// Label names the label of "labeled" and "unlabeled" events.
+type Event struct {
+	Event     string `json:"event"`
+	Actor     User   `json:"actor"`
+	Label     string `json:"label"`
+	CreatedAt string `json:"created_at"`
+}
+
+// CrossReference is a mention of an issue by pull request Number.
+type CrossReference struct {
+	Number    int    `json:"number"`
+	CreatedAt string `json:"created_at"`
+}
+
+// Review is a pull request review.
+type Review struct {
+	User        User   `json:"user"`
+	State       string `json:"state"`
+	SubmittedAt string `json:"submitted_at"`
+}
+
+// Commit is a commit on the default branch. Author is served as null when its
+// login is empty, as GitHub does for commits by emails it cannot match to a
+// user.
+type Commit struct {
+	SHA    string `json:"sha"`
+	Author User   `json:"author"`
+	Name   string `json:"name"`
+	Date   string `json:"date"`
+}
+
+// Tag points at a commit of the default branch by its SHA.
+type Tag struct {
+	Name string `json:"name"`
+	SHA  string `json:"sha"`
+}
+
+// Advisory is a repository security advisory, by its GHSA ID. State is one
+// of triage, draft, published or closed.
+type Advisory struct {
+	GHSAID      string `json:"ghsa_id"`
+	Summary     string `json:"summary"`
+	Severity    string `json:"severity"`
+	State       string `json:"state"`
+	CreatedAt   string `json:"created_at"`
+	PublishedAt string `json:"published_at"`
+	ClosedAt    string `json:"closed_at"`
+}
+
+type Release struct {
+	TagName     string `json:"tag_name"`
+	Draft       bool   `json:"draft"`
+	PublishedAt string `json:"published_at"`
+}
+
+// User is a login, and a type of "User" unless Type says otherwise.
+type User struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
+}
+
+// LoadFixture reads a fixture from a JSON file.
+func LoadFixture(path string) (Fixture, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("reading fixture: %s", err)
+	}
+
+	var fixture Fixture
+	err = json.Unmarshal(contents, &fixture)
+	if err != nil {
+		return Fixture{}, fmt.Errorf("parsing fixture %s: %s", path, err)
+	}
+	return fixture, nil
+}
@@ -0,0 +1,14 @@
+package fakegithub_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestFakeGitHub(t *testing.T) {
+	suite := spec.New("gloss/internal/fakegithub", spec.Report(report.Terminal{}))
+	suite("TestServer", testServer)
+	suite.Run(t)
+}
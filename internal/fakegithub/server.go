@@ -0,0 +1,800 @@
+// Package fakegithub serves the parts of the GitHub REST API gloss reads from
+// fixture data, so that tests and demos can run the real API client against
+// something that pages with Link headers, answers conditional requests with
+// 304 Not Modified and counts requests against a rate limit like GitHub does.
+package fakegithub
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is an httptest.Server backed by a Handler.
+type Server struct {
+	*httptest.Server
+	Handler *Handler
+}
+
+// NewServer starts a server for the fixture. Close it when done.
+func NewServer(fixture Fixture) *Server {
+	handler := NewHandler(fixture)
+	return &Server{Server: httptest.NewServer(handler), Handler: handler}
+}
+
+// Handler serves these endpoints from its fixture:
+//
+//	GET /orgs/{org}/repos
+//	GET /repos/{owner}/{repo}/issues
+//	GET /repos/{owner}/{repo}/issues/comments
+//	GET /repos/{owner}/{repo}/issues/{number}/comments
+//	GET /repos/{owner}/{repo}/issues/{number}/events
+//	GET /repos/{owner}/{repo}/issues/{number}/timeline
+//	GET /repos/{owner}/{repo}/pulls/{number}/reviews
+//	GET /repos/{owner}/{repo}/pulls/{number}/commits
+//	GET /repos/{owner}/{repo}/commits
+//	GET /repos/{owner}/{repo}/tags
+//	GET /repos/{owner}/{repo}/releases
+//	GET /repos/{owner}/{repo}/security-advisories
+//	GET /repos/{owner}/{repo}/contents/{path}
+//
+// Lists are paged by page and per_page (30 by default, at most 100). Issues
+// can be filtered by state, since, creator and labels, all of which they must
+// have, and are sorted newest first. A repository's comments can be filtered
+// by since, and are sorted oldest first. An issue's timeline holds its events
+// followed by its cross-references. A pull request's commits are listed in
+// the order they were made. Commits of the default branch can be filtered by
+// since and until, and by sha, which names the default branch or a tag to
+// list the commits up to; they are sorted newest first, and a repository
+// without any refuses to list them with 409 Conflict, as GitHub does. Security advisories
+// can be filtered by state, and are sorted newest first. Contents are served
+// from the repository's files: a directory as the list of its entries, and a
+// file, unlike everything else, as a single object with its content
+// base64-encoded.
+//
+// When Token is set, requests without it in their Authorization header are
+// refused. Every other request except a 304 Not Modified uses up one of
+// RateLimit requests, after which the rest are refused until ResetAt.
+type Handler struct {
+	Fixture   Fixture
+	Token     string
+	RateLimit int
+	ResetAt   time.Time
+
+	mutex    sync.Mutex
+	used     int
+	requests []string
+}
+
+func NewHandler(fixture Fixture) *Handler {
+	return &Handler{
+		Fixture:   fixture,
+		RateLimit: 5000,
+		ResetAt:   time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+}
+
+// Requests returns the method, path and query of each request served so far.
+func (h *Handler) Requests() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return append([]string{}, h.requests...)
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.requests = append(h.requests, fmt.Sprintf("%s %s", req.Method, req.URL.RequestURI()))
+
+	if h.Token != "" && !authorized(req, h.Token) {
+		writeMessage(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+
+	status, body, link, message := h.respond(req)
+	if status != http.StatusOK {
+		writeMessage(w, status, message)
+		return
+	}
+	etag := fmt.Sprintf(`W/"%x"`, sha256.Sum256(body))
+
+	if req.Header.Get("If-None-Match") == etag {
+		h.writeRateLimit(w)
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if h.used >= h.RateLimit {
+		h.writeRateLimit(w)
+		writeMessage(w, http.StatusForbidden, "API rate limit exceeded")
+		return
+	}
+	h.used++
+	h.writeRateLimit(w)
+
+	if link != "" {
+		w.Header().Set("Link", link)
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// respond renders the response body for the request, with the Link header for
+// the page of a list, or the status and message to fail with.
+func (h *Handler) respond(req *http.Request) (int, []byte, string, string) {
+	if file, ok := h.file(req); ok {
+		body, err := json.Marshal(file)
+		if err != nil {
+			return http.StatusInternalServerError, nil, "", err.Error()
+		}
+		return http.StatusOK, body, "", ""
+	}
+
+	status, items := h.route(req)
+	if status == http.StatusConflict {
+		return status, nil, "", "Git Repository is empty."
+	}
+	if status != http.StatusOK {
+		return status, nil, "", http.StatusText(status)
+	}
+
+	page, perPage, err := paging(req.URL.Query())
+	if err != nil {
+		return http.StatusUnprocessableEntity, nil, "", err.Error()
+	}
+	lastPage := (len(items) + perPage - 1) / perPage
+	if lastPage == 0 {
+		lastPage = 1
+	}
+	start, end := (page-1)*perPage, page*perPage
+	if start > len(items) {
+		start = len(items)
+	}
+	if end > len(items) {
+		end = len(items)
+	}
+
+	body, err := json.Marshal(items[start:end])
+	if err != nil {
+		return http.StatusInternalServerError, nil, "", err.Error()
+	}
+	return http.StatusOK, body, links(baseURL(req)+req.URL.Path, req.URL.Query(), page, lastPage), ""
+}
+
+// route finds the items to list for the request, or the status to fail with.
+func (h *Handler) route(req *http.Request) (int, []map[string]interface{}) {
+	if req.Method != "GET" {
+		return http.StatusNotFound, nil
+	}
+
+	base := baseURL(req)
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "orgs" && parts[2] == "repos":
+		for _, org := range h.Fixture.Orgs {
+			if org.Login == parts[1] {
+				repos := []map[string]interface{}{}
+				for _, repo := range org.Repos {
+					repos = append(repos, repoJSON(base, org, repo))
+				}
+				return http.StatusOK, repos
+			}
+		}
+
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "issues":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			issues, err := issuesJSON(base, parts[1], repo, req.URL.Query())
+			if err != nil {
+				return http.StatusUnprocessableEntity, nil
+			}
+			return http.StatusOK, issues
+		}
+
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "issues" && parts[4] == "comments":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			comments, err := repoCommentsJSON(base, parts[1], repo, req.URL.Query())
+			if err != nil {
+				return http.StatusUnprocessableEntity, nil
+			}
+			return http.StatusOK, comments
+		}
+
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && parts[5] == "timeline":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			for _, issue := range repo.Issues {
+				if strconv.Itoa(issue.Number) == parts[4] {
+					return http.StatusOK, timelineJSON(base, parts[1], repo, issue)
+				}
+			}
+		}
+
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "issues" && (parts[5] == "comments" || parts[5] == "events"):
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			for _, issue := range repo.Issues {
+				if strconv.Itoa(issue.Number) != parts[4] {
+					continue
+				}
+				items := []map[string]interface{}{}
+				if parts[5] == "comments" {
+					for _, comment := range issue.Comments {
+						items = append(items, commentJSON(comment))
+					}
+				} else {
+					for _, event := range issue.Events {
+						items = append(items, eventJSON(event))
+					}
+				}
+				return http.StatusOK, items
+			}
+		}
+
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "commits":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			if len(repo.Commits) == 0 {
+				return http.StatusConflict, nil
+			}
+			commits, err := commitsJSON(repo, req.URL.Query())
+			if err != nil {
+				return http.StatusUnprocessableEntity, nil
+			}
+			return http.StatusOK, commits
+		}
+
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "tags":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			tags := []map[string]interface{}{}
+			for _, tag := range repo.Tags {
+				tags = append(tags, map[string]interface{}{
+					"name":   tag.Name,
+					"commit": map[string]string{"sha": tag.SHA},
+				})
+			}
+			return http.StatusOK, tags
+		}
+
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "releases":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			releases := []map[string]interface{}{}
+			for _, release := range repo.Releases {
+				fields := map[string]interface{}{
+					"tag_name":     release.TagName,
+					"draft":        release.Draft,
+					"published_at": nil,
+				}
+				if release.PublishedAt != "" {
+					fields["published_at"] = release.PublishedAt
+				}
+				releases = append(releases, fields)
+			}
+			return http.StatusOK, releases
+		}
+
+	case len(parts) == 4 && parts[0] == "repos" && parts[3] == "security-advisories":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			return http.StatusOK, advisoriesJSON(repo, req.URL.Query())
+		}
+
+	case len(parts) > 4 && parts[0] == "repos" && parts[3] == "contents":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			if entries := contentsJSON(repo, strings.Join(parts[4:], "/")); len(entries) > 0 {
+				return http.StatusOK, entries
+			}
+		}
+
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "reviews":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			for _, issue := range repo.Issues {
+				if issue.PullRequest && strconv.Itoa(issue.Number) == parts[4] {
+					reviews := []map[string]interface{}{}
+					for _, review := range issue.Reviews {
+						reviews = append(reviews, map[string]interface{}{
+							"user":         userJSON(review.User),
+							"state":        review.State,
+							"submitted_at": review.SubmittedAt,
+						})
+					}
+					return http.StatusOK, reviews
+				}
+			}
+		}
+
+	case len(parts) == 6 && parts[0] == "repos" && parts[3] == "pulls" && parts[5] == "commits":
+		if repo, ok := h.repo(parts[1], parts[2]); ok {
+			for _, issue := range repo.Issues {
+				if issue.PullRequest && strconv.Itoa(issue.Number) == parts[4] {
+					commits := []map[string]interface{}{}
+					for _, commit := range issue.Commits {
+						commits = append(commits, commitJSON(commit))
+					}
+					return http.StatusOK, commits
+				}
+			}
+		}
+	}
+	return http.StatusNotFound, nil
+}
+
+// file finds the file the request asks the contents of, which unlike
+// everything else is served as an object rather than a list.
+func (h *Handler) file(req *http.Request) (map[string]interface{}, bool) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if req.Method != "GET" || len(parts) < 5 || parts[0] != "repos" || parts[3] != "contents" {
+		return nil, false
+	}
+	repo, ok := h.repo(parts[1], parts[2])
+	if !ok {
+		return nil, false
+	}
+	path := strings.Join(parts[4:], "/")
+	contents, ok := repo.Files[path]
+	if !ok {
+		return nil, false
+	}
+	fields := contentJSON(path, contents)
+	fields["encoding"] = "base64"
+	fields["content"] = base64.StdEncoding.EncodeToString([]byte(contents))
+	return fields, true
+}
+
+func (h *Handler) repo(owner, name string) (Repo, bool) {
+	for _, org := range h.Fixture.Orgs {
+		if org.Login != owner {
+			continue
+		}
+		for _, repo := range org.Repos {
+			if repo.Name == name {
+				return repo, true
+			}
+		}
+	}
+	return Repo{}, false
+}
+
+func (h *Handler) writeRateLimit(w http.ResponseWriter) {
+	remaining := h.RateLimit - h.used
+	if remaining < 0 {
+		remaining = 0
+	}
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(h.RateLimit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Used", strconv.Itoa(h.used))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(h.ResetAt.Unix(), 10))
+	w.Header().Set("X-RateLimit-Resource", "core")
+}
+
+func authorized(req *http.Request, token string) bool {
+	header := req.Header.Get("Authorization")
+	return header == "token "+token || header == "Bearer "+token || header == "bearer "+token
+}
+
+func baseURL(req *http.Request) string {
+	return fmt.Sprintf("http://%s", req.Host)
+}
+
+func writeMessage(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
+
+func paging(query url.Values) (int, int, error) {
+	page, perPage := 1, 30
+	if value := query.Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("page %q is not a positive number", value)
+		}
+		page = n
+	}
+	if value := query.Get("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("per_page %q is not a positive number", value)
+		}
+		perPage = n
+		if perPage > 100 {
+			perPage = 100
+		}
+	}
+	return page, perPage, nil
+}
+
+// links builds the Link header for a page of a list, which GitHub leaves out
+// when everything fits on one page.
+func links(base string, query url.Values, page, lastPage int) string {
+	if lastPage == 1 {
+		return ""
+	}
+
+	link := func(page int, rel string) string {
+		pageQuery := url.Values{}
+		for key, values := range query {
+			pageQuery[key] = values
+		}
+		pageQuery.Set("page", strconv.Itoa(page))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, base, pageQuery.Encode(), rel)
+	}
+
+	var rels []string
+	if page > 1 {
+		rels = append(rels, link(page-1, "prev"))
+	}
+	if page < lastPage {
+		rels = append(rels, link(page+1, "next"), link(lastPage, "last"))
+	}
+	if page > 1 {
+		rels = append(rels, link(1, "first"))
+	}
+	return strings.Join(rels, ", ")
+}
+
+func repoJSON(base string, org Org, repo Repo) map[string]interface{} {
+	fullName := fmt.Sprintf("%s/%s", org.Login, repo.Name)
+	fields := map[string]interface{}{
+		"archived":       false,
+		"fork":           false,
+		"is_template":    false,
+		"private":        false,
+		"topics":         []string{},
+		"language":       nil,
+		"pushed_at":      nil,
+		"default_branch": "main",
+	}
+	for key, value := range repo.Fields {
+		fields[key] = value
+	}
+	fields["name"] = repo.Name
+	fields["full_name"] = fullName
+	fields["url"] = fmt.Sprintf("%s/repos/%s", base, fullName)
+	fields["owner"] = map[string]interface{}{"login": org.Login}
+	return fields
+}
+
+func issuesJSON(base, owner string, repo Repo, query url.Values) ([]map[string]interface{}, error) {
+	state := query.Get("state")
+	if state == "" {
+		state = "open"
+	}
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var labels []string
+	if value := query.Get("labels"); value != "" {
+		labels = strings.Split(value, ",")
+	}
+	creator := query.Get("creator")
+
+	fixtures := append([]Issue{}, repo.Issues...)
+	sort.SliceStable(fixtures, func(i, j int) bool {
+		return fixtures[i].CreatedAt > fixtures[j].CreatedAt
+	})
+
+	issues := []map[string]interface{}{}
+	for _, issue := range fixtures {
+		issueState := issue.State
+		if issueState == "" {
+			issueState = "open"
+		}
+		if state != "all" && state != issueState {
+			continue
+		}
+		if !hasLabels(issue, labels) || (creator != "" && issue.User.Login != creator) {
+			continue
+		}
+
+		updatedAt := issue.UpdatedAt
+		if updatedAt == "" {
+			updatedAt = issue.CreatedAt
+		}
+		if !since.IsZero() {
+			updated, err := time.Parse(time.RFC3339, updatedAt)
+			if err != nil {
+				return nil, err
+			}
+			if updated.Before(since) {
+				continue
+			}
+		}
+
+		fields := issueJSON(base, owner, repo, issue)
+		issues = append(issues, fields)
+	}
+	return issues, nil
+}
+
+// issueJSON renders an issue, open unless its State says otherwise, and last
+// updated when it was created unless its UpdatedAt says otherwise.
+func issueJSON(base, owner string, repo Repo, issue Issue) map[string]interface{} {
+	issueState := issue.State
+	if issueState == "" {
+		issueState = "open"
+	}
+	updatedAt := issue.UpdatedAt
+	if updatedAt == "" {
+		updatedAt = issue.CreatedAt
+	}
+
+	issueURL := fmt.Sprintf("%s/repos/%s/%s/issues/%d", base, owner, repo.Name, issue.Number)
+	labels := []map[string]string{}
+	for _, label := range issue.Labels {
+		labels = append(labels, map[string]string{"name": label})
+	}
+	fields := map[string]interface{}{
+		"number":             issue.Number,
+		"title":              issue.Title,
+		"state":              issueState,
+		"url":                issueURL,
+		"comments_url":       issueURL + "/comments",
+		"user":               userJSON(issue.User),
+		"author_association": association(issue.AuthorAssociation),
+		"labels":             labels,
+		"body":               issue.Body,
+		"comments":           len(issue.Comments),
+		"created_at":         issue.CreatedAt,
+		"updated_at":         updatedAt,
+		"closed_at":          nil,
+	}
+	if issue.ClosedAt != "" {
+		fields["closed_at"] = issue.ClosedAt
+	}
+	if issue.PullRequest {
+		pullRequest := map[string]interface{}{
+			"url":       fmt.Sprintf("%s/repos/%s/%s/pulls/%d", base, owner, repo.Name, issue.Number),
+			"merged_at": nil,
+		}
+		if issue.MergedAt != "" {
+			pullRequest["merged_at"] = issue.MergedAt
+		}
+		fields["pull_request"] = pullRequest
+	}
+	return fields
+}
+
+func timelineJSON(base, owner string, repo Repo, issue Issue) []map[string]interface{} {
+	items := []map[string]interface{}{}
+	for _, event := range issue.Events {
+		items = append(items, eventJSON(event))
+	}
+	for _, reference := range issue.CrossReferences {
+		for _, source := range repo.Issues {
+			if source.Number == reference.Number {
+				items = append(items, map[string]interface{}{
+					"event":      "cross-referenced",
+					"created_at": reference.CreatedAt,
+					"source":     map[string]interface{}{"type": "issue", "issue": issueJSON(base, owner, repo, source)},
+				})
+			}
+		}
+	}
+	return items
+}
+
+func hasLabels(issue Issue, labels []string) bool {
+	for _, label := range labels {
+		found := false
+		for _, name := range issue.Labels {
+			found = found || name == label
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func repoCommentsJSON(base, owner string, repo Repo, query url.Values) ([]map[string]interface{}, error) {
+	var since time.Time
+	if value := query.Get("since"); value != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	comments := []map[string]interface{}{}
+	for _, issue := range repo.Issues {
+		for _, comment := range issue.Comments {
+			created, err := time.Parse(time.RFC3339, comment.CreatedAt)
+			if err != nil {
+				return nil, err
+			}
+			if created.Before(since) {
+				continue
+			}
+			fields := commentJSON(comment)
+			fields["issue_url"] = fmt.Sprintf("%s/repos/%s/%s/issues/%d", base, owner, repo.Name, issue.Number)
+			comments = append(comments, fields)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		return comments[i]["created_at"].(string) < comments[j]["created_at"].(string)
+	})
+	return comments, nil
+}
+
+func commitsJSON(repo Repo, query url.Values) ([]map[string]interface{}, error) {
+	var since, until time.Time
+	for key, bound := range map[string]*time.Time{"since": &since, "until": &until} {
+		if value := query.Get(key); value != "" {
+			var err error
+			*bound, err = time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if sha := query.Get("sha"); sha != "" && sha != "main" {
+		found := false
+		for _, tag := range repo.Tags {
+			for _, commit := range repo.Commits {
+				if tag.Name == sha && commit.SHA == tag.SHA {
+					tagged, err := time.Parse(time.RFC3339, commit.Date)
+					if err != nil {
+						return nil, err
+					}
+					if until.IsZero() || tagged.Before(until) {
+						until = tagged
+					}
+					found = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no branch or tag %q", sha)
+		}
+	}
+
+	fixtures := append([]Commit{}, repo.Commits...)
+	sort.SliceStable(fixtures, func(i, j int) bool {
+		return fixtures[i].Date > fixtures[j].Date
+	})
+
+	commits := []map[string]interface{}{}
+	for _, commit := range fixtures {
+		date, err := time.Parse(time.RFC3339, commit.Date)
+		if err != nil {
+			return nil, err
+		}
+		if date.Before(since) || (!until.IsZero() && date.After(until)) {
+			continue
+		}
+
+		commits = append(commits, commitJSON(commit))
+	}
+	return commits, nil
+}
+
+func commitJSON(commit Commit) map[string]interface{} {
+	var author interface{}
+	if commit.Author.Login != "" {
+		author = userJSON(commit.Author)
+	}
+	name := commit.Name
+	if name == "" {
+		name = commit.Author.Login
+	}
+	signature := map[string]string{"name": name, "date": commit.Date}
+	return map[string]interface{}{
+		"sha":    commit.SHA,
+		"commit": map[string]interface{}{"author": signature, "committer": signature},
+		"author": author,
+	}
+}
+
+func commentJSON(comment Comment) map[string]interface{} {
+	return map[string]interface{}{
+		"user":               userJSON(comment.User),
+		"author_association": association(comment.AuthorAssociation),
+		"created_at":         comment.CreatedAt,
+	}
+}
+
+func eventJSON(event Event) map[string]interface{} {
+	fields := map[string]interface{}{
+		"event":      event.Event,
+		"actor":      userJSON(event.Actor),
+		"created_at": event.CreatedAt,
+	}
+	if event.Label != "" {
+		fields["label"] = map[string]string{"name": event.Label}
+	}
+	return fields
+}
+
+// advisoriesJSON lists the repository's advisories in state, or all of them,
+// most recently created first.
+func advisoriesJSON(repo Repo, query url.Values) []map[string]interface{} {
+	advisories := append([]Advisory{}, repo.Advisories...)
+	sort.SliceStable(advisories, func(i, j int) bool { return advisories[i].CreatedAt > advisories[j].CreatedAt })
+
+	items := []map[string]interface{}{}
+	for _, advisory := range advisories {
+		if state := query.Get("state"); state != "" && advisory.State != state {
+			continue
+		}
+		fields := map[string]interface{}{
+			"ghsa_id":      advisory.GHSAID,
+			"summary":      advisory.Summary,
+			"severity":     advisory.Severity,
+			"state":        advisory.State,
+			"created_at":   advisory.CreatedAt,
+			"published_at": nil,
+			"closed_at":    nil,
+		}
+		if advisory.PublishedAt != "" {
+			fields["published_at"] = advisory.PublishedAt
+		}
+		if advisory.ClosedAt != "" {
+			fields["closed_at"] = advisory.ClosedAt
+		}
+		items = append(items, fields)
+	}
+	return items
+}
+
+// contentsJSON lists the files and directories directly in the directory at
+// path, sorted by name.
+func contentsJSON(repo Repo, path string) []map[string]interface{} {
+	entries := []map[string]interface{}{}
+	seen := make(map[string]bool)
+	for file, contents := range repo.Files {
+		if !strings.HasPrefix(file, path+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(file, path+"/")
+		if i := strings.Index(name, "/"); i >= 0 {
+			name = name[:i]
+			if !seen[name] {
+				seen[name] = true
+				entries = append(entries, map[string]interface{}{"name": name, "path": path + "/" + name, "type": "dir", "size": 0})
+			}
+			continue
+		}
+		entries = append(entries, contentJSON(file, contents))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i]["name"].(string) < entries[j]["name"].(string) })
+	return entries
+}
+
+func contentJSON(path, contents string) map[string]interface{} {
+	return map[string]interface{}{
+		"name": path[strings.LastIndex(path, "/")+1:],
+		"path": path,
+		"type": "file",
+		"size": len(contents),
+	}
+}
+
+func userJSON(user User) map[string]string {
+	userType := user.Type
+	if userType == "" {
+		userType = "User"
+	}
+	return map[string]string{"login": user.Login, "type": userType}
+}
+
+func association(value string) string {
+	if value == "" {
+		return "NONE"
+	}
+	return value
+}
@@ -0,0 +1,265 @@
+package fakegithub_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testServer(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var server *fakegithub.Server
+
+	get := func(path string, headers ...string) (*http.Response, []map[string]interface{}) {
+		req, err := http.NewRequest("GET", server.URL+path, nil)
+		Expect(err).NotTo(HaveOccurred())
+		for i := 0; i+1 < len(headers); i += 2 {
+			req.Header.Set(headers[i], headers[i+1])
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		Expect(err).NotTo(HaveOccurred())
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		var items []map[string]interface{}
+		if resp.StatusCode == http.StatusOK {
+			Expect(json.Unmarshal(body, &items)).To(Succeed())
+		}
+		return resp, items
+	}
+
+	it.Before(func() {
+		fixture, err := fakegithub.LoadFixture(filepath.Join("..", "testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("listing org repos", func() {
+		it("serves the repos with GitHub's fields", func() {
+			resp, repos := get("/orgs/example-org/repos")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(repos).To(HaveLen(3))
+			Expect(repos[0]).To(HaveKeyWithValue("full_name", "example-org/example-repo"))
+			Expect(repos[0]).To(HaveKeyWithValue("language", "Go"))
+			Expect(repos[0]).To(HaveKeyWithValue("archived", false))
+			Expect(repos[0]).To(HaveKeyWithValue("owner", map[string]interface{}{"login": "example-org"}))
+			Expect(repos[1]).To(HaveKeyWithValue("archived", true))
+		})
+
+		it("returns 404 for an unknown org", func() {
+			resp, _ := get("/orgs/missing-org/repos")
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	context("listing issues", func() {
+		it("serves open issues newest first", func() {
+			_, issues := get("/repos/example-org/example-repo/issues")
+			Expect(issues).To(HaveLen(3))
+			Expect(issues[0]).To(HaveKeyWithValue("number", 2.0))
+			Expect(issues[0]).To(HaveKey("pull_request"))
+			Expect(issues[1]).To(HaveKeyWithValue("number", 1.0))
+			Expect(issues[1]).To(HaveKeyWithValue("comments", 3.0))
+			Expect(issues[1]).To(HaveKeyWithValue("comments_url", server.URL+"/repos/example-org/example-repo/issues/1/comments"))
+			Expect(issues[1]).NotTo(HaveKey("pull_request"))
+		})
+
+		it("filters by state and since", func() {
+			_, issues := get("/repos/example-org/example-repo/issues?state=all&since=2001-01-01T00:00:00Z")
+			Expect(issues).To(HaveLen(4))
+			Expect(issues[0]).To(HaveKeyWithValue("number", 5.0))
+			Expect(issues[3]).To(HaveKeyWithValue("number", 1.0))
+		})
+
+		it("serves an issue's comments", func() {
+			_, comments := get("/repos/example-org/example-repo/issues/1/comments")
+			Expect(comments).To(HaveLen(3))
+			Expect(comments[1]).To(HaveKeyWithValue("user", map[string]interface{}{"login": "dependabot[bot]", "type": "Bot"}))
+		})
+
+		it("serves a repository's comments oldest first", func() {
+			_, comments := get("/repos/example-org/example-repo/issues/comments?since=2001-01-01T21:00:00Z")
+			Expect(comments).To(HaveLen(2))
+			Expect(comments[0]).To(HaveKeyWithValue("issue_url", server.URL+"/repos/example-org/example-repo/issues/1"))
+			Expect(comments[1]).To(HaveKeyWithValue("created_at", "2001-01-03T20:50:20Z"))
+		})
+
+		it("serves a pull request's reviews", func() {
+			_, reviews := get("/repos/example-org/example-repo/pulls/5/reviews")
+			Expect(reviews).To(HaveLen(2))
+			Expect(reviews[0]).To(HaveKeyWithValue("state", "APPROVED"))
+			Expect(reviews[0]).To(HaveKeyWithValue("submitted_at", "2001-01-11T20:20:20Z"))
+		})
+
+		it("serves an issue's events", func() {
+			_, events := get("/repos/example-org/example-repo/issues/4/events")
+			Expect(events).To(HaveLen(1))
+			Expect(events[0]).To(HaveKeyWithValue("event", "labeled"))
+			Expect(events[0]).To(HaveKeyWithValue("actor", map[string]interface{}{"login": "dependabot[bot]", "type": "Bot"}))
+		})
+
+		it("serves the commits up to a tag", func() {
+			_, commits := get("/repos/example-org/example-repo/commits?sha=v1.1.0&since=2001-01-01T00:00:00Z")
+			Expect(commits).To(HaveLen(2))
+			Expect(commits[0]).To(HaveKeyWithValue("sha", "c3"))
+			Expect(commits[1]).To(HaveKeyWithValue("sha", "c2"))
+		})
+
+		it("serves null for commit authors without a login", func() {
+			_, commits := get("/repos/example-org/example-repo/commits?per_page=1")
+			Expect(commits).To(HaveLen(1))
+			Expect(commits[0]).To(HaveKeyWithValue("author", BeNil()))
+		})
+
+		it("serves releases and tags", func() {
+			_, releases := get("/repos/example-org/example-repo/releases")
+			Expect(releases).To(HaveLen(4))
+			Expect(releases[0]).To(HaveKeyWithValue("published_at", BeNil()))
+
+			_, tags := get("/repos/example-org/example-repo/tags")
+			Expect(tags).To(HaveLen(2))
+			Expect(tags[0]).To(HaveKeyWithValue("commit", map[string]interface{}{"sha": "c3"}))
+		})
+	})
+
+	context("serving contents", func() {
+		it.Before(func() {
+			server.Close()
+			fixture, err := fakegithub.LoadFixture(filepath.Join("..", "testdata", "fakegithub", "triage.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server = fakegithub.NewServer(fixture)
+		})
+
+		it("lists a directory's entries", func() {
+			_, entries := get("/repos/example-org/example-repo/contents/.github")
+			Expect(entries).To(HaveLen(2))
+			Expect(entries[0]).To(HaveKeyWithValue("path", ".github/ISSUE_TEMPLATE"))
+			Expect(entries[0]).To(HaveKeyWithValue("type", "dir"))
+
+			_, entries = get("/repos/example-org/example-repo/contents/.github/ISSUE_TEMPLATE")
+			Expect(entries).To(HaveLen(3))
+			Expect(entries[0]).To(HaveKeyWithValue("name", "bug_report.md"))
+			Expect(entries[0]).NotTo(HaveKey("content"))
+		})
+
+		it("serves a file as an object with its content base64-encoded", func() {
+			resp, err := http.Get(server.URL + "/repos/example-org/example-repo/contents/.github/ISSUE_TEMPLATE/config.yml")
+			Expect(err).NotTo(HaveOccurred())
+			defer resp.Body.Close()
+
+			var file map[string]interface{}
+			Expect(json.NewDecoder(resp.Body).Decode(&file)).To(Succeed())
+			Expect(file).To(HaveKeyWithValue("encoding", "base64"))
+			Expect(file).To(HaveKeyWithValue("content", "YmxhbmtfaXNzdWVzX2VuYWJsZWQ6IGZhbHNlCg=="))
+		})
+
+		it("returns 404 for missing paths", func() {
+			resp, _ := get("/repos/example-org/other-repo/contents/.github/ISSUE_TEMPLATE")
+			Expect(resp.StatusCode).To(Equal(http.StatusNotFound))
+		})
+	})
+
+	context("serving security advisories", func() {
+		it.Before(func() {
+			server.Close()
+			fixture, err := fakegithub.LoadFixture(filepath.Join("..", "testdata", "fakegithub", "security.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server = fakegithub.NewServer(fixture)
+		})
+
+		it("serves them newest first, filtered by state", func() {
+			_, advisories := get("/repos/example-org/example-repo/security-advisories")
+			Expect(advisories).To(HaveLen(5))
+			Expect(advisories[0]).To(HaveKeyWithValue("ghsa_id", "GHSA-cccc-cccc-cccc"))
+			Expect(advisories[0]).To(HaveKeyWithValue("published_at", BeNil()))
+
+			_, advisories = get("/repos/example-org/example-repo/security-advisories?state=published")
+			Expect(advisories).To(HaveLen(2))
+			Expect(advisories[1]).To(HaveKeyWithValue("published_at", "2001-01-09T20:20:20Z"))
+		})
+	})
+
+	context("pagination", func() {
+		it("pages with Link headers", func() {
+			resp, repos := get("/orgs/example-org/repos?per_page=2")
+			Expect(repos).To(HaveLen(2))
+			Expect(resp.Header.Get("Link")).To(Equal(
+				`<` + server.URL + `/orgs/example-org/repos?page=2&per_page=2>; rel="next", ` +
+					`<` + server.URL + `/orgs/example-org/repos?page=2&per_page=2>; rel="last"`))
+
+			resp, repos = get("/orgs/example-org/repos?page=2&per_page=2")
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0]).To(HaveKeyWithValue("name", "example-fork"))
+			Expect(resp.Header.Get("Link")).To(Equal(
+				`<` + server.URL + `/orgs/example-org/repos?page=1&per_page=2>; rel="prev", ` +
+					`<` + server.URL + `/orgs/example-org/repos?page=1&per_page=2>; rel="first"`))
+		})
+
+		it("leaves out the Link header when everything fits on one page", func() {
+			resp, _ := get("/orgs/example-org/repos")
+			Expect(resp.Header).NotTo(HaveKey("Link"))
+		})
+	})
+
+	context("conditional requests", func() {
+		it("answers a matching If-None-Match with 304 without using up the rate limit", func() {
+			resp, _ := get("/orgs/example-org/repos")
+			etag := resp.Header.Get("ETag")
+			Expect(etag).NotTo(BeEmpty())
+			Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("4999"))
+
+			resp, _ = get("/orgs/example-org/repos", "If-None-Match", etag)
+			Expect(resp.StatusCode).To(Equal(http.StatusNotModified))
+			Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("4999"))
+		})
+	})
+
+	context("rate limiting", func() {
+		it.Before(func() {
+			server.Handler.RateLimit = 1
+		})
+
+		it("refuses requests once the limit is used up", func() {
+			resp, _ := get("/orgs/example-org/repos")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+			Expect(resp.Header.Get("X-RateLimit-Limit")).To(Equal("1"))
+			Expect(resp.Header.Get("X-RateLimit-Remaining")).To(Equal("0"))
+			Expect(resp.Header.Get("X-RateLimit-Reset")).NotTo(BeEmpty())
+
+			resp, _ = get("/orgs/example-org/repos")
+			Expect(resp.StatusCode).To(Equal(http.StatusForbidden))
+		})
+	})
+
+	context("authentication", func() {
+		it.Before(func() {
+			server.Handler.Token = "some-token"
+		})
+
+		it("refuses requests without the token", func() {
+			resp, _ := get("/orgs/example-org/repos")
+			Expect(resp.StatusCode).To(Equal(http.StatusUnauthorized))
+
+			resp, _ = get("/orgs/example-org/repos", "Authorization", "token some-token")
+			Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	it("records the requests it served", func() {
+		get("/orgs/example-org/repos?per_page=2")
+		Expect(server.Handler.Requests()).To(Equal([]string{"GET /orgs/example-org/repos?per_page=2"}))
+	})
+}
@@ -16,6 +16,20 @@ type Client struct {
 		}
 		Stub func(string, ...string) ([]byte, error)
 	}
+	GetNextCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Path   string
+			Params []string
+		}
+		Returns struct {
+			ByteSlice   []byte
+			StringSlice []string
+			Error       error
+		}
+		Stub func(string, ...string) ([]byte, []string, error)
+	}
 }
 
 func (f *Client) Get(param1 string, param2 ...string) ([]byte, error) {
@@ -29,3 +43,14 @@ func (f *Client) Get(param1 string, param2 ...string) ([]byte, error) {
 	}
 	return f.GetCall.Returns.ByteSlice, f.GetCall.Returns.Error
 }
+func (f *Client) GetNext(param1 string, param2 ...string) ([]byte, []string, error) {
+	f.GetNextCall.Lock()
+	defer f.GetNextCall.Unlock()
+	f.GetNextCall.CallCount++
+	f.GetNextCall.Receives.Path = param1
+	f.GetNextCall.Receives.Params = param2
+	if f.GetNextCall.Stub != nil {
+		return f.GetNextCall.Stub(param1, param2...)
+	}
+	return f.GetNextCall.Returns.ByteSlice, f.GetNextCall.Returns.StringSlice, f.GetNextCall.Returns.Error
+}
@@ -14,7 +14,7 @@ type CommentGetter struct {
 		}
 		Stub func() string
 	}
-	GetFirstReplyCall struct {
+	GetFirstResponseCall struct {
 		sync.Mutex
 		CallCount int
 		Receives  struct {
@@ -22,10 +22,26 @@ type CommentGetter struct {
 			IgnoredUsers []string
 		}
 		Returns struct {
-			Comment internal.Comment
-			Error   error
+			Response *internal.Response
+			Error    error
 		}
-		Stub func(internal.Client, ...string) (internal.Comment, error)
+		Stub func(internal.Client, ...string) (*internal.Response, error)
+	}
+	GetLabelsCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			StringSlice []string
+		}
+		Stub func() []string
+	}
+	GetNumberCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			Int int
+		}
+		Stub func() int
 	}
 	GetUserLoginCall struct {
 		sync.Mutex
@@ -35,6 +51,30 @@ type CommentGetter struct {
 		}
 		Stub func() string
 	}
+	GetUserTypeCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			String string
+		}
+		Stub func() string
+	}
+	IsMergeRequestCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			Bool bool
+		}
+		Stub func() bool
+	}
+	IsPullRequestCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			Bool bool
+		}
+		Stub func() bool
+	}
 }
 
 func (f *CommentGetter) GetCreatedAt() string {
@@ -46,16 +86,34 @@ func (f *CommentGetter) GetCreatedAt() string {
 	}
 	return f.GetCreatedAtCall.Returns.String
 }
-func (f *CommentGetter) GetFirstReply(param1 internal.Client, param2 ...string) (internal.Comment, error) {
-	f.GetFirstReplyCall.Lock()
-	defer f.GetFirstReplyCall.Unlock()
-	f.GetFirstReplyCall.CallCount++
-	f.GetFirstReplyCall.Receives.Client = param1
-	f.GetFirstReplyCall.Receives.IgnoredUsers = param2
-	if f.GetFirstReplyCall.Stub != nil {
-		return f.GetFirstReplyCall.Stub(param1, param2...)
-	}
-	return f.GetFirstReplyCall.Returns.Comment, f.GetFirstReplyCall.Returns.Error
+func (f *CommentGetter) GetFirstResponse(param1 internal.Client, param2 ...string) (*internal.Response, error) {
+	f.GetFirstResponseCall.Lock()
+	defer f.GetFirstResponseCall.Unlock()
+	f.GetFirstResponseCall.CallCount++
+	f.GetFirstResponseCall.Receives.Client = param1
+	f.GetFirstResponseCall.Receives.IgnoredUsers = param2
+	if f.GetFirstResponseCall.Stub != nil {
+		return f.GetFirstResponseCall.Stub(param1, param2...)
+	}
+	return f.GetFirstResponseCall.Returns.Response, f.GetFirstResponseCall.Returns.Error
+}
+func (f *CommentGetter) GetLabels() []string {
+	f.GetLabelsCall.Lock()
+	defer f.GetLabelsCall.Unlock()
+	f.GetLabelsCall.CallCount++
+	if f.GetLabelsCall.Stub != nil {
+		return f.GetLabelsCall.Stub()
+	}
+	return f.GetLabelsCall.Returns.StringSlice
+}
+func (f *CommentGetter) GetNumber() int {
+	f.GetNumberCall.Lock()
+	defer f.GetNumberCall.Unlock()
+	f.GetNumberCall.CallCount++
+	if f.GetNumberCall.Stub != nil {
+		return f.GetNumberCall.Stub()
+	}
+	return f.GetNumberCall.Returns.Int
 }
 func (f *CommentGetter) GetUserLogin() string {
 	f.GetUserLoginCall.Lock()
@@ -66,3 +124,30 @@ func (f *CommentGetter) GetUserLogin() string {
 	}
 	return f.GetUserLoginCall.Returns.String
 }
+func (f *CommentGetter) GetUserType() string {
+	f.GetUserTypeCall.Lock()
+	defer f.GetUserTypeCall.Unlock()
+	f.GetUserTypeCall.CallCount++
+	if f.GetUserTypeCall.Stub != nil {
+		return f.GetUserTypeCall.Stub()
+	}
+	return f.GetUserTypeCall.Returns.String
+}
+func (f *CommentGetter) IsMergeRequest() bool {
+	f.IsMergeRequestCall.Lock()
+	defer f.IsMergeRequestCall.Unlock()
+	f.IsMergeRequestCall.CallCount++
+	if f.IsMergeRequestCall.Stub != nil {
+		return f.IsMergeRequestCall.Stub()
+	}
+	return f.IsMergeRequestCall.Returns.Bool
+}
+func (f *CommentGetter) IsPullRequest() bool {
+	f.IsPullRequestCall.Lock()
+	defer f.IsPullRequestCall.Unlock()
+	f.IsPullRequestCall.CallCount++
+	if f.IsPullRequestCall.Stub != nil {
+		return f.IsPullRequestCall.Stub()
+	}
+	return f.IsPullRequestCall.Returns.Bool
+}
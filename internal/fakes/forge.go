@@ -0,0 +1,76 @@
+package fakes
+
+import (
+	"gloss/internal"
+	"sync"
+	"time"
+)
+
+type Forge struct {
+	ClientCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			Client internal.Client
+		}
+		Stub func() internal.Client
+	}
+	GetRecentIssuesCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Repo   internal.Repository
+			Window time.Duration
+		}
+		Returns struct {
+			IssueSlice []internal.Issue
+			Error      error
+		}
+		Stub func(internal.Repository, time.Duration) ([]internal.Issue, error)
+	}
+	GetReposCall struct {
+		sync.Mutex
+		CallCount int
+		Receives  struct {
+			Org    string
+			Filter internal.RepoFilter
+		}
+		Returns struct {
+			RepositorySlice []internal.Repository
+			Error           error
+		}
+		Stub func(string, internal.RepoFilter) ([]internal.Repository, error)
+	}
+}
+
+func (f *Forge) Client() internal.Client {
+	f.ClientCall.Lock()
+	defer f.ClientCall.Unlock()
+	f.ClientCall.CallCount++
+	if f.ClientCall.Stub != nil {
+		return f.ClientCall.Stub()
+	}
+	return f.ClientCall.Returns.Client
+}
+func (f *Forge) GetRecentIssues(param1 internal.Repository, param2 time.Duration) ([]internal.Issue, error) {
+	f.GetRecentIssuesCall.Lock()
+	defer f.GetRecentIssuesCall.Unlock()
+	f.GetRecentIssuesCall.CallCount++
+	f.GetRecentIssuesCall.Receives.Repo = param1
+	f.GetRecentIssuesCall.Receives.Window = param2
+	if f.GetRecentIssuesCall.Stub != nil {
+		return f.GetRecentIssuesCall.Stub(param1, param2)
+	}
+	return f.GetRecentIssuesCall.Returns.IssueSlice, f.GetRecentIssuesCall.Returns.Error
+}
+func (f *Forge) GetRepos(param1 string, param2 internal.RepoFilter) ([]internal.Repository, error) {
+	f.GetReposCall.Lock()
+	defer f.GetReposCall.Unlock()
+	f.GetReposCall.CallCount++
+	f.GetReposCall.Receives.Org = param1
+	f.GetReposCall.Receives.Filter = param2
+	if f.GetReposCall.Stub != nil {
+		return f.GetReposCall.Stub(param1, param2)
+	}
+	return f.GetReposCall.Returns.RepositorySlice, f.GetReposCall.Returns.Error
+}
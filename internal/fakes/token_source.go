@@ -0,0 +1,25 @@
+package fakes
+
+import "sync"
+
+type TokenSource struct {
+	TokenCall struct {
+		sync.Mutex
+		CallCount int
+		Returns   struct {
+			String string
+			Error  error
+		}
+		Stub func() (string, error)
+	}
+}
+
+func (f *TokenSource) Token() (string, error) {
+	f.TokenCall.Lock()
+	defer f.TokenCall.Unlock()
+	f.TokenCall.CallCount++
+	if f.TokenCall.Stub != nil {
+		return f.TokenCall.Stub()
+	}
+	return f.TokenCall.Returns.String, f.TokenCall.Returns.Error
+}
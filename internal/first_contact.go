@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ContactStatus says how far an issue got towards first contact.
+type ContactStatus string
+
+const (
+	// StatusResponded issues have a first response; Elapsed runs until it.
+	StatusResponded ContactStatus = "responded"
+	// StatusAwaiting issues have no response yet; Elapsed runs until the
+	// measurement was taken.
+	StatusAwaiting ContactStatus = "awaiting"
+	// StatusFailed issues could not be measured; Error says why.
+	StatusFailed ContactStatus = "failed"
+)
+
+// ResponseKind is the kind of activity that counted as first contact.
+type ResponseKind string
+
+const (
+	ResponseComment ResponseKind = "comment"
+	ResponseReview  ResponseKind = "review"
+)
+
+// IssueRef identifies an issue or pull request.
+type IssueRef struct {
+	Repo         string `json:"repo"`
+	Number       int    `json:"number"`
+	PullRequest  bool   `json:"pull_request"`
+	MergeRequest bool   `json:"merge_request,omitempty"`
+}
+
+// String writes GitLab merge requests, which are numbered apart from issues,
+// with GitLab's ! instead of #.
+func (r IssueRef) String() string {
+	if r.MergeRequest {
+		return fmt.Sprintf("%s!%d", r.Repo, r.Number)
+	}
+	return fmt.Sprintf("%s#%d", r.Repo, r.Number)
+}
+
+// Response is the first activity on an issue by someone other than its
+// author, an ignored user or a bot.
+type Response struct {
+	At    time.Time    `json:"at"`
+	Actor string       `json:"actor"`
+	Kind  ResponseKind `json:"kind"`
+}
+
+// FirstContact is the measurement of one issue's time to first contact.
+// Response is only set for StatusResponded.
+type FirstContact struct {
+	Issue     IssueRef      `json:"issue"`
+	CreatedAt time.Time     `json:"created_at"`
+	Response  *Response     `json:"response,omitempty"`
+	Elapsed   time.Duration `json:"elapsed"`
+	Labels    []string      `json:"labels,omitempty"`
+	Status    ContactStatus `json:"status"`
+	Error     error         `json:"-"`
+}
+
+// Minutes is Elapsed in whole minutes, as reports show it.
+func (c FirstContact) Minutes() float64 {
+	return math.Round(c.Elapsed.Minutes())
+}
+
+// Responder is the login of whoever responded first, if anyone has.
+func (c FirstContact) Responder() string {
+	if c.Response == nil {
+		return ""
+	}
+	return c.Response.Actor
+}
+
+// ResponseKind is the kind of the first response, if anyone has responded.
+func (c FirstContact) ResponseKind() string {
+	if c.Response == nil {
+		return ""
+	}
+	return string(c.Response.Kind)
+}
@@ -0,0 +1,54 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+// contactAfter is a first contact by responder after the given minutes, or
+// one still awaiting a response when responder is empty.
+func contactAfter(minutes int, responder string) FirstContact {
+	contact := FirstContact{Elapsed: time.Duration(minutes) * time.Minute, Status: StatusAwaiting}
+	if responder != "" {
+		contact.Status = StatusResponded
+		contact.Response = &Response{Actor: responder, Kind: ResponseComment}
+	}
+	return contact
+}
+
+func testFirstContact(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Minutes", func() {
+		it("rounds the elapsed time to whole minutes", func() {
+			Expect(FirstContact{Elapsed: 90*time.Second + time.Millisecond}.Minutes()).To(Equal(2.0))
+			Expect(FirstContact{Elapsed: 89 * time.Second}.Minutes()).To(Equal(1.0))
+		})
+	})
+
+	context("Responder", func() {
+		it("is whoever responded first", func() {
+			Expect(contactAfter(10, "alice").Responder()).To(Equal("alice"))
+		})
+
+		it("is empty while awaiting a response", func() {
+			Expect(contactAfter(10, "").Responder()).To(BeEmpty())
+		})
+	})
+
+	context("IssueRef", func() {
+		it("reads like a GitHub issue reference", func() {
+			Expect(IssueRef{Repo: "example-org/example-repo", Number: 7}.String()).To(Equal("example-org/example-repo#7"))
+		})
+
+		it("writes GitLab merge requests with !", func() {
+			ref := IssueRef{Repo: "example-group/example-project", Number: 7, PullRequest: true, MergeRequest: true}
+			Expect(ref.String()).To(Equal("example-group/example-project!7"))
+		})
+	})
+}
@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+//go:generate faux --interface Forge --output fakes/forge.go
+
+// Forge is a code hosting service gloss measures: it lists an organization's
+// repositories and a repository's recently updated issues. Issues come back in
+// the same model regardless of forge, so the metrics do not depend on where
+// they were fetched from. Client is what the issues' GetFirstResponse uses to
+// request comments and reviews that were not fetched along with them.
+type Forge interface {
+	GetRepos(org string, filter RepoFilter) ([]Repository, error)
+	GetRecentIssues(repo Repository, window time.Duration) ([]Issue, error)
+	Client() Client
+}
+
+// GitHubForge fetches from GitHub's REST API, or fetches issues from its
+// GraphQL API when GraphQL is set. Discussions, which only the GraphQL API
+// covers, are fetched with Discussions.
+type GitHubForge struct {
+	client      Client
+	clock       Clock
+	GraphQL     *GraphQLClient
+	Discussions *GraphQLClient
+}
+
+func NewGitHubForge(client Client, clock Clock) *GitHubForge {
+	return &GitHubForge{client: client, clock: clock}
+}
+
+func (f *GitHubForge) GetRepos(org string, filter RepoFilter) ([]Repository, error) {
+	organization := Organization{Name: org}
+	return organization.GetRepos(f.client, f.clock, filter)
+}
+
+func (f *GitHubForge) GetRecentIssues(repo Repository, window time.Duration) ([]Issue, error) {
+	if f.GraphQL != nil {
+		return f.GraphQL.GetRecentIssues(repo, f.clock, window)
+	}
+	return repo.GetRecentIssues(f.client, f.clock, window)
+}
+
+// GetRecentDiscussions returns the repository's discussions updated within
+// the window.
+func (f *GitHubForge) GetRecentDiscussions(repo Repository, window time.Duration) ([]Discussion, error) {
+	if f.Discussions == nil {
+		return nil, fmt.Errorf("getting recent discussions: no GraphQL client")
+	}
+	return f.Discussions.GetRecentDiscussions(repo, f.clock, window)
+}
+
+func (f *GitHubForge) Client() Client {
+	return f.client
+}
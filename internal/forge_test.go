@@ -0,0 +1,84 @@
+package internal_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testForge(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var client *fakes.Client
+	var clock *fakes.Clock
+	var forge *GitHubForge
+
+	it.Before(func() {
+		client = &fakes.Client{}
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		forge = NewGitHubForge(client, clock)
+	})
+
+	context("GitHubForge", func() {
+		context("GetRepos", func() {
+			it.Before(func() {
+				client.GetCall.Returns.ByteSlice = []byte(`[{"full_name": "example-org/example-repo"}]`)
+			})
+
+			it("lists the organization's repositories", func() {
+				repos, err := forge.GetRepos("example-org", RepoFilter{})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.GetCall.Receives.Path).To(Equal("orgs/example-org/repos"))
+				Expect(repos).To(HaveLen(1))
+			})
+		})
+
+		context("GetRecentIssues", func() {
+			it.Before(func() {
+				client.GetCall.Returns.ByteSlice = []byte(`[{"number": 1, "created_at": "2001-01-01T20:20:20Z"}]`)
+			})
+
+			it("lists the repository's issues through the REST API", func() {
+				issues, err := forge.GetRecentIssues(Repository{Name: "example-org/example-repo"}, 24*time.Hour)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(client.GetCall.Receives.Path).To(Equal("/repos/example-org/example-repo/issues"))
+				Expect(client.GetCall.Receives.Params).To(ContainElement("since=2001-01-30T20:20:20Z"))
+				Expect(issues).To(HaveLen(1))
+			})
+
+			context("when GraphQL is set", func() {
+				var httpClient *fakes.HTTPClient
+
+				it.Before(func() {
+					httpClient = &fakes.HTTPClient{}
+					httpClient.DoCall.Returns.Response = &http.Response{
+						StatusCode: 200,
+						Body: ioutil.NopCloser(bytes.NewReader([]byte(`{"data": {"repository": {"issues": {
+							"pageInfo": {"hasNextPage": false},
+							"nodes": [{"number": 2, "createdAt": "2001-01-01T20:20:20Z"}]
+						}}}}`))),
+					}
+					graphql := NewGraphQLClient("https://api.github.com/graphql", httpClient, StaticToken(""))
+					forge.GraphQL = &graphql
+				})
+
+				it("lists the repository's issues through GraphQL", func() {
+					issues, err := forge.GetRecentIssues(Repository{Name: "example-org/example-repo"}, 24*time.Hour)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(client.GetCall.CallCount).To(Equal(0))
+					Expect(httpClient.DoCall.CallCount).To(Equal(1))
+					Expect(issues).To(HaveLen(1))
+					Expect(issues[0].Number).To(Equal(2))
+				})
+			})
+		})
+	})
+}
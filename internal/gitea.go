@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// GiteaForge fetches from the Gitea v1 API, which Forgejo serves too. Its
+// issues and comments are shaped like GitHub's, so they decode straight into
+// Issue and Comment, but its repositories differ and every list is paged by
+// page and limit rather than by Link headers.
+type GiteaForge struct {
+	client Client
+	clock  Clock
+	// PageSize is how many items are asked for per page. Gitea caps it at its
+	// MAX_RESPONSE_ITEMS setting, 50 by default.
+	PageSize int
+}
+
+func NewGiteaForge(client Client, clock Clock) *GiteaForge {
+	return &GiteaForge{client: client, clock: clock, PageSize: 50}
+}
+
+type giteaRepository struct {
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+	Owner    struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Archived  bool     `json:"archived"`
+	Fork      bool     `json:"fork"`
+	Template  bool     `json:"template"`
+	Private   bool     `json:"private"`
+	Internal  bool     `json:"internal"`
+	Topics    []string `json:"topics"`
+	Language  string   `json:"language"`
+	UpdatedAt string   `json:"updated_at"`
+}
+
+func (f *GiteaForge) GetRepos(org string, filter RepoFilter) ([]Repository, error) {
+	now := f.clock.Now().UTC()
+	repos := []Repository{}
+	for page := 1; ; page++ {
+		body, err := f.client.Get(fmt.Sprintf("/orgs/%s/repos", org),
+			fmt.Sprintf("limit=%d", f.PageSize),
+			fmt.Sprintf("page=%d", page))
+		if err != nil {
+			return nil, fmt.Errorf("failed getting org repos: %s", err)
+		}
+
+		items := []giteaRepository{}
+		err = json.Unmarshal(body, &items)
+		if err != nil {
+			return nil, fmt.Errorf("could not unmarshal response: %s\n  : %s", string(body), err)
+		}
+
+		for _, item := range items {
+			repo := Repository{
+				Name:       item.FullName,
+				URL:        item.HTMLURL,
+				Archived:   item.Archived,
+				Fork:       item.Fork,
+				IsTemplate: item.Template,
+				Private:    item.Private || item.Internal,
+				Topics:     item.Topics,
+				Language:   item.Language,
+				PushedAt:   item.UpdatedAt,
+			}
+			repo.Owner.Login = item.Owner.Login
+
+			if filter.Matches(repo, now) {
+				repos = append(repos, repo)
+			}
+		}
+
+		if len(items) < f.PageSize {
+			return repos, nil
+		}
+	}
+}
+
+// GetRecentIssues returns the repository's open issues and pull requests
+// updated within the window. Gitea issues have no comments URL, so it is
+// filled in for GetFirstReply to use.
+func (f *GiteaForge) GetRecentIssues(repo Repository, window time.Duration) ([]Issue, error) {
+	since := fmt.Sprintf("since=%s", f.clock.Now().UTC().Add(-window).Format(time.RFC3339))
+
+	issues := []Issue{}
+	for page := 1; ; page++ {
+		body, err := f.client.Get(fmt.Sprintf("/repos/%s/issues", repo.Name),
+			fmt.Sprintf("limit=%d", f.PageSize),
+			fmt.Sprintf("page=%d", page),
+			since)
+		if err != nil {
+			return nil, fmt.Errorf("getting recent issues: %s", err)
+		}
+
+		items := []Issue{}
+		err = json.Unmarshal(body, &items)
+		if err != nil {
+			return nil, fmt.Errorf("getting recent issues: could not unmarshal JSON '%s' : %s", string(body), err)
+		}
+
+		for _, issue := range items {
+			issue.CommentsURL = fmt.Sprintf("/repos/%s/issues/%d/comments", repo.Name, issue.Number)
+			issues = append(issues, issue)
+		}
+
+		if len(items) < f.PageSize {
+			return issues, nil
+		}
+	}
+}
+
+func (f *GiteaForge) Client() Client {
+	return f.client
+}
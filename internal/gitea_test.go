@@ -0,0 +1,170 @@
+package internal_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGitea(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var server *httptest.Server
+	var clock *fakes.Clock
+	var forge *GiteaForge
+	var queries []string
+
+	it.Before(func() {
+		queries = nil
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+
+		// The stand-in serves a Gitea org with two repositories and one of
+		// them with three issues, paged two at a time. It only answers
+		// requests carrying the token.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Header.Get("Authorization") != "token some-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message": "token is required"}`)
+				return
+			}
+			queries = append(queries, req.URL.RawQuery)
+			page := req.URL.Query().Get("page")
+
+			switch {
+			case req.URL.Path == "/api/v1/orgs/example-org/repos" && page == "1":
+				fmt.Fprint(w, `[
+{
+	"full_name": "example-org/example-repo",
+	"html_url": "https://gitea.example.com/example-org/example-repo",
+	"owner": {"login": "example-org"},
+	"template": true,
+	"internal": true,
+	"topics": ["go"],
+	"language": "Go",
+	"updated_at": "2001-01-30T00:00:00Z"
+},
+{
+	"full_name": "example-org/archived-repo",
+	"archived": true
+}]`)
+			case req.URL.Path == "/api/v1/orgs/example-org/repos":
+				fmt.Fprint(w, `[]`)
+			case req.URL.Path == "/api/v1/repos/example-org/example-repo/issues" && page == "1":
+				fmt.Fprint(w, `[
+{"number": 3, "created_at": "2001-01-03T20:20:20Z", "comments": 0, "user": {"login": "contributor"}, "pull_request": {"merged": false}},
+{"number": 2, "created_at": "2001-01-02T20:20:20Z", "comments": 2, "user": {"login": "originalPoster"}, "labels": [{"name": "bug"}], "pull_request": null}
+]`)
+			case req.URL.Path == "/api/v1/repos/example-org/example-repo/issues" && page == "2":
+				fmt.Fprint(w, `[
+{"number": 1, "created_at": "2001-01-01T20:20:20Z", "comments": 0, "user": {"login": "originalPoster"}}
+]`)
+			case req.URL.Path == "/api/v1/repos/example-org/example-repo/issues/2/comments":
+				fmt.Fprint(w, `[
+{"created_at": "2001-01-02T20:30:20Z", "user": {"login": "originalPoster"}},
+{"created_at": "2001-01-02T21:20:20Z", "user": {"login": "maintainer"}}
+]`)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"message": "not found"}`)
+			}
+		}))
+
+		client, err := NewAPIClient(server.URL+"/api/v1", http.DefaultClient, StaticToken("some-token"))
+		Expect(err).NotTo(HaveOccurred())
+		forge = NewGiteaForge(&client, clock)
+		forge.PageSize = 2
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("GetRepos", func() {
+		it("returns the org's repositories that pass the filter", func() {
+			repos, err := forge.GetRepos("example-org", RepoFilter{Templates: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries).To(Equal([]string{"limit=2&page=1", "limit=2&page=2"}))
+
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].Name).To(Equal("example-org/example-repo"))
+			Expect(repos[0].URL).To(Equal("https://gitea.example.com/example-org/example-repo"))
+			Expect(repos[0].Owner.Login).To(Equal("example-org"))
+			Expect(repos[0].IsTemplate).To(BeTrue())
+			Expect(repos[0].Private).To(BeTrue())
+			Expect(repos[0].Topics).To(Equal([]string{"go"}))
+			Expect(repos[0].Language).To(Equal("Go"))
+			Expect(repos[0].PushedAt).To(Equal("2001-01-30T00:00:00Z"))
+		})
+	})
+
+	context("GetRecentIssues", func() {
+		var repo Repository
+
+		it.Before(func() {
+			repo = Repository{Name: "example-org/example-repo"}
+		})
+
+		it("returns the issues updated within the window from every page", func() {
+			issues, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(queries).To(Equal([]string{
+				"limit=2&page=1&since=2001-01-01T20:20:20Z",
+				"limit=2&page=2&since=2001-01-01T20:20:20Z",
+			}))
+
+			Expect(issues).To(HaveLen(3))
+			Expect(issues[0].Number).To(Equal(3))
+			Expect(issues[0].PullRequest).NotTo(BeNil())
+			Expect(issues[1].PullRequest).To(BeNil())
+			Expect(issues[1].GetLabels()).To(Equal([]string{"bug"}))
+			Expect(issues[2].Number).To(Equal(1))
+		})
+
+		it("finds first replies through the comments endpoint", func() {
+			issues, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := issues[1].GetFirstReply(forge.Client())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.User.Login).To(Equal("maintainer"))
+			Expect(reply.CreatedAt).To(Equal("2001-01-02T21:20:20Z"))
+		})
+
+		it("feeds the first contact times", func() {
+			issues, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			getters := []CommentGetter{}
+			for i := range issues {
+				getters = append(getters, &issues[i])
+			}
+			output := make(chan FirstContact)
+			go repo.GetFirstContactTimes(forge.Client(), getters, clock, ContactOptions{}, output)
+
+			times := []float64{}
+			for result := range output {
+				Expect(result.Error).NotTo(HaveOccurred())
+				times = append(times, result.Minutes())
+			}
+			Expect(times).To(HaveLen(3))
+			Expect(times[1]).To(Equal(60.0))
+		})
+
+		context("failure cases", func() {
+			context("when the repository does not exist", func() {
+				it("returns the error", func() {
+					_, err := forge.GetRecentIssues(Repository{Name: "example-org/missing-repo"}, 30*24*time.Hour)
+					Expect(err).To(MatchError(ContainSubstring("getting recent issues:")))
+				})
+			})
+		})
+	})
+}
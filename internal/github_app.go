@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// GitHubAppToken provides installation access tokens for a GitHub App. It
+// signs a short-lived JWT with the App's private key, exchanges it for an
+// installation token, and reuses that token until shortly before it expires.
+type GitHubAppToken struct {
+	AppID          int64
+	InstallationID int64
+	PrivateKey     *rsa.PrivateKey
+	ServerURL      string
+
+	client HTTPClient
+	clock  Clock
+
+	mutex     sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// tokenRefreshMargin is how long before expiry a cached installation token is
+// replaced, so that a token never expires in the middle of a run of requests.
+const tokenRefreshMargin = time.Minute
+
+func NewGitHubAppToken(serverURL string, appID, installationID int64, privateKey *rsa.PrivateKey, httpClient HTTPClient, clock Clock) *GitHubAppToken {
+	return &GitHubAppToken{
+		AppID:          appID,
+		InstallationID: installationID,
+		PrivateKey:     privateKey,
+		ServerURL:      serverURL,
+		client:         httpClient,
+		clock:          clock,
+	}
+}
+
+// ParsePrivateKey parses a PEM encoded RSA private key, in either the PKCS #1
+// form GitHub hands out or PKCS #8.
+func ParsePrivateKey(contents []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(contents)
+	if block == nil {
+		return nil, fmt.Errorf("parsing private key: no PEM data found")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing private key: %s", err)
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("parsing private key: not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+func (a *GitHubAppToken) Token() (string, error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	now := a.clock.Now()
+	if a.token != "" && now.Add(tokenRefreshMargin).Before(a.expiresAt) {
+		return a.token, nil
+	}
+
+	jwt, err := a.signJWT(now)
+	if err != nil {
+		return "", fmt.Errorf("signing app JWT: %s", err)
+	}
+
+	server, err := ParseServerURL(a.ServerURL)
+	if err != nil {
+		return "", err
+	}
+	uri := JoinPath(server, fmt.Sprintf("/app/installations/%d/access_tokens", a.InstallationID))
+
+	request, _ := http.NewRequest("POST", uri.String(), nil)
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", jwt))
+	request.Header.Add("Accept", "application/vnd.github+json")
+
+	response, err := a.client.Do(request)
+	if err != nil {
+		return "", fmt.Errorf("requesting installation token: %s", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return "", fmt.Errorf("requesting installation token: %s", err)
+	}
+	if response.StatusCode != http.StatusCreated {
+		return "", fmt.Errorf("requesting installation token: unexpected status %d: %s", response.StatusCode, string(body))
+	}
+
+	var installationToken struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.Unmarshal(body, &installationToken)
+	if err != nil {
+		return "", fmt.Errorf("requesting installation token: could not unmarshal JSON '%s' : %s", string(body), err)
+	}
+
+	a.token = installationToken.Token
+	a.expiresAt = installationToken.ExpiresAt
+	return a.token, nil
+}
+
+// signJWT builds the RS256 JWT that authenticates as the App itself. GitHub
+// accepts JWTs valid for at most ten minutes; the issue time is backdated to
+// allow for clock drift.
+func (a *GitHubAppToken) signJWT(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]int64{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": a.AppID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString(header), base64.RawURLEncoding.EncodeToString(claims))
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.PrivateKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%s", unsigned, base64.RawURLEncoding.EncodeToString(signature)), nil
+}
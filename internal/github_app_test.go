@@ -0,0 +1,149 @@
+package internal_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGitHubApp(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var key *rsa.PrivateKey
+	var server *httptest.Server
+	var clock *fakes.Clock
+	var exchanges int
+	var claims map[string]int64
+	var source *GitHubAppToken
+
+	it.Before(func() {
+		var err error
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+		Expect(err).NotTo(HaveOccurred())
+
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 1, 12, 0, 0, 0, time.UTC)
+		exchanges = 0
+
+		// The fake server only hands out a token for a JWT signed by the
+		// App's key, and each token lasts an hour from the fake clock's time.
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.Method != "POST" || req.URL.Path != "/app/installations/456/access_tokens" {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			jwt := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+			parts := strings.Split(jwt, ".")
+			if len(parts) != 3 || verifyJWT(&key.PublicKey, parts) != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				fmt.Fprint(w, `{"message": "bad JWT"}`)
+				return
+			}
+			payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			Expect(json.Unmarshal(payload, &claims)).To(Succeed())
+
+			exchanges++
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"token": "installation-token-%d", "expires_at": "%s"}`,
+				exchanges, clock.Now().Add(time.Hour).Format(time.RFC3339))
+		}))
+
+		source = NewGitHubAppToken(server.URL, 123, 456, key, http.DefaultClient, clock)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("Token", func() {
+		it("exchanges a JWT signed by the app for an installation token", func() {
+			token, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("installation-token-1"))
+
+			Expect(claims["iss"]).To(Equal(int64(123)))
+			Expect(claims["iat"]).To(Equal(clock.Now().Add(-time.Minute).Unix()))
+			Expect(claims["exp"]).To(Equal(clock.Now().Add(9 * time.Minute).Unix()))
+		})
+
+		it("reuses the installation token until it is about to expire", func() {
+			_, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+
+			clock.NowCall.Returns.Time = clock.NowCall.Returns.Time.Add(58 * time.Minute)
+			token, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("installation-token-1"))
+
+			clock.NowCall.Returns.Time = clock.NowCall.Returns.Time.Add(time.Minute)
+			token, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("installation-token-2"))
+		})
+
+		context("failure cases", func() {
+			context("when the server rejects the JWT", func() {
+				it.Before(func() {
+					otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+					Expect(err).NotTo(HaveOccurred())
+					source = NewGitHubAppToken(server.URL, 123, 456, otherKey, http.DefaultClient, clock)
+				})
+
+				it("returns the error", func() {
+					_, err := source.Token()
+					Expect(err).To(MatchError(`requesting installation token: unexpected status 401: {"message": "bad JWT"}`))
+				})
+			})
+		})
+	})
+
+	context("ParsePrivateKey", func() {
+		it("parses PKCS #1 keys", func() {
+			parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Equal(key)).To(BeTrue())
+		})
+
+		it("parses PKCS #8 keys", func() {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			Expect(err).NotTo(HaveOccurred())
+
+			parsed, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Equal(key)).To(BeTrue())
+		})
+
+		context("failure cases", func() {
+			it("returns an error when there is no PEM data", func() {
+				_, err := ParsePrivateKey([]byte("not a key"))
+				Expect(err).To(MatchError("parsing private key: no PEM data found"))
+			})
+		})
+	})
+}
+
+func verifyJWT(key *rsa.PublicKey, parts []string) error {
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+}
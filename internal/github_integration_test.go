@@ -0,0 +1,89 @@
+package internal_test
+
+import (
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGitHubIntegration(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var server *fakegithub.Server
+	var clock *fakes.Clock
+	var forge *GitHubForge
+
+	it.Before(func() {
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+		server.Handler.Token = "some-token"
+
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+
+		client, err := NewAPIClient(server.URL, http.DefaultClient, StaticToken("some-token"))
+		Expect(err).NotTo(HaveOccurred())
+		forge = NewGitHubForge(&client, clock)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	it("measures first contact times against the fake GitHub API", func() {
+		repos, err := forge.GetRepos("example-org", RepoFilter{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(repos).To(HaveLen(1))
+		Expect(repos[0].Name).To(Equal("example-org/example-repo"))
+
+		issues, err := forge.GetRecentIssues(repos[0], 30*24*time.Hour)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(HaveLen(2))
+
+		getters := []CommentGetter{}
+		for i := range issues {
+			getters = append(getters, &issues[i])
+		}
+		output := make(chan FirstContact)
+		go repos[0].GetFirstContactTimes(forge.Client(), getters, clock, ContactOptions{}, output)
+
+		results := []FirstContact{}
+		for result := range output {
+			Expect(result.Error).NotTo(HaveOccurred())
+			results = append(results, result)
+		}
+		Expect(results).To(Equal([]FirstContact{
+			{
+				Issue:     IssueRef{Repo: "example-org/example-repo", Number: 2, PullRequest: true},
+				CreatedAt: time.Date(2001, time.January, 3, 20, 20, 20, 0, time.UTC),
+				Response:  &Response{At: time.Date(2001, time.January, 3, 20, 50, 20, 0, time.UTC), Actor: "maintainer", Kind: ResponseComment},
+				Elapsed:   30 * time.Minute,
+				Status:    StatusResponded,
+			},
+			{
+				Issue:     IssueRef{Repo: "example-org/example-repo", Number: 1},
+				CreatedAt: time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC),
+				Response:  &Response{At: time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC), Actor: "maintainer", Kind: ResponseComment},
+				Elapsed:   time.Hour,
+				Labels:    []string{"bug"},
+				Status:    StatusResponded,
+			},
+		}))
+
+		Expect(server.Handler.Requests()).To(Equal([]string{
+			"GET /orgs/example-org/repos?per_page=100&page=1",
+			"GET /repos/example-org/example-repo/issues?per_page=100&since=2001-01-01T20:20:20Z&page=1",
+			"GET /repos/example-org/example-repo/issues/2/comments?per_page=100&page=1",
+			"GET /repos/example-org/example-repo/pulls/2/reviews?per_page=100&page=1",
+			"GET /repos/example-org/example-repo/issues/1/comments?per_page=100&page=1",
+		}))
+	})
+}
@@ -0,0 +1,212 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+// GitLabForge fetches from the GitLab v4 API. Groups map to organizations and
+// projects to repositories; issues and merge requests are both reported as
+// issues, with each one's notes attached as its comments. System notes, such
+// as "changed the description", are left out. GitLab has no template
+// projects, so none are treated as templates.
+type GitLabForge struct {
+	client Client
+	clock  Clock
+}
+
+func NewGitLabForge(client Client, clock Clock) *GitLabForge {
+	return &GitLabForge{client: client, clock: clock}
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+	Bot      bool   `json:"bot"`
+}
+
+type gitlabProject struct {
+	PathWithNamespace string          `json:"path_with_namespace"`
+	WebURL            string          `json:"web_url"`
+	Archived          bool            `json:"archived"`
+	Visibility        string          `json:"visibility"`
+	Topics            []string        `json:"topics"`
+	LastActivityAt    string          `json:"last_activity_at"`
+	ForkedFromProject json.RawMessage `json:"forked_from_project"`
+	Namespace         struct {
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+}
+
+type gitlabIssue struct {
+	IID            int        `json:"iid"`
+	CreatedAt      string     `json:"created_at"`
+	UserNotesCount int        `json:"user_notes_count"`
+	Author         gitlabUser `json:"author"`
+	Labels         []string   `json:"labels"`
+	WebURL         string     `json:"web_url"`
+}
+
+type gitlabNote struct {
+	System    bool       `json:"system"`
+	CreatedAt string     `json:"created_at"`
+	Author    gitlabUser `json:"author"`
+}
+
+// gitlabBotUsername matches the users GitLab creates for project and group
+// access tokens, which it does not flag as bots in note authors.
+var gitlabBotUsername = regexp.MustCompile(`^(project|group)_\d+_bot`)
+
+func (f *GitLabForge) GetRepos(group string, filter RepoFilter) ([]Repository, error) {
+	projects := []gitlabProject{}
+	err := getAllPages(f.client, fmt.Sprintf("/groups/%s/projects", url.PathEscape(group)), func(body []byte) (int, error) {
+		page := []gitlabProject{}
+		err := json.Unmarshal(body, &page)
+		projects = append(projects, page...)
+		return len(page), err
+	}, "include_subgroups=true")
+	if err != nil {
+		return nil, fmt.Errorf("failed getting group projects: %s", err)
+	}
+
+	now := f.clock.Now().UTC()
+	byLanguage := len(filter.Languages) > 0 || len(filter.ExcludeLanguages) > 0
+	withoutLanguages := filter
+	withoutLanguages.Languages, withoutLanguages.ExcludeLanguages = nil, nil
+	repos := []Repository{}
+	for _, project := range projects {
+		repo := Repository{
+			Name:     project.PathWithNamespace,
+			URL:      project.WebURL,
+			Archived: project.Archived,
+			Fork:     len(project.ForkedFromProject) > 0 && string(project.ForkedFromProject) != "null",
+			Private:  project.Visibility != "public",
+			Topics:   project.Topics,
+			PushedAt: project.LastActivityAt,
+		}
+		repo.Owner.Login = project.Namespace.FullPath
+		if byLanguage && withoutLanguages.Matches(repo, now) {
+			repo.Language, err = f.getLanguage(repo.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if filter.Matches(repo, now) {
+			repos = append(repos, repo)
+		}
+	}
+	return repos, nil
+}
+
+// getLanguage returns the project's main language, the one most of its code
+// is in. The project list leaves languages out, so they are only fetched
+// when a filter needs them.
+func (f *GitLabForge) getLanguage(project string) (string, error) {
+	body, err := f.client.Get(fmt.Sprintf("/projects/%s/languages", url.PathEscape(project)))
+	if err != nil {
+		return "", fmt.Errorf("getting languages of %s: %s", project, err)
+	}
+	languages := map[string]float64{}
+	err = json.Unmarshal(body, &languages)
+	if err != nil {
+		return "", fmt.Errorf("getting languages of %s: could not unmarshal JSON '%s' : %s", project, string(body), err)
+	}
+
+	var language string
+	for name, share := range languages {
+		if share > languages[language] || (share == languages[language] && name < language) {
+			language = name
+		}
+	}
+	return language, nil
+}
+
+// GetRecentIssues returns the project's open issues and merge requests
+// updated within the window, like Repository.GetRecentIssues. Merge requests
+// are numbered apart from issues, so their refs are marked as merge requests.
+func (f *GitLabForge) GetRecentIssues(repo Repository, window time.Duration) ([]Issue, error) {
+	since := fmt.Sprintf("updated_after=%s", f.clock.Now().UTC().Add(-window).Format(time.RFC3339))
+	project := url.PathEscape(repo.Name)
+
+	var issues []Issue
+	for _, kind := range []string{"issues", "merge_requests"} {
+		items := []gitlabIssue{}
+		err := getAllPages(f.client, fmt.Sprintf("/projects/%s/%s", project, kind), func(body []byte) (int, error) {
+			page := []gitlabIssue{}
+			err := json.Unmarshal(body, &page)
+			items = append(items, page...)
+			return len(page), err
+		}, "scope=all", "state=opened", since)
+		if err != nil {
+			return nil, fmt.Errorf("getting recent %s: %s", kind, err)
+		}
+
+		for _, item := range items {
+			issue, err := f.toIssue(project, kind, item)
+			if err != nil {
+				return nil, err
+			}
+			if kind == "merge_requests" {
+				issue.PullRequest = &PullRequestLinks{URL: item.WebURL, MergeRequest: true}
+			}
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+func (f *GitLabForge) Client() Client {
+	return f.client
+}
+
+// toIssue converts a GitLab issue or merge request, fetching its notes so
+// that GetFirstReply never has to.
+func (f *GitLabForge) toIssue(project, kind string, item gitlabIssue) (Issue, error) {
+	issue := Issue{
+		Number:      item.IID,
+		CreatedAt:   item.CreatedAt,
+		CommentsURL: fmt.Sprintf("/projects/%s/%s/%d/notes", project, kind, item.IID),
+		Comments:    []Comment{},
+	}
+	issue.User.Login, issue.User.Type = item.Author.user()
+	for _, label := range item.Labels {
+		issue.Labels = append(issue.Labels, Label{Name: label})
+	}
+
+	if item.UserNotesCount == 0 {
+		return issue, nil
+	}
+
+	notes := []gitlabNote{}
+	err := getAllPages(f.client, issue.CommentsURL, func(body []byte) (int, error) {
+		page := []gitlabNote{}
+		err := json.Unmarshal(body, &page)
+		notes = append(notes, page...)
+		return len(page), err
+	}, "sort=asc", "order_by=created_at")
+	if err != nil {
+		return Issue{}, fmt.Errorf("getting notes: %s", err)
+	}
+
+	for _, note := range notes {
+		if note.System {
+			continue
+		}
+		comment := Comment{CreatedAt: note.CreatedAt}
+		comment.User.Login, comment.User.Type = note.Author.user()
+		issue.Comments = append(issue.Comments, comment)
+	}
+	issue.NumComments = len(issue.Comments)
+	return issue, nil
+}
+
+// user returns the username and GitHub-style user type of a GitLab user.
+func (u gitlabUser) user() (string, string) {
+	if u.Bot || gitlabBotUsername.MatchString(u.Username) {
+		return u.Username, "Bot"
+	}
+	return u.Username, "User"
+}
@@ -0,0 +1,177 @@
+package internal_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGitLab(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var client *fakes.Client
+	var clock *fakes.Clock
+	var forge *GitLabForge
+	var responses map[string]string
+	var requests []string
+
+	it.Before(func() {
+		requests = nil
+		responses = map[string]string{}
+		client = &fakes.Client{}
+		client.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+			requests = append(requests, path)
+			body, ok := responses[path]
+			if !ok {
+				return nil, fmt.Errorf("unexpected request for %s", path)
+			}
+			return []byte(body), nil
+		}
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+
+		forge = NewGitLabForge(client, clock)
+	})
+
+	context("GetRepos", func() {
+		it.Before(func() {
+			responses["/groups/example-group%2Fsubgroup/projects"] = `[
+{
+	"path_with_namespace": "example-group/subgroup/example-project",
+	"web_url": "https://gitlab.example.com/example-group/subgroup/example-project",
+	"visibility": "internal",
+	"topics": ["go"],
+	"last_activity_at": "2001-01-30T00:00:00Z",
+	"forked_from_project": null,
+	"namespace": {"full_path": "example-group/subgroup"}
+},
+{
+	"path_with_namespace": "example-group/subgroup/forked-project",
+	"forked_from_project": {"id": 1}
+},
+{
+	"path_with_namespace": "example-group/subgroup/archived-project",
+	"archived": true
+}]`
+		})
+
+		it("returns the group's projects that pass the filter", func() {
+			repos, err := forge.GetRepos("example-group/subgroup", RepoFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(client.GetCall.Receives.Params).To(ContainElement("include_subgroups=true"))
+
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].Name).To(Equal("example-group/subgroup/example-project"))
+			Expect(repos[0].Owner.Login).To(Equal("example-group/subgroup"))
+			Expect(repos[0].Private).To(BeTrue())
+			Expect(repos[0].Topics).To(Equal([]string{"go"}))
+			Expect(repos[0].PushedAt).To(Equal("2001-01-30T00:00:00Z"))
+		})
+
+		it("fetches each project's main language when the filter needs it", func() {
+			responses["/projects/example-group%2Fsubgroup%2Fexample-project/languages"] = `{"Shell": 10.5, "Go": 89.5}`
+
+			repos, err := forge.GetRepos("example-group/subgroup", RepoFilter{Languages: []string{"go"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].Language).To(Equal("Go"))
+			Expect(requests).To(HaveLen(2))
+		})
+
+		it("does not fetch languages when the filter does not need them", func() {
+			_, err := forge.GetRepos("example-group/subgroup", RepoFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"/groups/example-group%2Fsubgroup/projects"}))
+		})
+
+		it("maps forks so that the filter can include them", func() {
+			repos, err := forge.GetRepos("example-group/subgroup", RepoFilter{Forks: true})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(2))
+			Expect(repos[1].Fork).To(BeTrue())
+		})
+	})
+
+	context("GetRecentIssues", func() {
+		var repo Repository
+
+		it.Before(func() {
+			repo = Repository{Name: "example-group/example-project"}
+			responses["/projects/example-group%2Fexample-project/issues"] = `[
+{
+	"iid": 7,
+	"created_at": "2001-01-01T20:20:20Z",
+	"user_notes_count": 2,
+	"author": {"username": "originalPoster"},
+	"labels": ["bug"]
+}]`
+			responses["/projects/example-group%2Fexample-project/issues/7/notes"] = `[
+{"system": true, "created_at": "2001-01-01T20:21:20Z", "author": {"username": "maintainer"}},
+{"system": false, "created_at": "2001-01-01T20:22:20Z", "author": {"username": "project_12_bot_abc"}},
+{"system": false, "created_at": "2001-01-01T21:20:20Z", "author": {"username": "maintainer"}}
+]`
+			responses["/projects/example-group%2Fexample-project/merge_requests"] = `[
+{
+	"iid": 3,
+	"created_at": "2001-01-02T20:20:20Z",
+	"user_notes_count": 0,
+	"author": {"username": "contributor"},
+	"web_url": "https://gitlab.example.com/example-group/example-project/-/merge_requests/3"
+}]`
+		})
+
+		it("asks for issues and merge requests updated within the window", func() {
+			_, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(ContainElement("/projects/example-group%2Fexample-project/merge_requests"))
+			Expect(client.GetCall.Receives.Params).To(ContainElement("updated_after=2001-01-01T20:20:20Z"))
+			Expect(client.GetCall.Receives.Params).To(ContainElement("state=opened"))
+		})
+
+		it("returns issues and merge requests with their notes as comments", func() {
+			issues, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(2))
+
+			Expect(issues[0].Number).To(Equal(7))
+			Expect(issues[0].User.Login).To(Equal("originalPoster"))
+			Expect(issues[0].GetLabels()).To(Equal([]string{"bug"}))
+			Expect(issues[0].PullRequest).To(BeNil())
+			Expect(issues[0].NumComments).To(Equal(2))
+			Expect(issues[0].Comments[0].User.Type).To(Equal("Bot"))
+
+			Expect(issues[1].Number).To(Equal(3))
+			Expect(issues[1].PullRequest).To(Equal(&PullRequestLinks{URL: "https://gitlab.example.com/example-group/example-project/-/merge_requests/3", MergeRequest: true}))
+			Expect(issues[1].IsMergeRequest()).To(BeTrue())
+			Expect(issues[1].Comments).To(BeEmpty())
+		})
+
+		it("leaves out system notes and bots when finding the first reply", func() {
+			issues, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			reply, err := issues[0].GetFirstReply(forge.Client())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.User.Login).To(Equal("maintainer"))
+			Expect(reply.CreatedAt).To(Equal("2001-01-01T21:20:20Z"))
+		})
+
+		context("failure cases", func() {
+			context("when the notes cannot be fetched", func() {
+				it.Before(func() {
+					delete(responses, "/projects/example-group%2Fexample-project/issues/7/notes")
+				})
+
+				it("returns the error", func() {
+					_, err := forge.GetRecentIssues(repo, 30*24*time.Hour)
+					Expect(err).To(MatchError("getting notes: unexpected request for /projects/example-group%2Fexample-project/issues/7/notes"))
+				})
+			})
+		})
+	})
+}
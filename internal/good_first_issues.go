@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// GoodFirstIssuesConfig lists the labels that mark issues for newcomers.
+type GoodFirstIssuesConfig struct {
+	Labels []string `yaml:"labels"`
+}
+
+func (g GoodFirstIssuesConfig) Validate() error {
+	if len(g.Labels) == 0 {
+		return fmt.Errorf("labels: must not be empty")
+	}
+	return nil
+}
+
+// GoodFirstIssue is an issue labeled for newcomers. It was picked up when it
+// was first assigned or a pull request first mentioned it, and completed when
+// it was closed with a mentioning pull request merged. CompletedByNewcomer
+// says whether that pull request was its author's first merged one.
+type GoodFirstIssue struct {
+	Issue               IssueRef  `json:"issue"`
+	Title               string    `json:"title"`
+	CreatedAt           time.Time `json:"created_at"`
+	Open                bool      `json:"open"`
+	PickedUpAt          time.Time `json:"picked_up_at"`
+	Completed           bool      `json:"completed"`
+	CompletedBy         string    `json:"completed_by,omitempty"`
+	CompletedByNewcomer bool      `json:"completed_by_newcomer"`
+}
+
+// GoodFirstIssueReport says whether a repository's good first issues, or an
+// org's together, get picked up and by whom. Ages are in days and times to
+// pick up in hours; NewcomerShare is the share of completed issues completed
+// by newcomers.
+type GoodFirstIssueReport struct {
+	Name                 string  `json:"name"`
+	Issues               int     `json:"issues"`
+	Open                 int     `json:"open"`
+	MedianOpenAge        float64 `json:"median_open_age_days"`
+	OldestOpenAge        float64 `json:"oldest_open_age_days"`
+	PickedUp             int     `json:"picked_up"`
+	MedianTimeToPickUp   float64 `json:"median_time_to_pick_up_hours"`
+	Completed            int     `json:"completed"`
+	CompletedByNewcomers int     `json:"completed_by_newcomers"`
+	NewcomerShare        float64 `json:"newcomer_share"`
+}
+
+type GoodFirstIssueSummary struct {
+	Repositories []GoodFirstIssueReport `json:"repositories"`
+	Orgs         []GoodFirstIssueReport `json:"orgs"`
+}
+
+type timelineItem struct {
+	Event     string `json:"event"`
+	CreatedAt string `json:"created_at"`
+	Source    struct {
+		Issue *Issue `json:"issue"`
+	} `json:"source"`
+}
+
+// GetGoodFirstIssues returns the repository's issues with any of the labels
+// that are open or were opened since since, with their timelines looked at to
+// tell when they were picked up and who completed them. Each completer's
+// merged pull requests are read once, however many issues they completed.
+func GetGoodFirstIssues(client Client, repo Repository, since time.Time, labels []string) ([]GoodFirstIssue, error) {
+	issues, err := repo.GetLabeledIssues(client, labels, since)
+	if err != nil {
+		return nil, err
+	}
+
+	firstMerges := make(map[string]string)
+	var results []GoodFirstIssue
+	for _, issue := range issues {
+		created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse creation time of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		open := issue.State != "closed"
+		if !open && created.Before(since) {
+			continue
+		}
+
+		result := GoodFirstIssue{
+			Issue:     IssueRef{Repo: repo.Name, Number: issue.Number},
+			Title:     issue.Title,
+			CreatedAt: created,
+			Open:      open,
+		}
+		err = result.readTimeline(client, repo, !open, firstMerges)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// readTimeline finds when the issue was picked up and, if it is closed, who
+// completed it. firstMerges holds the first merge of each completer looked up
+// so far.
+func (g *GoodFirstIssue) readTimeline(client Client, repo Repository, closed bool, firstMerges map[string]string) error {
+	var items []timelineItem
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/issues/%d/timeline", repo.Name, g.Issue.Number), func(body []byte) (int, error) {
+		page := []timelineItem{}
+		err := json.Unmarshal(body, &page)
+		items = append(items, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return fmt.Errorf("getting timeline of %s: %s", g.Issue, err)
+	}
+
+	var completion *Issue
+	for _, item := range items {
+		pullRequest := item.Event == "cross-referenced" && item.Source.Issue != nil && item.Source.Issue.IsPullRequest()
+		if item.Event != "assigned" && !pullRequest {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, item.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("could not parse timeline time of %s: %s", g.Issue, err)
+		}
+		if g.PickedUpAt.IsZero() || at.Before(g.PickedUpAt) {
+			g.PickedUpAt = at
+		}
+		if closed && pullRequest && item.Source.Issue.PullRequest.MergedAt != "" {
+			completion = item.Source.Issue
+		}
+	}
+	if completion == nil {
+		return nil
+	}
+
+	g.Completed = true
+	g.CompletedBy = completion.User.Login
+	if contains(coreAssociations, completion.AuthorAssociation) {
+		return nil
+	}
+	login := completion.User.Login
+	first, ok := firstMerges[login]
+	if !ok {
+		var err error
+		first, err = firstMerge(client, repo, login)
+		if err != nil {
+			return fmt.Errorf("getting pull requests of %s: %s", login, err)
+		}
+		firstMerges[login] = first
+	}
+	g.CompletedByNewcomer = first == "" || first >= completion.PullRequest.MergedAt
+	return nil
+}
+
+// firstMerge returns when login first had a pull request merged into the
+// repository, or "" if never. GitHub stops calling people first-time
+// contributors as soon as their first pull request is merged, so their
+// author_association cannot tell whether a merge was their first.
+func firstMerge(client Client, repo Repository, login string) (string, error) {
+	first := ""
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/issues", repo.Name), func(body []byte) (int, error) {
+		page := []Issue{}
+		err := json.Unmarshal(body, &page)
+		for _, issue := range page {
+			if issue.PullRequest == nil || issue.PullRequest.MergedAt == "" {
+				continue
+			}
+			if first == "" || issue.PullRequest.MergedAt < first {
+				first = issue.PullRequest.MergedAt
+			}
+		}
+		return len(page), err
+	}, "state=closed", fmt.Sprintf("creator=%s", url.QueryEscape(login)))
+	return first, err
+}
+
+// NewGoodFirstIssueSummary reports on the good first issues of each
+// repository and of each org.
+func NewGoodFirstIssueSummary(issues []GoodFirstIssue, now time.Time) GoodFirstIssueSummary {
+	byRepo := make(map[string][]GoodFirstIssue)
+	byOrg := make(map[string][]GoodFirstIssue)
+	for _, issue := range issues {
+		byRepo[issue.Issue.Repo] = append(byRepo[issue.Issue.Repo], issue)
+		byOrg[ownerOf(issue.Issue.Repo)] = append(byOrg[ownerOf(issue.Issue.Repo)], issue)
+	}
+
+	summary := GoodFirstIssueSummary{Repositories: []GoodFirstIssueReport{}, Orgs: []GoodFirstIssueReport{}}
+	for name, items := range byRepo {
+		summary.Repositories = append(summary.Repositories, NewGoodFirstIssueReport(name, items, now))
+	}
+	for name, items := range byOrg {
+		summary.Orgs = append(summary.Orgs, NewGoodFirstIssueReport(name, items, now))
+	}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+	sort.Slice(summary.Orgs, func(i, j int) bool { return summary.Orgs[i].Name < summary.Orgs[j].Name })
+	return summary
+}
+
+func NewGoodFirstIssueReport(name string, issues []GoodFirstIssue, now time.Time) GoodFirstIssueReport {
+	report := GoodFirstIssueReport{Name: name, Issues: len(issues)}
+	var ages, pickUps []float64
+	for _, issue := range issues {
+		if issue.Open {
+			report.Open++
+			age := now.Sub(issue.CreatedAt).Hours() / 24
+			ages = append(ages, age)
+			if age > report.OldestOpenAge {
+				report.OldestOpenAge = age
+			}
+		}
+		if !issue.PickedUpAt.IsZero() {
+			report.PickedUp++
+			pickUps = append(pickUps, issue.PickedUpAt.Sub(issue.CreatedAt).Hours())
+		}
+		if issue.Completed {
+			report.Completed++
+			if issue.CompletedByNewcomer {
+				report.CompletedByNewcomers++
+			}
+		}
+	}
+	report.MedianOpenAge = Median(ages)
+	report.MedianTimeToPickUp = Median(pickUps)
+	if report.Completed > 0 {
+		report.NewcomerShare = float64(report.CompletedByNewcomers) / float64(report.Completed)
+	}
+	return report
+}
+
+func (s GoodFirstIssueSummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tISSUES\tOPEN\tMEDIAN OPEN AGE (DAYS)\tOLDEST (DAYS)\tPICKED UP\tMEDIAN TIME TO PICK UP (H)\tCOMPLETED\tBY NEWCOMERS")
+		for _, repo := range s.Repositories {
+			writeGoodFirstIssueRow(table, repo.Name, repo)
+		}
+		for _, org := range s.Orgs {
+			writeGoodFirstIssueRow(table, org.Name+" (org)", org)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeGoodFirstIssueRow(w io.Writer, name string, report GoodFirstIssueReport) {
+	fmt.Fprintf(w, "%s\t%d\t%d\t%.0f\t%.0f\t%d\t%.0f\t%d\t%d (%.0f%%)\n", name, report.Issues, report.Open, report.MedianOpenAge,
+		report.OldestOpenAge, report.PickedUp, report.MedianTimeToPickUp, report.Completed, report.CompletedByNewcomers, report.NewcomerShare*100)
+}
@@ -0,0 +1,131 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGoodFirstIssues(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var server *fakegithub.Server
+	var client APIClient
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "good_first_issues.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+
+		client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("GetGoodFirstIssues", func() {
+		it("finds open issues and those opened within the window with any of the labels", func() {
+			issues, err := GetGoodFirstIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), []string{"good first issue", "good-first-issue"})
+			Expect(err).NotTo(HaveOccurred())
+
+			byNumber := make(map[int]GoodFirstIssue)
+			for _, issue := range issues {
+				byNumber[issue.Issue.Number] = issue
+			}
+			Expect(byNumber).To(HaveLen(4))
+			Expect(byNumber).To(HaveKey(14))
+
+			Expect(byNumber[10].PickedUpAt).To(Equal(time.Date(2001, time.January, 3, 20, 20, 20, 0, time.UTC)))
+			Expect(byNumber[10].Completed).To(BeTrue())
+			Expect(byNumber[10].CompletedBy).To(Equal("newcomer"))
+			Expect(byNumber[10].CompletedByNewcomer).To(BeTrue())
+
+			Expect(byNumber[12].PickedUpAt).To(Equal(time.Date(2001, time.January, 12, 20, 20, 20, 0, time.UTC)))
+			Expect(byNumber[12].Completed).To(BeFalse())
+
+			Expect(byNumber[14].PickedUpAt.IsZero()).To(BeTrue())
+
+			Expect(byNumber[15].CompletedBy).To(Equal("other-maintainer"))
+			Expect(byNumber[15].CompletedByNewcomer).To(BeFalse())
+		})
+
+		it("reads the pull requests of each completer once", func() {
+			issues, err := GetGoodFirstIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), []string{"good first issue", "beginner"})
+			Expect(err).NotTo(HaveOccurred())
+
+			byNumber := make(map[int]GoodFirstIssue)
+			for _, issue := range issues {
+				byNumber[issue.Issue.Number] = issue
+			}
+			Expect(byNumber[10].CompletedByNewcomer).To(BeTrue())
+			Expect(byNumber[19].CompletedBy).To(Equal("newcomer"))
+			Expect(byNumber[19].CompletedByNewcomer).To(BeFalse())
+
+			var lookups []string
+			for _, request := range server.Handler.Requests() {
+				if strings.Contains(request, "creator=newcomer") {
+					lookups = append(lookups, request)
+				}
+			}
+			Expect(lookups).To(HaveLen(1))
+		})
+
+		it("does not count contributors with an earlier merged pull request as newcomers", func() {
+			issues, err := GetGoodFirstIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), []string{"good first issue"})
+			Expect(err).NotTo(HaveOccurred())
+
+			summary := NewGoodFirstIssueSummary(issues, now)
+			Expect(summary.Repositories).To(Equal([]GoodFirstIssueReport{{
+				Name:                 "example-org/example-repo",
+				Issues:               3,
+				Open:                 2,
+				MedianOpenAge:        70.5,
+				OldestOpenAge:        120,
+				PickedUp:             2,
+				MedianTimeToPickUp:   36,
+				Completed:            1,
+				CompletedByNewcomers: 1,
+				NewcomerShare:        1,
+			}}))
+		})
+	})
+
+	context("NewGoodFirstIssueSummary", func() {
+		it("reports per repository and per org", func() {
+			issues, err := GetGoodFirstIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), []string{"good first issue", "good-first-issue"})
+			Expect(err).NotTo(HaveOccurred())
+
+			summary := NewGoodFirstIssueSummary(issues, now)
+			Expect(summary.Orgs).To(Equal([]GoodFirstIssueReport{{
+				Name:                 "example-org",
+				Issues:               4,
+				Open:                 2,
+				MedianOpenAge:        70.5,
+				OldestOpenAge:        120,
+				PickedUp:             3,
+				MedianTimeToPickUp:   24,
+				Completed:            2,
+				CompletedByNewcomers: 1,
+				NewcomerShare:        0.5,
+			}}))
+
+			var output bytes.Buffer
+			Expect(summary.Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org (org)"))
+			Expect(output.String()).To(ContainSubstring("2          1 (50%)"))
+		})
+	})
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// GraphQLClient talks to GitHub's GraphQL (v4) API. It fetches a repository's
+// issues together with their first comments and timeline events, so that
+// measuring first contact does not take one request per issue.
+type GraphQLClient struct {
+	URL      string
+	client   HTTPClient
+	tokens   TokenSource
+	PageSize int
+	Comments int
+}
+
+const (
+	defaultGraphQLPageSize = 50
+	defaultGraphQLComments = 20
+)
+
+func NewGraphQLClient(graphqlURL string, httpClient HTTPClient, tokens TokenSource) GraphQLClient {
+	return GraphQLClient{
+		URL:      graphqlURL,
+		client:   httpClient,
+		tokens:   tokens,
+		PageSize: defaultGraphQLPageSize,
+		Comments: defaultGraphQLComments,
+	}
+}
+
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// Query runs a GraphQL query and unmarshals its data into result.
+func (c *GraphQLClient) Query(query string, variables map[string]interface{}, result interface{}) error {
+	payload, err := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	if err != nil {
+		return fmt.Errorf("encoding GraphQL query: %s", err)
+	}
+
+	token, err := c.tokens.Token()
+	if err != nil {
+		return fmt.Errorf("getting auth token: %s", err)
+	}
+
+	request, err := http.NewRequest("POST", c.URL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("could not parse GraphQL URL: %s", err)
+	}
+	request.Header.Add("Content-Type", "application/json")
+	if token != "" {
+		request.Header.Add("Authorization", fmt.Sprintf("bearer %s", token))
+	}
+
+	response, err := c.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("client couldn't make HTTP request: %s", err)
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return fmt.Errorf("reading GraphQL response: %s", err)
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphqlError  `json:"errors"`
+	}
+	err = json.Unmarshal(body, &envelope)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal JSON '%s' : %s", string(body), err)
+	}
+	if len(envelope.Errors) > 0 {
+		messages := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			messages = append(messages, e.Message)
+		}
+		return fmt.Errorf("GraphQL query failed: %s", strings.Join(messages, "; "))
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GraphQL query failed: unexpected status %d: %s", response.StatusCode, string(body))
+	}
+
+	err = json.Unmarshal(envelope.Data, result)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal JSON '%s' : %s", string(envelope.Data), err)
+	}
+	return nil
+}
+
+const recentIssuesQuery = `query($owner: String!, $name: String!, $since: DateTime!, $cursor: String, $pageSize: Int!, $comments: Int!) {
+  repository(owner: $owner, name: $name) {
+    issues(first: $pageSize, after: $cursor, states: [OPEN], filterBy: {since: $since}, orderBy: {field: CREATED_AT, direction: ASC}) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        number
+        createdAt
+        authorAssociation
+        author { login __typename }
+        labels(first: 100) { nodes { name } }
+        comments(first: $comments) {
+          totalCount
+          nodes { createdAt authorAssociation author { login __typename } }
+        }
+      }
+    }
+  }
+}`
+
+type graphqlActor struct {
+	Login    string `json:"login"`
+	Typename string `json:"__typename"`
+}
+
+type graphqlIssues struct {
+	Repository struct {
+		Issues struct {
+			PageInfo struct {
+				HasNextPage bool   `json:"hasNextPage"`
+				EndCursor   string `json:"endCursor"`
+			} `json:"pageInfo"`
+			Nodes []struct {
+				Number            int           `json:"number"`
+				CreatedAt         string        `json:"createdAt"`
+				AuthorAssociation string        `json:"authorAssociation"`
+				Author            *graphqlActor `json:"author"`
+				Labels            struct {
+					Nodes []Label `json:"nodes"`
+				} `json:"labels"`
+				Comments struct {
+					TotalCount int `json:"totalCount"`
+					Nodes      []struct {
+						CreatedAt         string        `json:"createdAt"`
+						AuthorAssociation string        `json:"authorAssociation"`
+						Author            *graphqlActor `json:"author"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"nodes"`
+		} `json:"issues"`
+	} `json:"repository"`
+}
+
+// GetRecentIssues returns the repository's open issues updated within the
+// window, like Repository.GetRecentIssues, but with each issue's first
+// comments already attached. Pull requests are excluded: unlike the REST API,
+// GraphQL does not list them as issues.
+func (c *GraphQLClient) GetRecentIssues(repo Repository, clock Clock, window time.Duration) ([]Issue, error) {
+	parts := strings.SplitN(repo.Name, "/", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("getting recent issues: %q is not a full repository name", repo.Name)
+	}
+
+	variables := map[string]interface{}{
+		"owner":    parts[0],
+		"name":     parts[1],
+		"since":    clock.Now().UTC().Add(-window).Format(time.RFC3339),
+		"pageSize": c.PageSize,
+		"comments": c.Comments,
+	}
+
+	issues := []Issue{}
+	for {
+		var page graphqlIssues
+		err := c.Query(recentIssuesQuery, variables, &page)
+		if err != nil {
+			return nil, fmt.Errorf("getting recent issues: %s", err)
+		}
+
+		for _, node := range page.Repository.Issues.Nodes {
+			issue := Issue{
+				Number:            node.Number,
+				CreatedAt:         node.CreatedAt,
+				NumComments:       node.Comments.TotalCount,
+				CommentsURL:       fmt.Sprintf("/repos/%s/issues/%d/comments", repo.Name, node.Number),
+				AuthorAssociation: node.AuthorAssociation,
+				Labels:            node.Labels.Nodes,
+				Comments:          []Comment{},
+			}
+			issue.User.Login, issue.User.Type = node.Author.user()
+
+			for _, commentNode := range node.Comments.Nodes {
+				comment := Comment{CreatedAt: commentNode.CreatedAt, AuthorAssociation: commentNode.AuthorAssociation}
+				comment.User.Login, comment.User.Type = commentNode.Author.user()
+				issue.Comments = append(issue.Comments, comment)
+			}
+
+			issues = append(issues, issue)
+		}
+
+		if !page.Repository.Issues.PageInfo.HasNextPage {
+			return issues, nil
+		}
+		variables["cursor"] = page.Repository.Issues.PageInfo.EndCursor
+	}
+}
+
+// user returns the login and REST-style user type of an actor. GitHub reports
+// deleted accounts as a null actor, which REST calls "ghost".
+func (a *graphqlActor) user() (string, string) {
+	if a == nil {
+		return "ghost", "User"
+	}
+	return a.Login, a.Typename
+}
@@ -0,0 +1,146 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testGraphQL(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var httpClient *fakes.HTTPClient
+	var tokens *fakes.TokenSource
+	var clock *fakes.Clock
+	var client GraphQLClient
+	var requests []map[string]interface{}
+
+	// respondWith replies to each request with the next recorded fixture.
+	respondWith := func(fixtures ...string) {
+		httpClient.DoCall.Stub = func(req *http.Request) (*http.Response, error) {
+			var payload map[string]interface{}
+			body, _ := ioutil.ReadAll(req.Body)
+			Expect(json.Unmarshal(body, &payload)).To(Succeed())
+			requests = append(requests, payload)
+
+			contents, err := ioutil.ReadFile(filepath.Join("testdata", "graphql", fixtures[len(requests)-1]))
+			Expect(err).NotTo(HaveOccurred())
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(bytes.NewReader(contents))}, nil
+		}
+	}
+
+	it.Before(func() {
+		httpClient = &fakes.HTTPClient{}
+		tokens = &fakes.TokenSource{}
+		tokens.TokenCall.Returns.String = "some-token"
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		requests = nil
+
+		client = NewGraphQLClient("https://api.github.com/graphql", httpClient, tokens)
+	})
+
+	context("GetRecentIssues", func() {
+		it.Before(func() {
+			respondWith("recent_issues_page_1.json", "recent_issues_page_2.json")
+		})
+
+		it("queries every page of the repository's recent issues", func() {
+			_, err := client.GetRecentIssues(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(httpClient.DoCall.Receives.Req.Method).To(Equal("POST"))
+			Expect(httpClient.DoCall.Receives.Req.URL.String()).To(Equal("https://api.github.com/graphql"))
+			Expect(httpClient.DoCall.Receives.Req.Header.Get("Authorization")).To(Equal("bearer some-token"))
+
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0]["variables"]).To(Equal(map[string]interface{}{
+				"owner":    "example-org",
+				"name":     "example-repo",
+				"since":    "2001-01-01T20:20:20Z",
+				"pageSize": 50.0,
+				"comments": 20.0,
+			}))
+			Expect(requests[1]["variables"]).To(HaveKeyWithValue("cursor", "Y3Vyc29yOnYyOpHOAAAAAQ=="))
+			Expect(requests[0]["query"]).To(ContainSubstring("states: [OPEN]"))
+		})
+
+		it("returns the issues with their comments", func() {
+			issues, err := client.GetRecentIssues(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(2))
+
+			first := issues[0]
+			Expect(first.Number).To(Equal(101))
+			Expect(first.CreatedAt).To(Equal("2001-01-01T20:20:20Z"))
+			Expect(first.NumComments).To(Equal(2))
+			Expect(first.CommentsURL).To(Equal("/repos/example-org/example-repo/issues/101/comments"))
+			Expect(first.User.Login).To(Equal("originalPoster"))
+			Expect(first.GetLabels()).To(Equal([]string{"bug"}))
+			Expect(first.Comments).To(HaveLen(2))
+			Expect(first.Comments[0].User.Type).To(Equal("Bot"))
+			Expect(first.Comments[1].User.Login).To(Equal("maintainer"))
+			Expect(first.Comments[1].AuthorAssociation).To(Equal("MEMBER"))
+			Expect(first.Events).To(BeEmpty())
+
+			second := issues[1]
+			Expect(second.Number).To(Equal(102))
+			Expect(second.User.Login).To(Equal("ghost"))
+			Expect(second.Comments).To(BeEmpty())
+		})
+
+		it("feeds the same first reply logic as the REST backend", func() {
+			issues, err := client.GetRecentIssues(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+
+			restClient := &fakes.Client{}
+			reply, err := issues[0].GetFirstReply(restClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reply.User.Login).To(Equal("maintainer"))
+			Expect(reply.CreatedAt).To(Equal("2001-01-01T21:20:20Z"))
+			Expect(restClient.GetCall.CallCount).To(Equal(0))
+		})
+
+		context("failure cases", func() {
+			context("when the query returns errors", func() {
+				it.Before(func() {
+					respondWith("error.json")
+				})
+
+				it("returns the error", func() {
+					_, err := client.GetRecentIssues(Repository{Name: "example-org/missing-repo"}, clock, 30*24*time.Hour)
+					Expect(err).To(MatchError("getting recent issues: GraphQL query failed: Could not resolve to a Repository with the name 'example-org/missing-repo'."))
+				})
+			})
+
+			context("when the request fails", func() {
+				it.Before(func() {
+					httpClient.DoCall.Stub = nil
+					httpClient.DoCall.Returns.Error = fmt.Errorf("connection refused")
+				})
+
+				it("returns the error", func() {
+					_, err := client.GetRecentIssues(Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour)
+					Expect(err).To(MatchError("getting recent issues: client couldn't make HTTP request: connection refused"))
+				})
+			})
+
+			context("when the repository name has no owner", func() {
+				it("returns the error", func() {
+					_, err := client.GetRecentIssues(Repository{Name: "example-repo"}, clock, 30*24*time.Hour)
+					Expect(err).To(MatchError(`getting recent issues: "example-repo" is not a full repository name`))
+				})
+			})
+		})
+	})
+}
@@ -12,5 +12,36 @@ func TestInternal(t *testing.T) {
 	suite("TestIssue", testIssue)
 	suite("TestAPIClient", testAPIClient)
 	suite("TestRepository", testRepository)
+	suite("TestConfig", testConfig)
+	suite("TestBusinessHours", testBusinessHours)
+	suite("TestReport", testReport)
+	suite("TestRepoFilter", testRepoFilter)
+	suite("TestSegments", testSegments)
+	suite("TestResponders", testResponders)
+	suite("TestTokenSource", testTokenSource)
+	suite("TestGitHubApp", testGitHubApp)
+	suite("TestServerConfig", testServerConfig)
+	suite("TestGraphQL", testGraphQL)
+	suite("TestForge", testForge)
+	suite("TestGitLab", testGitLab)
+	suite("TestGitea", testGitea)
+	suite("TestGitHubIntegration", testGitHubIntegration)
+	suite("TestCassette", testCassette)
+	suite("TestLogger", testLogger)
+	suite("TestLoggingClient", testLoggingClient)
+	suite("TestErrors", testErrors)
+	suite("TestFirstContact", testFirstContact)
+	suite("TestContactStore", testContactStore)
+	suite("TestWebhook", testWebhook)
+	suite("TestBacklog", testBacklog)
+	suite("TestStale", testStale)
+	suite("TestContributors", testContributors)
+	suite("TestBusFactor", testBusFactor)
+	suite("TestCadence", testCadence)
+	suite("TestNewcomers", testNewcomers)
+	suite("TestDiscussions", testDiscussions)
+	suite("TestGoodFirstIssues", testGoodFirstIssues)
+	suite("TestTriage", testTriage)
+	suite("TestSecurity", testSecurity)
 	suite.Run(t)
 }
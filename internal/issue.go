@@ -4,20 +4,46 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 )
 
-type TimeContainer struct {
-	Time  float64
-	Error error
-}
-
 type Issue struct {
+	Number      int    `json:"number"`
+	Title       string `json:"title"`
+	Body        string `json:"body"`
+	State       string `json:"state"`
 	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	ClosedAt    string `json:"closed_at"`
 	NumComments int    `json:"comments"`
 	CommentsURL string `json:"comments_url"`
 	User        struct {
 		Login string `json:"login"`
+		Type  string `json:"type"`
 	} `json:"user"`
+	AuthorAssociation string            `json:"author_association"`
+	Labels            []Label           `json:"labels"`
+	PullRequest       *PullRequestLinks `json:"pull_request"`
+
+	// Comments are filled in by backends that fetch them together with the
+	// issue, and hold the earliest comments, which may be fewer than
+	// NumComments. Events are filled in by the reports that read them.
+	Comments []Comment    `json:"-"`
+	Events   []IssueEvent `json:"-"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+// PullRequestLinks is only set on issues that are pull requests. MergedAt is
+// empty until the pull request is merged. MergeRequest marks GitLab merge
+// requests, which are numbered apart from issues.
+type PullRequestLinks struct {
+	URL          string `json:"url"`
+	MergedAt     string `json:"merged_at"`
+	MergeRequest bool   `json:"-"`
 }
 
 type Comment struct {
@@ -25,14 +51,103 @@ type Comment struct {
 		Login string `json:"login"`
 		Type  string `json:"type"`
 	} `json:"user"`
-	CreatedAt string `json:"created_at"`
+	CreatedAt         string `json:"created_at"`
+	AuthorAssociation string `json:"author_association"`
+}
+
+// IssueEvent is a timeline event on an issue, such as it being labeled,
+// assigned or closed.
+type IssueEvent struct {
+	Type  string
+	Actor struct {
+		Login string
+		Type  string
+	}
+	CreatedAt string
 }
 
 //go:generate faux --interface CommentGetter --output fakes/comment_getter.go
 type CommentGetter interface {
-	GetFirstReply(client Client, ignoredUsers ...string) (Comment, error)
+	GetFirstResponse(client Client, ignoredUsers ...string) (*Response, error)
 	GetCreatedAt() string
 	GetUserLogin() string
+	GetUserType() string
+	GetLabels() []string
+	GetNumber() int
+	IsPullRequest() bool
+	IsMergeRequest() bool
+}
+
+// GetFirstResponse returns the earliest reply or, on a pull request, submitted
+// review that counts as first contact, or nil if there is none yet.
+func (i *Issue) GetFirstResponse(client Client, ignoredUsers ...string) (*Response, error) {
+	var first *Response
+	reply, err := i.GetFirstReply(client, ignoredUsers...)
+	if err != nil {
+		return nil, err
+	}
+	if reply.CreatedAt != "" {
+		at, err := time.Parse(time.RFC3339, reply.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse first reply time: %s", err)
+		}
+		first = &Response{At: at, Actor: reply.User.Login, Kind: ResponseComment}
+	}
+
+	review, err := i.firstReview(client, ignoredUsers)
+	if err != nil {
+		return nil, err
+	}
+	if review.SubmittedAt != "" {
+		at, err := time.Parse(time.RFC3339, review.SubmittedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse first review time: %s", err)
+		}
+		if first == nil || at.Before(first.At) {
+			first = &Response{At: at, Actor: review.User.Login, Kind: ResponseReview}
+		}
+	}
+	return first, nil
+}
+
+// firstReview returns the first submitted review of a pull request that is
+// neither from its author, an ignored user nor a bot. Issues, and pull
+// requests whose API URL does not lead to GitHub's reviews endpoint, have
+// none.
+func (i *Issue) firstReview(client Client, ignoredUsers []string) (restReview, error) {
+	if i.PullRequest == nil || i.PullRequest.URL == "" {
+		return restReview{}, nil
+	}
+	pullRequestURL, err := url.Parse(i.PullRequest.URL)
+	if err != nil {
+		return restReview{}, fmt.Errorf("parsing pull request url: %s", err)
+	}
+	if !strings.Contains(pullRequestURL.Path, "/pulls/") {
+		return restReview{}, nil
+	}
+
+	var reviews []restReview
+	err = getAllPages(client, pullRequestURL.Path+"/reviews", func(body []byte) (int, error) {
+		page := []restReview{}
+		err := json.Unmarshal(body, &page)
+		reviews = append(reviews, page...)
+		return len(page), err
+	})
+	if err != nil {
+		return restReview{}, fmt.Errorf("getting pull request reviews: %s", err)
+	}
+
+	// Reviews are listed in the order they were submitted.
+	for _, review := range reviews {
+		if review.State == "PENDING" || review.SubmittedAt == "" || review.User.Login == i.User.Login {
+			continue
+		}
+		if ignoredActor(review.User.Login, review.User.Type, ignoredUsers) {
+			continue
+		}
+		return review, nil
+	}
+	return restReview{}, nil
 }
 
 func (i *Issue) GetFirstReply(client Client, ignoredUsers ...string) (Comment, error) {
@@ -40,48 +155,102 @@ func (i *Issue) GetFirstReply(client Client, ignoredUsers ...string) (Comment, e
 		return Comment{}, nil
 	}
 
+	if reply, found := i.firstReply(i.Comments, ignoredUsers); found {
+		return reply, nil
+	}
+	if len(i.Comments) >= i.NumComments {
+		return Comment{}, nil
+	}
+
 	commentsURL, err := url.Parse(i.CommentsURL)
 	if err != nil {
 		return Comment{}, fmt.Errorf("parsing comments url: %s", err)
 	}
 
-	//TODO: Figure out whether pagination is a thing we should worry about here
-	body, err := client.Get(commentsURL.Path)
+	// The comments are paged through until one is a reply.
+	var reply Comment
+	err = getAllPages(client, commentsURL.Path, func(body []byte) (int, error) {
+		page := []Comment{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return 0, err
+		}
+		var found bool
+		reply, found = i.firstReply(page, ignoredUsers)
+		if found {
+			return 0, nil
+		}
+		return len(page), nil
+	})
 	if err != nil {
 		return Comment{}, fmt.Errorf("getting issue comments: %s", err)
 	}
+	return reply, nil
+}
 
-	replies := []Comment{}
-	err = json.Unmarshal(body, &replies)
-	if err != nil {
-		return Comment{}, fmt.Errorf("getting issue comments: could not unmarshal JSON '%s' : %s", string(body), err)
-	}
-
-	ignore := make(map[string]struct{})
-	for i := range ignoredUsers {
-		ignore[ignoredUsers[i]] = struct{}{}
-	}
-
+// firstReply finds the first comment that is neither from the issue's author,
+// an ignored user nor a bot.
+func (i *Issue) firstReply(replies []Comment, ignoredUsers []string) (Comment, bool) {
 	// Comments are sorted by ascending ID. TODO:Does that correspond to recency of creation?
 	for _, reply := range replies {
 		if reply.User.Login == i.User.Login {
 			continue
 		}
-		if _, skipUser := ignore[reply.User.Login]; skipUser {
-			continue
-		}
-		if reply.User.Type == "Bot" {
+		if ignoredActor(reply.User.Login, reply.User.Type, ignoredUsers) {
 			continue
 		}
-		return reply, nil
+		return reply, true
 	}
-	return Comment{}, nil
+	return Comment{}, false
+}
+
+// ignoredActor reports whether activity by a user is left out of the metrics
+// because the user is ignored or a bot.
+func ignoredActor(login, userType string, ignoredUsers []string) bool {
+	return contains(ignoredUsers, login) || userType == "Bot"
+}
+
+// ignoredAuthor reports whether what a user opened is left out of the
+// metrics: the user is ignored, or is a bot, by account type or by the bot
+// policy's patterns, and the policy does not include bots.
+func ignoredAuthor(login, userType string, options ContactOptions) bool {
+	if contains(options.IgnoredUsers, login) {
+		return true
+	}
+	return !options.Bots.Include && (userType == "Bot" || options.Bots.IsBot(login))
 }
 
 func (i *Issue) GetCreatedAt() string {
 	return i.CreatedAt
 }
 
+func (i *Issue) GetNumber() int {
+	return i.Number
+}
+
 func (i *Issue) GetUserLogin() string {
 	return i.User.Login
 }
+
+func (i *Issue) GetUserType() string {
+	return i.User.Type
+}
+
+func (i *Issue) IsPullRequest() bool {
+	return i.PullRequest != nil
+}
+
+func (i *Issue) IsMergeRequest() bool {
+	return i.PullRequest != nil && i.PullRequest.MergeRequest
+}
+
+func (i *Issue) GetLabels() []string {
+	if len(i.Labels) == 0 {
+		return nil
+	}
+	labels := make([]string, 0, len(i.Labels))
+	for _, label := range i.Labels {
+		labels = append(labels, label.Name)
+	}
+	return labels
+}
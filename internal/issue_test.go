@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"gloss/internal"
 	"gloss/internal/fakes"
+	"strings"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	"github.com/sclevine/spec"
@@ -151,6 +153,107 @@ func testIssue(t *testing.T, context spec.G, it spec.S) {
 				Expect(reply).To(Equal(internal.Comment{}))
 			})
 		})
+		context("when the first reply is past the first page of comments", func() {
+			it.Before(func() {
+				issue = internal.Issue{
+					NumComments: 101,
+					CommentsURL: "https://api.github.com/repos/example-org/example-repo/issues/7/comments",
+				}
+				issue.User.Login = "originalPoster"
+
+				var own []string
+				for i := 0; i < 100; i++ {
+					own = append(own, `{"user": {"login": "originalPoster", "type": "User"}, "created_at": "2001-01-01T00:00:00Z"}`)
+				}
+				client.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+					if params[len(params)-1] == "page=1" {
+						return []byte("[" + strings.Join(own, ",") + "]"), nil
+					}
+					return []byte(`[{"user": {"login": "replyGuy", "type": "User"}, "created_at": "2001-01-02T00:00:00Z"}]`), nil
+				}
+			})
+
+			it("pages through the comments until it finds one", func() {
+				reply, err := issue.GetFirstReply(client)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply.User.Login).To(Equal("replyGuy"))
+				Expect(client.GetCall.CallCount).To(Equal(2))
+				Expect(client.GetCall.Receives.Params).To(ContainElement("page=2"))
+			})
+		})
+		context("when the issue's first comments were fetched with it", func() {
+			var prefetched internal.Comment
+
+			it.Before(func() {
+				issue = internal.Issue{
+					NumComments: 2,
+					CommentsURL: "www.example.com",
+				}
+				issue.User.Login = "originalPoster"
+
+				prefetched = internal.Comment{CreatedAt: "2001-01-01T00:00:00Z"}
+				prefetched.User.Login = "replyGuy"
+				issue.Comments = []internal.Comment{prefetched}
+			})
+
+			it("returns the reply without requesting the comments", func() {
+				reply, err := issue.GetFirstReply(client)
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(reply).To(Equal(prefetched))
+				Expect(client.GetCall.CallCount).To(Equal(0))
+			})
+
+			context("when none of them is a reply but there are more", func() {
+				it.Before(func() {
+					issue.Comments[0].User.Login = "originalPoster"
+
+					client.GetCall.Returns.ByteSlice = []byte(`
+[
+  {
+    "user": {
+      "login": "originalPoster",
+      "type": "User"
+    },
+		"created_at": "2001-01-01T00:00:00Z"
+  },
+  {
+    "user": {
+      "login": "replyGuy",
+      "type": "User"
+    },
+		"created_at": "2001-01-02T00:00:00Z"
+  }
+]
+`)
+				})
+
+				it("requests the comments", func() {
+					reply, err := issue.GetFirstReply(client)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(reply.User.Login).To(Equal("replyGuy"))
+					Expect(client.GetCall.CallCount).To(Equal(1))
+				})
+			})
+
+			context("when none of them is a reply and there are no more", func() {
+				it.Before(func() {
+					issue.NumComments = 1
+					issue.Comments[0].User.Login = "originalPoster"
+				})
+
+				it("returns an empty comment without requesting the comments", func() {
+					reply, err := issue.GetFirstReply(client)
+
+					Expect(err).NotTo(HaveOccurred())
+					Expect(reply).To(Equal(internal.Comment{}))
+					Expect(client.GetCall.CallCount).To(Equal(0))
+				})
+			})
+		})
+
 		context("failure cases", func() {
 			context("when the comment URL cannot be parsed", func() {
 				it.Before(func() {
@@ -195,6 +298,102 @@ func testIssue(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("GetFirstResponse", func() {
+		var client *fakes.Client
+		var reviews string
+
+		it.Before(func() {
+			issue = internal.Issue{
+				NumComments: 1,
+				CommentsURL: "https://api.github.com/repos/example-org/example-repo/issues/7/comments",
+				PullRequest: &internal.PullRequestLinks{URL: "https://api.github.com/repos/example-org/example-repo/pulls/7"},
+			}
+			issue.User.Login = "originalPoster"
+
+			reviews = `[
+  {"user": {"login": "originalPoster", "type": "User"}, "state": "COMMENTED", "submitted_at": "2001-01-01T00:00:00Z"},
+  {"user": {"login": "reviewGuy", "type": "User"}, "state": "PENDING"},
+  {"user": {"login": "reviewGuy", "type": "User"}, "state": "APPROVED", "submitted_at": "2001-01-01T12:00:00Z"}
+]`
+			client = &fakes.Client{}
+			client.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+				switch path {
+				case "/repos/example-org/example-repo/issues/7/comments":
+					return []byte(`[{"user": {"login": "replyGuy", "type": "User"}, "created_at": "2001-01-02T00:00:00Z"}]`), nil
+				case "/repos/example-org/example-repo/pulls/7/reviews":
+					return []byte(reviews), nil
+				}
+				return nil, fmt.Errorf("unexpected path %s", path)
+			}
+		})
+
+		it("returns the first submitted review of a pull request when it came before any reply", func() {
+			response, err := issue.GetFirstResponse(client)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response).To(Equal(&internal.Response{
+				At:    time.Date(2001, time.January, 1, 12, 0, 0, 0, time.UTC),
+				Actor: "reviewGuy",
+				Kind:  internal.ResponseReview,
+			}))
+		})
+
+		it("returns the first reply when it came before any review", func() {
+			reviews = `[{"user": {"login": "reviewGuy", "type": "User"}, "state": "APPROVED", "submitted_at": "2001-01-03T00:00:00Z"}]`
+			response, err := issue.GetFirstResponse(client)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response).To(Equal(&internal.Response{
+				At:    time.Date(2001, time.January, 2, 0, 0, 0, 0, time.UTC),
+				Actor: "replyGuy",
+				Kind:  internal.ResponseComment,
+			}))
+		})
+
+		it("does not request reviews of issues", func() {
+			issue.PullRequest = nil
+			response, err := issue.GetFirstResponse(client)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response.Kind).To(Equal(internal.ResponseComment))
+			Expect(client.GetCall.CallCount).To(Equal(1))
+		})
+
+		it("returns nil when nobody has responded", func() {
+			issue.NumComments = 0
+			reviews = `[]`
+			response, err := issue.GetFirstResponse(client)
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(response).To(BeNil())
+		})
+
+		context("failure cases", func() {
+			context("when the reply time cannot be parsed", func() {
+				it.Before(func() {
+					issue.PullRequest = nil
+					issue.Comments = []internal.Comment{{CreatedAt: "some-garbage"}}
+				})
+
+				it("returns the error", func() {
+					_, err := issue.GetFirstResponse(client)
+					Expect(err).To(MatchError(`could not parse first reply time: parsing time "some-garbage" as "2006-01-02T15:04:05Z07:00": cannot parse "some-garbage" as "2006"`))
+				})
+			})
+
+			context("when the reviews cannot be fetched", func() {
+				it.Before(func() {
+					reviews = "[["
+				})
+
+				it("returns the error", func() {
+					_, err := issue.GetFirstResponse(client)
+					Expect(err).To(MatchError("getting pull request reviews: could not unmarshal JSON '[[' : unexpected end of JSON input"))
+				})
+			})
+		})
+	})
+
 	context("GetCreatedAt", func() {
 		context("when the issue has a CreatedAt field", func() {
 			it.Before(func() {
@@ -249,4 +448,26 @@ func testIssue(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 	})
+
+	context("GetLabels", func() {
+		context("when the issue has labels", func() {
+			it.Before(func() {
+				issue = internal.Issue{Labels: []internal.Label{{Name: "bug"}, {Name: "help wanted"}}}
+			})
+
+			it("returns the label names", func() {
+				Expect(issue.GetLabels()).To(Equal([]string{"bug", "help wanted"}))
+			})
+		})
+
+		context("when the issue has no labels", func() {
+			it.Before(func() {
+				issue = internal.Issue{}
+			})
+
+			it("returns nil", func() {
+				Expect(issue.GetLabels()).To(BeNil())
+			})
+		})
+	})
 }
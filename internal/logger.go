@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	default:
+		return "ERROR"
+	}
+}
+
+// Logger writes structured log lines at or above its level, either as
+// key=value text or as one JSON object per line. Fields are given as
+// alternating keys and values. A nil *Logger logs nothing, so components can
+// leave theirs unset.
+type Logger struct {
+	w      io.Writer
+	level  Level
+	format string
+	clock  Clock
+	mutex  sync.Mutex
+}
+
+// LogLevels are the names ParseLevel accepts, from most to least verbose.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// ParseLevel reads a level by its name in LogLevels.
+func ParseLevel(name string) (Level, error) {
+	for i, level := range LogLevels {
+		if name == level {
+			return Level(i), nil
+		}
+	}
+	return LevelWarn, fmt.Errorf("log level %q is not one of %s", name, strings.Join(LogLevels, ", "))
+}
+
+// LogFormats are the formats NewLogger accepts.
+var LogFormats = []string{"text", "json"}
+
+func NewLogger(w io.Writer, level Level, format string, clock Clock) (*Logger, error) {
+	if !contains(LogFormats, format) {
+		return nil, fmt.Errorf("log format %q is not one of %s", format, strings.Join(LogFormats, ", "))
+	}
+	return &Logger{w: w, level: level, format: format, clock: clock}, nil
+}
+
+func (l *Logger) Debug(msg string, fields ...interface{}) {
+	l.log(LevelDebug, msg, fields)
+}
+
+func (l *Logger) Info(msg string, fields ...interface{}) {
+	l.log(LevelInfo, msg, fields)
+}
+
+func (l *Logger) Warn(msg string, fields ...interface{}) {
+	l.log(LevelWarn, msg, fields)
+}
+
+func (l *Logger) Error(msg string, fields ...interface{}) {
+	l.log(LevelError, msg, fields)
+}
+
+func (l *Logger) log(level Level, msg string, fields []interface{}) {
+	if l == nil || level < l.level {
+		return
+	}
+
+	all := append([]interface{}{
+		"time", l.clock.Now().UTC().Format("2006-01-02T15:04:05.000Z07:00"),
+		"level", level.String(),
+		"msg", msg,
+	}, fields...)
+	if len(all)%2 != 0 {
+		all = append(all, "(missing)")
+	}
+
+	var line bytes.Buffer
+	if l.format == "json" {
+		line.WriteString("{")
+		for i := 0; i < len(all); i += 2 {
+			if i > 0 {
+				line.WriteString(",")
+			}
+			key, _ := json.Marshal(fmt.Sprint(all[i]))
+			value, err := json.Marshal(logValue(all[i+1]))
+			if err != nil {
+				value, _ = json.Marshal(fmt.Sprint(all[i+1]))
+			}
+			line.Write(key)
+			line.WriteString(":")
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+	} else {
+		for i := 0; i < len(all); i += 2 {
+			if i > 0 {
+				line.WriteString(" ")
+			}
+			value := fmt.Sprint(logValue(all[i+1]))
+			if value == "" || strings.ContainsAny(value, " =\"\t\n") {
+				value = strconv.Quote(value)
+			}
+			fmt.Fprintf(&line, "%s=%s", all[i], value)
+		}
+		line.WriteString("\n")
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.w.Write(line.Bytes())
+}
+
+// logValue turns errors and durations into strings, which JSON would otherwise
+// render as {} and nanoseconds.
+func logValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return value
+	}
+}
@@ -0,0 +1,88 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testLogger(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var buffer *bytes.Buffer
+	var clock *fakes.Clock
+
+	it.Before(func() {
+		buffer = &bytes.Buffer{}
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC)
+	})
+
+	context("text format", func() {
+		it("writes key=value pairs, quoting values that need it", func() {
+			logger, err := NewLogger(buffer, LevelInfo, "text", clock)
+			Expect(err).NotTo(HaveOccurred())
+
+			logger.Info("measured repository", "repo", "example-org/example-repo", "error", errors.New("some error"), "duration", 1500*time.Millisecond)
+			Expect(buffer.String()).To(Equal(`time=2001-01-01T20:20:20.000Z level=INFO msg="measured repository" repo=example-org/example-repo error="some error" duration=1.5s` + "\n"))
+		})
+	})
+
+	context("json format", func() {
+		it("writes one object per line with the fields in order", func() {
+			logger, err := NewLogger(buffer, LevelInfo, "json", clock)
+			Expect(err).NotTo(HaveOccurred())
+
+			logger.Warn("HTTP request refused", "status", 404, "error", errors.New("some error"))
+			Expect(buffer.String()).To(Equal(`{"time":"2001-01-01T20:20:20.000Z","level":"WARN","msg":"HTTP request refused","status":404,"error":"some error"}` + "\n"))
+
+			var line map[string]interface{}
+			Expect(json.Unmarshal(buffer.Bytes(), &line)).To(Succeed())
+		})
+	})
+
+	it("leaves out lines below its level", func() {
+		logger, err := NewLogger(buffer, LevelWarn, "text", clock)
+		Expect(err).NotTo(HaveOccurred())
+
+		logger.Debug("debug")
+		logger.Info("info")
+		logger.Warn("warn")
+		logger.Error("error")
+		Expect(buffer.String()).To(Equal("time=2001-01-01T20:20:20.000Z level=WARN msg=warn\ntime=2001-01-01T20:20:20.000Z level=ERROR msg=error\n"))
+	})
+
+	it("parses levels by name", func() {
+		level, err := ParseLevel("info")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(level).To(Equal(LevelInfo))
+	})
+
+	it("logs nothing when nil", func() {
+		var logger *Logger
+		logger.Error("nobody hears this")
+	})
+
+	context("failure cases", func() {
+		context("when the format is unknown", func() {
+			it("returns the error", func() {
+				_, err := NewLogger(buffer, LevelInfo, "xml", clock)
+				Expect(err).To(MatchError(`log format "xml" is not one of text, json`))
+			})
+		})
+
+		context("when the level is unknown", func() {
+			it("returns the error", func() {
+				_, err := ParseLevel("loud")
+				Expect(err).To(MatchError(`log level "loud" is not one of debug, info, warn, error`))
+			})
+		})
+	})
+}
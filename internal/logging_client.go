@@ -0,0 +1,57 @@
+package internal
+
+import "net/http"
+
+// rateLimitHeaders are the headers GitHub, GitLab and Gitea report their rate
+// limits in, by the field they are logged as.
+var rateLimitHeaders = [][2]string{
+	{"rate_limit", "X-RateLimit-Limit"},
+	{"rate_limit_remaining", "X-RateLimit-Remaining"},
+	{"rate_limit_reset", "X-RateLimit-Reset"},
+	{"rate_limit", "RateLimit-Limit"},
+	{"rate_limit_remaining", "RateLimit-Remaining"},
+	{"rate_limit_reset", "RateLimit-Reset"},
+}
+
+// LoggingClient passes requests on to another HTTPClient and logs each one
+// with its status, latency and the rate limit left: at debug level when it
+// succeeds, warn when the server refuses it and error when it fails outright.
+type LoggingClient struct {
+	client HTTPClient
+	logger *Logger
+	clock  Clock
+}
+
+func NewLoggingClient(client HTTPClient, logger *Logger, clock Clock) *LoggingClient {
+	return &LoggingClient{client: client, logger: logger, clock: clock}
+}
+
+func (c *LoggingClient) Do(req *http.Request) (*http.Response, error) {
+	// The query is logged, but never the headers, which carry credentials.
+	uri := *req.URL
+	uri.User = nil
+
+	start := c.clock.Now()
+	response, err := c.client.Do(req)
+	latency := c.clock.Now().Sub(start)
+
+	fields := []interface{}{"method", req.Method, "url", uri.String(), "latency_ms", latency.Milliseconds()}
+	if err != nil {
+		c.logger.Error("HTTP request failed", append(fields, "error", err)...)
+		return nil, err
+	}
+
+	fields = append(fields, "status", response.StatusCode)
+	for _, header := range rateLimitHeaders {
+		if value := response.Header.Get(header[1]); value != "" {
+			fields = append(fields, header[0], value)
+		}
+	}
+
+	if response.StatusCode >= 400 {
+		c.logger.Warn("HTTP request refused", fields...)
+	} else {
+		c.logger.Debug("HTTP request", fields...)
+	}
+	return response, nil
+}
@@ -0,0 +1,101 @@
+package internal_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testLoggingClient(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var buffer *bytes.Buffer
+	var clock *fakes.Clock
+	var logger *Logger
+	var server *fakegithub.Server
+
+	lines := func() []map[string]interface{} {
+		var lines []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			var fields map[string]interface{}
+			Expect(json.Unmarshal([]byte(line), &fields)).To(Succeed())
+			lines = append(lines, fields)
+		}
+		return lines
+	}
+
+	it.Before(func() {
+		buffer = &bytes.Buffer{}
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC)
+
+		var err error
+		logger, err = NewLogger(buffer, LevelDebug, "json", clock)
+		Expect(err).NotTo(HaveOccurred())
+
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	it("logs each request with its status and rate limit at debug level", func() {
+		client, err := NewAPIClient(server.URL, NewLoggingClient(http.DefaultClient, logger, clock), StaticToken("some-token"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Get("orgs/example-org/repos", "per_page=100")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(lines()).To(Equal([]map[string]interface{}{{
+			"time":                 "2001-01-01T20:20:20.000Z",
+			"level":                "DEBUG",
+			"msg":                  "HTTP request",
+			"method":               "GET",
+			"url":                  server.URL + "/orgs/example-org/repos?per_page=100",
+			"latency_ms":           0.0,
+			"status":               200.0,
+			"rate_limit":           "5000",
+			"rate_limit_remaining": "4999",
+			"rate_limit_reset":     lines()[0]["rate_limit_reset"],
+		}}))
+		Expect(buffer.String()).NotTo(ContainSubstring("some-token"))
+	})
+
+	it("logs refused requests as warnings", func() {
+		client, err := NewAPIClient(server.URL, NewLoggingClient(http.DefaultClient, logger, clock), StaticToken(""))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = client.Get("orgs/missing-org/repos")
+		Expect(err).To(MatchError(ContainSubstring("returned 404 Not Found")))
+
+		Expect(lines()[0]).To(HaveKeyWithValue("level", "WARN"))
+		Expect(lines()[0]).To(HaveKeyWithValue("status", 404.0))
+	})
+
+	it("logs failed requests as errors", func() {
+		httpClient := &fakes.HTTPClient{}
+		httpClient.DoCall.Returns.Error = errors.New("connection refused")
+
+		req, err := http.NewRequest("GET", "https://api.github.com/orgs/example-org/repos", nil)
+		Expect(err).NotTo(HaveOccurred())
+		_, err = NewLoggingClient(httpClient, logger, clock).Do(req)
+		Expect(err).To(MatchError("connection refused"))
+
+		Expect(lines()[0]).To(HaveKeyWithValue("level", "ERROR"))
+		Expect(lines()[0]).To(HaveKeyWithValue("error", "connection refused"))
+	})
+}
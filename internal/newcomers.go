@@ -0,0 +1,179 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// newcomerAssociations are the author_associations GitHub gives people who
+// have not contributed to a repository before.
+var newcomerAssociations = []string{"FIRST_TIME_CONTRIBUTOR", "FIRST_TIMER"}
+
+// NewcomerItem is an issue or pull request opened within the window, with its
+// first contact and, for pull requests, whether and when it was merged.
+type NewcomerItem struct {
+	Contact  FirstContact
+	Author   string
+	Newcomer bool
+	Closed   bool
+	MergedAt time.Time
+}
+
+// GroupExperience summarizes the issues and pull requests of newcomers, or of
+// established contributors. MedianFirstContact is over all of them, as it is
+// for first contact times; MedianTimeToMerge is over the merged pull requests, and
+// ClosedUnmergedShare is the share of all pull requests that were closed
+// without being merged.
+type GroupExperience struct {
+	Issues              int     `json:"issues"`
+	PullRequests        int     `json:"pull_requests"`
+	MedianFirstContact  float64 `json:"median_first_contact_minutes"`
+	Merged              int     `json:"merged"`
+	MedianTimeToMerge   float64 `json:"median_time_to_merge_hours"`
+	ClosedUnmerged      int     `json:"closed_unmerged"`
+	ClosedUnmergedShare float64 `json:"closed_unmerged_share"`
+}
+
+type NewcomerRepoReport struct {
+	Name        string          `json:"name"`
+	Newcomers   GroupExperience `json:"newcomers"`
+	Established GroupExperience `json:"established"`
+}
+
+// NewcomerReport compares how newcomers and established contributors fare,
+// per repository and across all of them.
+type NewcomerReport struct {
+	Repositories []NewcomerRepoReport `json:"repositories"`
+	Total        NewcomerRepoReport   `json:"total"`
+}
+
+// GetNewcomerItems returns the issues and pull requests opened on the
+// repository within the window, leaving out those opened by bots and ignored
+// users. Their authors are newcomers if GitHub says they are first-time
+// contributors, or, when a store is given, if they have no earlier activity in
+// it and GitHub does not know them as owners, members or collaborators.
+func GetNewcomerItems(client Client, repo Repository, clock Clock, window time.Duration, options ContactOptions, store *ContactStore) ([]NewcomerItem, error) {
+	since := clock.Now().UTC().Add(-window)
+	issues, err := repo.GetIssues(client, "all", since)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []NewcomerItem
+	for i := range issues {
+		issue := &issues[i]
+		if ignoredAuthor(issue.User.Login, issue.User.Type, options) {
+			continue
+		}
+
+		created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse creation time of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		if created.Before(since) {
+			continue
+		}
+
+		contact, err := repo.firstContact(client, issue, clock, options)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		contact.Issue = IssueRef{Repo: repo.Name, Number: issue.Number, PullRequest: issue.IsPullRequest()}
+
+		item := NewcomerItem{Contact: contact, Author: issue.User.Login, Closed: issue.State == "closed"}
+		item.Newcomer = contains(newcomerAssociations, issue.AuthorAssociation)
+		if !item.Newcomer && store != nil && !contains(coreAssociations, issue.AuthorAssociation) {
+			item.Newcomer = !store.HasActivityBefore(issue.User.Login, contact.CreatedAt)
+		}
+		if issue.PullRequest != nil && issue.PullRequest.MergedAt != "" {
+			item.MergedAt, err = time.Parse(time.RFC3339, issue.PullRequest.MergedAt)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse merge time of %s#%d: %s", repo.Name, issue.Number, err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// NewNewcomerReport groups the items by repository, ordered by name.
+func NewNewcomerReport(items []NewcomerItem) NewcomerReport {
+	byRepo := make(map[string][]NewcomerItem)
+	for _, item := range items {
+		byRepo[item.Contact.Issue.Repo] = append(byRepo[item.Contact.Issue.Repo], item)
+	}
+
+	report := NewcomerReport{Repositories: []NewcomerRepoReport{}, Total: newNewcomerRepoReport("total", items)}
+	for name, repoItems := range byRepo {
+		report.Repositories = append(report.Repositories, newNewcomerRepoReport(name, repoItems))
+	}
+	sort.Slice(report.Repositories, func(i, j int) bool { return report.Repositories[i].Name < report.Repositories[j].Name })
+	return report
+}
+
+func newNewcomerRepoReport(name string, items []NewcomerItem) NewcomerRepoReport {
+	var newcomers, established []NewcomerItem
+	for _, item := range items {
+		if item.Newcomer {
+			newcomers = append(newcomers, item)
+		} else {
+			established = append(established, item)
+		}
+	}
+	return NewcomerRepoReport{Name: name, Newcomers: newGroupExperience(newcomers), Established: newGroupExperience(established)}
+}
+
+func newGroupExperience(items []NewcomerItem) GroupExperience {
+	var group GroupExperience
+	var contacts, merges []float64
+	for _, item := range items {
+		contacts = append(contacts, item.Contact.Minutes())
+		if !item.Contact.Issue.PullRequest {
+			group.Issues++
+			continue
+		}
+
+		group.PullRequests++
+		switch {
+		case !item.MergedAt.IsZero():
+			group.Merged++
+			merges = append(merges, item.MergedAt.Sub(item.Contact.CreatedAt).Hours())
+		case item.Closed:
+			group.ClosedUnmerged++
+		}
+	}
+	group.MedianFirstContact = Median(contacts)
+	group.MedianTimeToMerge = Median(merges)
+	if group.PullRequests > 0 {
+		group.ClosedUnmergedShare = float64(group.ClosedUnmerged) / float64(group.PullRequests)
+	}
+	return group
+}
+
+func (r NewcomerReport) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tAUTHORS\tISSUES\tPULL REQUESTS\tMEDIAN FIRST CONTACT (MIN)\tMEDIAN TIME TO MERGE (H)\tCLOSED UNMERGED")
+		for _, repo := range append(r.Repositories, r.Total) {
+			writeGroupRow(table, repo.Name, "newcomers", repo.Newcomers)
+			writeGroupRow(table, repo.Name, "established", repo.Established)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func writeGroupRow(w io.Writer, name, authors string, group GroupExperience) {
+	fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%.0f\t%.0f\t%d (%.0f%%)\n", name, authors, group.Issues, group.PullRequests,
+		group.MedianFirstContact, group.MedianTimeToMerge, group.ClosedUnmerged, group.ClosedUnmergedShare*100)
+}
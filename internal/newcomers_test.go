@@ -0,0 +1,154 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testNewcomers(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+	})
+
+	context("NewNewcomerReport", func() {
+		item := func(number int, pullRequest, newcomer bool, minutes int, closed bool, mergedAfter time.Duration) NewcomerItem {
+			created := now.Add(-10 * 24 * time.Hour)
+			item := NewcomerItem{
+				Contact: FirstContact{
+					Issue:     IssueRef{Repo: "example-org/example-repo", Number: number, PullRequest: pullRequest},
+					CreatedAt: created,
+					Elapsed:   time.Duration(minutes) * time.Minute,
+				},
+				Newcomer: newcomer,
+				Closed:   closed,
+			}
+			if mergedAfter > 0 {
+				item.MergedAt = created.Add(mergedAfter)
+			}
+			return item
+		}
+
+		it("compares newcomers with established contributors", func() {
+			report := NewNewcomerReport([]NewcomerItem{
+				item(1, false, true, 600, false, 0),
+				item(2, true, true, 300, true, 0),
+				item(3, true, true, 120, true, 72*time.Hour),
+				item(4, true, false, 30, true, 4*time.Hour),
+				item(5, false, false, 60, false, 0),
+			})
+
+			Expect(report.Repositories).To(HaveLen(1))
+			Expect(report.Total.Newcomers).To(Equal(GroupExperience{
+				Issues: 1, PullRequests: 2, MedianFirstContact: 300,
+				Merged: 1, MedianTimeToMerge: 72, ClosedUnmerged: 1, ClosedUnmergedShare: 0.5,
+			}))
+			Expect(report.Total.Established).To(Equal(GroupExperience{
+				Issues: 1, PullRequests: 1, MedianFirstContact: 45,
+				Merged: 1, MedianTimeToMerge: 4,
+			}))
+		})
+
+		it("writes a text report", func() {
+			var output bytes.Buffer
+			report := NewNewcomerReport([]NewcomerItem{item(2, true, true, 300, true, 0)})
+			Expect(report.Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  newcomers    0       1              300                         0                         1 (100%)"))
+			Expect(output.String()).To(ContainSubstring("total                     established  0       0"))
+		})
+	})
+
+	context("GetNewcomerItems", func() {
+		var server *fakegithub.Server
+		var client APIClient
+		var clock *fakes.Clock
+
+		it.Before(func() {
+			fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server = fakegithub.NewServer(fixture)
+
+			client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+			Expect(err).NotTo(HaveOccurred())
+			clock = &fakes.Clock{}
+			clock.NowCall.Returns.Time = now
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		newcomers := func(items []NewcomerItem) []int {
+			numbers := []int{}
+			for _, item := range items {
+				if item.Newcomer {
+					numbers = append(numbers, item.Contact.Issue.Number)
+				}
+			}
+			return numbers
+		}
+
+		it("finds newcomers by their author association", func() {
+			items, err := GetNewcomerItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, ContactOptions{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(items).To(HaveLen(4))
+			Expect(newcomers(items)).To(ConsistOf(2, 5))
+
+			report := NewNewcomerReport(items)
+			Expect(report.Total.Newcomers.Merged).To(Equal(1))
+			Expect(report.Total.Newcomers.MedianTimeToMerge).To(Equal(48.0))
+			Expect(report.Total.Established.Issues).To(Equal(2))
+			Expect(report.Total.Established.MedianFirstContact).To(Equal((60.0 + 27*24*60) / 2))
+		})
+
+		it("counts reviews as first responses to pull requests", func() {
+			items, err := GetNewcomerItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, ContactOptions{}, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			var reviewed *NewcomerItem
+			for i := range items {
+				if items[i].Contact.Issue.Number == 5 {
+					reviewed = &items[i]
+				}
+			}
+			Expect(reviewed).NotTo(BeNil())
+			Expect(reviewed.Contact.Status).To(Equal(StatusResponded))
+			Expect(reviewed.Contact.Response).To(Equal(&Response{
+				At:    time.Date(2001, time.January, 11, 20, 20, 20, 0, time.UTC),
+				Actor: "other-maintainer",
+				Kind:  ResponseReview,
+			}))
+			Expect(reviewed.Contact.Elapsed).To(Equal(24 * time.Hour))
+		})
+
+		it("counts authors without earlier activity in the store as newcomers", func() {
+			store, err := NewContactStore("")
+			Expect(err).NotTo(HaveOccurred())
+
+			items, err := GetNewcomerItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, ContactOptions{}, store)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newcomers(items)).To(ConsistOf(1, 2, 3, 5))
+
+			Expect(store.Track(TrackedIssue{
+				Issue:     IssueRef{Repo: "example-org/other-repo", Number: 1},
+				CreatedAt: time.Date(2000, time.December, 1, 0, 0, 0, 0, time.UTC),
+				Author:    "originalPoster",
+			})).To(Succeed())
+			items, err = GetNewcomerItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, ContactOptions{}, store)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(newcomers(items)).To(ConsistOf(2, 5))
+		})
+	})
+}
@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// RepoFilter selects which of an organization's repositories are measured.
+//
+// Names, Topics and Languages narrow the selection: when a list is set, a
+// repository must match at least one of its entries. Exclude, ExcludeTopics
+// and ExcludeLanguages drop any repository matching one of their entries.
+// Name patterns are globs matched against the full name (owner/repo), or
+// against the repository name alone when the pattern has no slash.
+//
+// Archived repositories, forks and templates are left out unless the
+// corresponding flag is set.
+type RepoFilter struct {
+	Names            []string      `yaml:"names"`
+	Exclude          []string      `yaml:"exclude"`
+	Topics           []string      `yaml:"topics"`
+	ExcludeTopics    []string      `yaml:"exclude_topics"`
+	Languages        []string      `yaml:"languages"`
+	ExcludeLanguages []string      `yaml:"exclude_languages"`
+	Archived         bool          `yaml:"archived"`
+	Forks            bool          `yaml:"forks"`
+	Templates        bool          `yaml:"templates"`
+	Visibility       string        `yaml:"visibility"`
+	PushedWithin     time.Duration `yaml:"pushed_within"`
+}
+
+var visibilities = []string{"", "all", "public", "private"}
+
+func (f RepoFilter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Names...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("%q is not a valid name pattern", pattern)
+		}
+	}
+	if !contains(visibilities, f.Visibility) {
+		return fmt.Errorf("visibility %q is not one of all, public, private", f.Visibility)
+	}
+	if f.PushedWithin < 0 {
+		return fmt.Errorf("pushed_within must not be negative, got %s", f.PushedWithin)
+	}
+	return nil
+}
+
+// Matches reports whether repo passes the filter. now is only used to
+// evaluate PushedWithin.
+func (f RepoFilter) Matches(repo Repository, now time.Time) bool {
+	if repo.Archived && !f.Archived {
+		return false
+	}
+	if repo.Fork && !f.Forks {
+		return false
+	}
+	if repo.IsTemplate && !f.Templates {
+		return false
+	}
+	switch f.Visibility {
+	case "public":
+		if repo.Private {
+			return false
+		}
+	case "private":
+		if !repo.Private {
+			return false
+		}
+	}
+
+	if len(f.Names) > 0 && !matchesName(f.Names, repo.Name) {
+		return false
+	}
+	if matchesName(f.Exclude, repo.Name) {
+		return false
+	}
+	if len(f.Topics) > 0 && !overlaps(f.Topics, repo.Topics) {
+		return false
+	}
+	if overlaps(f.ExcludeTopics, repo.Topics) {
+		return false
+	}
+	if len(f.Languages) > 0 && !overlaps(f.Languages, []string{repo.Language}) {
+		return false
+	}
+	if overlaps(f.ExcludeLanguages, []string{repo.Language}) {
+		return false
+	}
+
+	if f.PushedWithin > 0 {
+		pushedAt, err := time.Parse(time.RFC3339, repo.PushedAt)
+		if err != nil || now.Sub(pushedAt) > f.PushedWithin {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesName(patterns []string, fullName string) bool {
+	fullName = strings.ToLower(fullName)
+	shortName := fullName[strings.LastIndex(fullName, "/")+1:]
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		name := fullName
+		if !strings.Contains(pattern, "/") {
+			name = shortName
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// overlaps reports whether the lists share an entry, ignoring case.
+func overlaps(list, values []string) bool {
+	for _, item := range list {
+		for _, value := range values {
+			if strings.EqualFold(item, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
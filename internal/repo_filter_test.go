@@ -0,0 +1,142 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testRepoFilter(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var filter RepoFilter
+	var repo Repository
+	var now = time.Date(2001, time.February, 1, 0, 0, 0, 0, time.UTC)
+
+	it.Before(func() {
+		filter = RepoFilter{}
+		repo = Repository{
+			Name:     "example-org/example-repo",
+			Topics:   []string{"buildpacks", "go"},
+			Language: "Go",
+			PushedAt: "2001-01-01T00:00:00Z",
+		}
+	})
+
+	context("Matches", func() {
+		it("matches an active repository by default", func() {
+			Expect(filter.Matches(repo, now)).To(BeTrue())
+		})
+
+		context("when the repository is archived, a fork or a template", func() {
+			it("does not match unless those are included", func() {
+				repo.Archived = true
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+				filter.Archived = true
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				repo.Fork = true
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+				filter.Forks = true
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				repo.IsTemplate = true
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+				filter.Templates = true
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+			})
+		})
+
+		context("when a visibility is set", func() {
+			it("only matches repositories with that visibility", func() {
+				filter.Visibility = "private"
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+
+				repo.Private = true
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.Visibility = "public"
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+		})
+
+		context("when name patterns are set", func() {
+			it("matches full names and repository names", func() {
+				filter.Names = []string{"example-org/example-*"}
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.Names = []string{"Example-*"}
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.Names = []string{"other-org/*"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+
+			it("excludes matching repositories", func() {
+				filter.Exclude = []string{"*-repo"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+		})
+
+		context("when topics are set", func() {
+			it("requires one of the topics", func() {
+				filter.Topics = []string{"rust", "go"}
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.Topics = []string{"rust"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+
+			it("excludes repositories with an excluded topic", func() {
+				filter.ExcludeTopics = []string{"Buildpacks"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+		})
+
+		context("when languages are set", func() {
+			it("requires one of the languages", func() {
+				filter.Languages = []string{"go"}
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.Languages = []string{"Rust"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+
+			it("excludes repositories in an excluded language", func() {
+				filter.ExcludeLanguages = []string{"Go"}
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+		})
+
+		context("when PushedWithin is set", func() {
+			it("only matches repositories pushed to recently", func() {
+				filter.PushedWithin = 31 * 24 * time.Hour
+				Expect(filter.Matches(repo, now)).To(BeTrue())
+
+				filter.PushedWithin = 30 * 24 * time.Hour
+				Expect(filter.Matches(repo, now)).To(BeFalse())
+			})
+		})
+	})
+
+	context("Validate", func() {
+		it("accepts the zero value", func() {
+			Expect(filter.Validate()).To(Succeed())
+		})
+
+		context("failure cases", func() {
+			it("rejects malformed name patterns", func() {
+				filter.Exclude = []string{"example-org/["}
+				Expect(filter.Validate()).To(MatchError(`"example-org/[" is not a valid name pattern`))
+			})
+
+			it("rejects a negative PushedWithin", func() {
+				filter.PushedWithin = -time.Hour
+				Expect(filter.Validate()).To(MatchError("pushed_within must not be negative, got -1h0m0s"))
+			})
+		})
+	})
+}
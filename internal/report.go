@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+type Report struct {
+	Repositories []RepoReport       `json:"repositories"`
+	Responders   []ResponderReport  `json:"responders,omitempty"`
+	Discussions  []DiscussionReport `json:"discussions,omitempty"`
+	Errors       *ErrorSummary      `json:"errors,omitempty"`
+}
+
+type RepoReport struct {
+	Name               string            `json:"name"`
+	Issues             int               `json:"issues"`
+	Failed             int               `json:"failed,omitempty"`
+	MedianFirstContact float64           `json:"median_first_contact_minutes"`
+	Segments           []SegmentReport   `json:"segments,omitempty"`
+	Responders         []ResponderReport `json:"responders,omitempty"`
+}
+
+type SegmentReport struct {
+	Name               string  `json:"name"`
+	Issues             int     `json:"issues"`
+	MedianFirstContact float64 `json:"median_first_contact_minutes"`
+}
+
+// ReportOptions controls which breakdowns a report includes. When Errors
+// lets runs continue past issues that cannot be measured, the report
+// summarizes those issues.
+type ReportOptions struct {
+	Segments   Segmentation
+	Responders bool
+	ShowLogins bool
+	Errors     ErrorPolicy
+}
+
+// RepoResults holds the first contact times measured for one repository, and
+// the issues that could not be measured.
+type RepoResults struct {
+	Name    string
+	Results []FirstContact
+	Errors  []IssueError
+}
+
+// NewReport summarizes the results of every repository, ordered by name, and
+// when asked for adds the responder breakdown across all of them.
+func NewReport(repos []RepoResults, options ReportOptions) Report {
+	report := Report{Repositories: []RepoReport{}}
+	var all []FirstContact
+	var failures []IssueError
+	for _, repo := range repos {
+		repoReport := NewRepoReport(repo.Name, repo.Results, options)
+		repoReport.Failed = len(repo.Errors)
+		report.Repositories = append(report.Repositories, repoReport)
+		all = append(all, repo.Results...)
+		failures = append(failures, repo.Errors...)
+	}
+	sort.Slice(report.Repositories, func(i, j int) bool { return report.Repositories[i].Name < report.Repositories[j].Name })
+
+	if options.Errors.Continue {
+		summary := NewErrorSummary(failures, len(all)+len(failures), options.Errors.Budget)
+		report.Errors = &summary
+	}
+
+	if options.Responders {
+		report.Responders = NewResponderReports(all)
+		if !options.ShowLogins {
+			report.anonymizeResponders()
+		}
+	}
+	return report
+}
+
+// NewRepoReport summarizes the first contact times of a repository, broken
+// down into segments and responders as the options ask.
+func NewRepoReport(name string, results []FirstContact, options ReportOptions) RepoReport {
+	var times []float64
+	segmentTimes := make(map[string][]float64)
+	for _, result := range results {
+		times = append(times, result.Minutes())
+		if options.Segments.Enabled() {
+			for _, segment := range options.Segments.Segments(result.Labels) {
+				segmentTimes[segment] = append(segmentTimes[segment], result.Minutes())
+			}
+		}
+	}
+
+	report := RepoReport{
+		Name:               name,
+		Issues:             len(times),
+		MedianFirstContact: Median(times),
+	}
+	for segment, times := range segmentTimes {
+		report.Segments = append(report.Segments, SegmentReport{
+			Name:               segment,
+			Issues:             len(times),
+			MedianFirstContact: Median(times),
+		})
+	}
+	sort.Slice(report.Segments, func(i, j int) bool { return report.Segments[i].Name < report.Segments[j].Name })
+
+	if options.Responders {
+		report.Responders = NewResponderReports(results)
+	}
+	return report
+}
+
+// Median returns the median of values, or 0 if there are none.
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tISSUES\tMEDIAN FIRST CONTACT (MIN)")
+		for _, repo := range r.Repositories {
+			fmt.Fprintf(table, "%s\t%d\t%.0f\n", repo.Name, repo.Issues, repo.MedianFirstContact)
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+		err = r.writeSegments(w)
+		if err != nil {
+			return err
+		}
+		err = r.writeResponders(w)
+		if err != nil {
+			return err
+		}
+		err = r.writeDiscussions(w)
+		if err != nil {
+			return err
+		}
+		return r.writeErrors(w)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+func (r Report) writeSegments(w io.Writer) error {
+	segmented := false
+	for _, repo := range r.Repositories {
+		segmented = segmented || len(repo.Segments) > 0
+	}
+	if !segmented {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "REPOSITORY\tSEGMENT\tISSUES\tMEDIAN FIRST CONTACT (MIN)")
+	for _, repo := range r.Repositories {
+		for _, segment := range repo.Segments {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%.0f\n", repo.Name, segment.Name, segment.Issues, segment.MedianFirstContact)
+		}
+	}
+	return table.Flush()
+}
+
+func (r Report) writeResponders(w io.Writer) error {
+	if len(r.Responders) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "REPOSITORY\tRESPONDER\tFIRST CONTACTS\tSHARE\tMEDIAN FIRST CONTACT (MIN)")
+	for _, repo := range r.Repositories {
+		for _, responder := range repo.Responders {
+			fmt.Fprintf(table, "%s\t%s\t%d\t%.0f%%\t%.0f\n", repo.Name, responder.Login, responder.Issues, responder.Share*100, responder.MedianFirstContact)
+		}
+	}
+	for _, responder := range r.Responders {
+		fmt.Fprintf(table, "%s\t%s\t%d\t%.0f%%\t%.0f\n", "(all)", responder.Login, responder.Issues, responder.Share*100, responder.MedianFirstContact)
+	}
+	return table.Flush()
+}
+
+func (r Report) writeDiscussions(w io.Writer) error {
+	if len(r.Discussions) == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "REPOSITORY\tDISCUSSIONS\tREPLIED\tMEDIAN FIRST REPLY (MIN)\tANSWERED\tMEDIAN TIME TO ANSWER (H)")
+	for _, repo := range r.Discussions {
+		fmt.Fprintf(table, "%s\t%d\t%d\t%.0f\t%d of %d\t%.0f\n", repo.Name, repo.Discussions, repo.Replied,
+			repo.MedianFirstReply, repo.Answered, repo.Answerable, repo.MedianTimeToAnswer)
+	}
+	return table.Flush()
+}
+
+func (r Report) writeErrors(w io.Writer) error {
+	if r.Errors == nil || r.Errors.Failed == 0 {
+		return nil
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d of %d issues could not be measured (%.1f%%, budget %.1f%%)\n",
+		r.Errors.Failed, r.Errors.Issues, r.Errors.Rate*100, r.Errors.Budget*100)
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "REPOSITORY\tISSUE\tERROR")
+	for _, failure := range r.Errors.Failures {
+		issue := "-"
+		if failure.MergeRequest {
+			issue = fmt.Sprintf("!%d", failure.Number)
+		} else if failure.Number != 0 {
+			issue = fmt.Sprintf("#%d", failure.Number)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\n", failure.Repo, issue, failure.Error)
+	}
+	return table.Flush()
+}
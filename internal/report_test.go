@@ -0,0 +1,226 @@
+package internal_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testReport(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var report Report
+	var buffer *bytes.Buffer
+
+	it.Before(func() {
+		buffer = bytes.NewBuffer(nil)
+		report = Report{Repositories: []RepoReport{
+			NewRepoReport("example-org/example-repo", []FirstContact{contactAfter(60, ""), contactAfter(10, ""), contactAfter(30, ""), contactAfter(20, "")}, ReportOptions{}),
+		}}
+	})
+
+	context("Median", func() {
+		it("returns the middle value of an odd number of values", func() {
+			Expect(Median([]float64{3, 1, 2})).To(Equal(2.0))
+		})
+
+		it("returns the mean of the two middle values of an even number of values", func() {
+			Expect(Median([]float64{4, 1, 3, 2})).To(Equal(2.5))
+		})
+
+		it("returns 0 when there are no values", func() {
+			Expect(Median(nil)).To(Equal(0.0))
+		})
+	})
+
+	context("NewRepoReport", func() {
+		var results []FirstContact
+
+		it.Before(func() {
+			results = []FirstContact{
+				{Elapsed: 10 * time.Minute, Labels: []string{"bug"}, Status: StatusAwaiting},
+				{Elapsed: 20 * time.Minute, Labels: []string{"kind/bug", "question"}, Status: StatusAwaiting},
+				{Elapsed: 30 * time.Minute, Labels: []string{"question"}, Status: StatusAwaiting},
+				contactAfter(40, ""),
+			}
+		})
+
+		it("does not segment the results by default", func() {
+			report := NewRepoReport("example-org/example-repo", results, ReportOptions{})
+			Expect(report.Issues).To(Equal(4))
+			Expect(report.MedianFirstContact).To(Equal(25.0))
+			Expect(report.Segments).To(BeEmpty())
+		})
+
+		context("when segmenting by category", func() {
+			it("reports each category and the unmatched issues", func() {
+				report := NewRepoReport("example-org/example-repo", results, ReportOptions{Segments: Segmentation{
+					Categories: map[string][]string{"bugs": {"bug", "kind/bug"}, "questions": {"question"}},
+				}})
+				Expect(report.Segments).To(Equal([]SegmentReport{
+					{Name: "bugs", Issues: 2, MedianFirstContact: 15},
+					{Name: "questions", Issues: 2, MedianFirstContact: 25},
+					{Name: "unmatched", Issues: 1, MedianFirstContact: 40},
+				}))
+			})
+		})
+	})
+
+	context("NewReport", func() {
+		var repos []RepoResults
+
+		it.Before(func() {
+			repos = []RepoResults{
+				{Name: "example-org/z-repo", Results: []FirstContact{contactAfter(10, "alice"), contactAfter(20, "bob")}},
+				{Name: "example-org/a-repo", Results: []FirstContact{contactAfter(30, "bob"), contactAfter(40, "")}},
+			}
+		})
+
+		it("reports every repository ordered by name", func() {
+			report := NewReport(repos, ReportOptions{})
+			Expect(report.Repositories).To(HaveLen(2))
+			Expect(report.Repositories[0].Name).To(Equal("example-org/a-repo"))
+			Expect(report.Repositories[1].Name).To(Equal("example-org/z-repo"))
+			Expect(report.Responders).To(BeEmpty())
+		})
+
+		context("when the responder breakdown is on", func() {
+			it("reports responders per repository and across repositories", func() {
+				report := NewReport(repos, ReportOptions{Responders: true, ShowLogins: true})
+				Expect(report.Repositories[0].Responders).To(Equal([]ResponderReport{
+					{Login: "bob", Issues: 1, Share: 1, MedianFirstContact: 30},
+				}))
+				Expect(report.Responders).To(Equal([]ResponderReport{
+					{Login: "bob", Issues: 2, Share: 2.0 / 3, MedianFirstContact: 25},
+					{Login: "alice", Issues: 1, Share: 1.0 / 3, MedianFirstContact: 10},
+				}))
+			})
+
+			context("when logins are not shown", func() {
+				it("uses the same pseudonym for a responder everywhere", func() {
+					report := NewReport(repos, ReportOptions{Responders: true})
+					Expect(report.Responders[0].Login).To(Equal("responder-1"))
+					Expect(report.Responders[1].Login).To(Equal("responder-2"))
+					Expect(report.Repositories[0].Responders[0].Login).To(Equal("responder-1"))
+					Expect(report.Repositories[1].Responders[0].Login).To(Equal("responder-2"))
+					Expect(report.Repositories[1].Responders[1].Login).To(Equal("responder-1"))
+				})
+			})
+		})
+
+		context("when runs continue past errors", func() {
+			it.Before(func() {
+				repos[0].Errors = []IssueError{{Repo: "example-org/z-repo", Number: 7, Err: errors.New("some error")}}
+			})
+
+			it("counts each repository's failures and summarizes them", func() {
+				report := NewReport(repos, ReportOptions{Errors: ErrorPolicy{Continue: true, Budget: 0.5}})
+				Expect(report.Repositories[1].Failed).To(Equal(1))
+				Expect(report.Errors).To(Equal(&ErrorSummary{
+					Issues:   5,
+					Failed:   1,
+					Rate:     0.2,
+					Budget:   0.5,
+					Failures: []IssueErrorReport{{Repo: "example-org/z-repo", Number: 7, Error: "some error"}},
+				}))
+			})
+		})
+	})
+
+	context("Write", func() {
+		context("when the format is text", func() {
+			it("writes a table", func() {
+				Expect(report.Write(buffer, "text")).To(Succeed())
+				Expect(buffer.String()).To(Equal(
+					"REPOSITORY                ISSUES  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  4       25\n"))
+			})
+		})
+
+		context("when the report is segmented", func() {
+			it.Before(func() {
+				report.Repositories[0].Segments = []SegmentReport{{Name: "bug", Issues: 1, MedianFirstContact: 10}}
+			})
+
+			it("writes a table of segments after the repositories", func() {
+				Expect(report.Write(buffer, "text")).To(Succeed())
+				Expect(buffer.String()).To(Equal(
+					"REPOSITORY                ISSUES  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  4       25\n" +
+						"\n" +
+						"REPOSITORY                SEGMENT  ISSUES  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  bug      1       10\n"))
+			})
+		})
+
+		context("when the report has responders", func() {
+			it.Before(func() {
+				report.Repositories[0].Responders = []ResponderReport{{Login: "alice", Issues: 4, Share: 1, MedianFirstContact: 25}}
+				report.Responders = []ResponderReport{{Login: "alice", Issues: 4, Share: 1, MedianFirstContact: 25}}
+			})
+
+			it("writes a table of responders after the repositories", func() {
+				Expect(report.Write(buffer, "text")).To(Succeed())
+				Expect(buffer.String()).To(Equal(
+					"REPOSITORY                ISSUES  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  4       25\n" +
+						"\n" +
+						"REPOSITORY                RESPONDER  FIRST CONTACTS  SHARE  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  alice      4               100%   25\n" +
+						"(all)                     alice      4               100%   25\n"))
+			})
+		})
+
+		context("when some issues could not be measured", func() {
+			it.Before(func() {
+				report.Errors = &ErrorSummary{
+					Issues:   5,
+					Failed:   1,
+					Rate:     0.2,
+					Budget:   0.1,
+					Exceeded: true,
+					Failures: []IssueErrorReport{
+						{Repo: "example-org/example-repo", Number: 7, Error: "could not get first response: not found"},
+						{Repo: "example-org/other-repo", Error: "getting recent issues: not found"},
+					},
+				}
+			})
+
+			it("lists them after the repositories", func() {
+				Expect(report.Write(buffer, "text")).To(Succeed())
+				Expect(buffer.String()).To(Equal(
+					"REPOSITORY                ISSUES  MEDIAN FIRST CONTACT (MIN)\n" +
+						"example-org/example-repo  4       25\n" +
+						"\n" +
+						"1 of 5 issues could not be measured (20.0%, budget 10.0%)\n" +
+						"REPOSITORY                ISSUE  ERROR\n" +
+						"example-org/example-repo  #7     could not get first response: not found\n" +
+						"example-org/other-repo    -      getting recent issues: not found\n"))
+			})
+		})
+
+		context("when the format is json", func() {
+			it("writes JSON", func() {
+				Expect(report.Write(buffer, "json")).To(Succeed())
+				Expect(buffer.String()).To(MatchJSON(`{
+  "repositories": [
+    {"name": "example-org/example-repo", "issues": 4, "median_first_contact_minutes": 25}
+  ]
+}`))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the format is unknown", func() {
+				it("returns the error", func() {
+					Expect(report.Write(buffer, "xml")).To(MatchError(`unknown output format "xml"`))
+				})
+			})
+		})
+	})
+}
@@ -2,9 +2,10 @@ package internal
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
-	"strings"
+	"net/http"
+	"net/url"
 	"time"
 )
 
@@ -19,6 +20,15 @@ type Repository struct {
 	Owner struct {
 		Login string `json:"login"`
 	} `json:"owner"`
+	Archived   bool     `json:"archived"`
+	Fork       bool     `json:"fork"`
+	IsTemplate bool     `json:"is_template"`
+	Private    bool     `json:"private"`
+	Topics     []string `json:"topics"`
+	Language   string   `json:"language"`
+	PushedAt   string   `json:"pushed_at"`
+
+	DefaultBranch string `json:"default_branch"`
 }
 
 type Organization struct {
@@ -30,73 +40,349 @@ type Clock interface {
 	Now() time.Time
 }
 
-// TODO change to Client and not APIClient
-func (o *Organization) GetRepos(client APIClient) ([]Repository, error) {
-	body, err := client.Get(fmt.Sprintf("orgs/%s/repos", o.Name), "per_page=100")
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always reports the same time, e.g. while a run is recorded or
+// replayed.
+type FixedClock time.Time
+
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+// GetRepos returns the organization's repositories that pass the filter.
+func (o *Organization) GetRepos(client Client, clock Clock, filter RepoFilter) ([]Repository, error) {
+	repos := []Repository{}
+	err := getAllPages(client, fmt.Sprintf("orgs/%s/repos", o.Name), func(body []byte) (int, error) {
+		page := []Repository{}
+		err := json.Unmarshal(body, &page)
+		repos = append(repos, page...)
+		return len(page), err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed getting org repos: %s", err)
 	}
 
-	repos := []Repository{}
-	err = json.Unmarshal(body, &repos)
-	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal response: %s\n  : %s", string(body), err)
+	now := clock.Now().UTC()
+	selected := []Repository{}
+	for _, repo := range repos {
+		if filter.Matches(repo, now) {
+			selected = append(selected, repo)
+		}
 	}
-	return repos, nil
+	return selected, nil
+}
+
+// ContactOptions controls which issues and replies count towards first
+// contact times, how the time until first contact is measured, and whether
+// an issue that cannot be measured stops the rest. Logger, if set, is told
+// why issues are skipped and who replied to the rest.
+type ContactOptions struct {
+	IgnoredUsers    []string
+	Bots            BotPolicy
+	BusinessHours   BusinessHours
+	ContinueOnError bool
+	Logger          *Logger
 }
 
-func (r *Repository) GetRecentIssues(client Client, clock Clock) ([]Issue, error) {
-	timeString := clock.Now().UTC().Add(-30 * 24 * time.Hour).Format(time.RFC3339)
+func (r *Repository) GetRecentIssues(client Client, clock Clock, window time.Duration) ([]Issue, error) {
+	timeString := clock.Now().UTC().Add(-window).Format(time.RFC3339)
 
-	body, err := client.Get(fmt.Sprintf("/repos/%s/issues", r.Name),
-		"per_page=100",
-		fmt.Sprintf("since=%s", timeString))
+	issues := []Issue{}
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/issues", r.Name), func(body []byte) (int, error) {
+		page := []Issue{}
+		err := json.Unmarshal(body, &page)
+		issues = append(issues, page...)
+		return len(page), err
+	}, fmt.Sprintf("since=%s", timeString))
 	if err != nil {
 		return nil, fmt.Errorf("getting recent issues: %s", err)
 	}
+	return issues, nil
+}
+
+// GetIssues returns every issue and pull request of the repository in state
+// ("open", "closed" or "all") that was updated since since, or all of them if
+// since is zero.
+func (r *Repository) GetIssues(client Client, state string, since time.Time) ([]Issue, error) {
+	params := []string{fmt.Sprintf("state=%s", state)}
+	if !since.IsZero() {
+		params = append(params, fmt.Sprintf("since=%s", since.UTC().Format(time.RFC3339)))
+	}
 
 	issues := []Issue{}
-	err = json.Unmarshal(body, &issues)
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/issues", r.Name), func(body []byte) (int, error) {
+		page := []Issue{}
+		err := json.Unmarshal(body, &page)
+		issues = append(issues, page...)
+		return len(page), err
+	}, params...)
 	if err != nil {
-		return nil, fmt.Errorf("getting recent issues: could not unmarshal JSON '%s' : %s", string(body), err)
+		return nil, fmt.Errorf("getting issues: %s", err)
 	}
 	return issues, nil
 }
 
-func (r *Repository) GetFirstContactTimes(client Client, issues []CommentGetter, clock Clock, output chan TimeContainer) {
+// GetLabeledIssues returns the repository's issues, leaving out pull
+// requests, with any of the labels that are open or were updated since since.
+// Issues with several of the labels are returned once.
+func (r *Repository) GetLabeledIssues(client Client, labels []string, since time.Time) ([]Issue, error) {
+	seen := make(map[int]struct{})
+	issues := []Issue{}
+	for _, label := range labels {
+		for _, params := range [][]string{
+			{"state=open", fmt.Sprintf("labels=%s", url.QueryEscape(label))},
+			{"state=all", fmt.Sprintf("labels=%s", url.QueryEscape(label)), fmt.Sprintf("since=%s", since.UTC().Format(time.RFC3339))},
+		} {
+			err := getAllPages(client, fmt.Sprintf("/repos/%s/issues", r.Name), func(body []byte) (int, error) {
+				page := []Issue{}
+				err := json.Unmarshal(body, &page)
+				for _, issue := range page {
+					if _, duplicate := seen[issue.Number]; !duplicate && !issue.IsPullRequest() {
+						seen[issue.Number] = struct{}{}
+						issues = append(issues, issue)
+					}
+				}
+				return len(page), err
+			}, params...)
+			if err != nil {
+				return nil, fmt.Errorf("getting issues labeled %q: %s", label, err)
+			}
+		}
+	}
+	return issues, nil
+}
+
+// GetCommits returns the commits on the repository's default branch since
+// since, newest first. An empty repository has none.
+func (r *Repository) GetCommits(client Client, since time.Time) ([]Commit, error) {
+	params := []string{fmt.Sprintf("since=%s", since.UTC().Format(time.RFC3339))}
+	if r.DefaultBranch != "" {
+		params = append([]string{fmt.Sprintf("sha=%s", r.DefaultBranch)}, params...)
+	}
+
+	commits := []Commit{}
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/commits", r.Name), func(body []byte) (int, error) {
+		page := []Commit{}
+		err := json.Unmarshal(body, &page)
+		commits = append(commits, page...)
+		return len(page), err
+	}, params...)
+	if emptyRepository(err) {
+		return []Commit{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting commits: %s", err)
+	}
+	return commits, nil
+}
+
+// GetLastCommit returns the latest commit reachable from ref, a branch or tag,
+// or from the default branch if ref is empty. It returns nil if there are no
+// commits at all.
+func (r *Repository) GetLastCommit(client Client, ref string) (*Commit, error) {
+	if ref == "" {
+		ref = r.DefaultBranch
+	}
+	params := []string{"per_page=1"}
+	if ref != "" {
+		params = append(params, fmt.Sprintf("sha=%s", ref))
+	}
+
+	body, err := client.Get(fmt.Sprintf("/repos/%s/commits", r.Name), params...)
+	if emptyRepository(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("getting last commit: %s", err)
+	}
+	commits := []Commit{}
+	err = json.Unmarshal(body, &commits)
+	if err != nil {
+		return nil, fmt.Errorf("getting last commit: could not unmarshal JSON '%s' : %s", string(body), err)
+	}
+	if len(commits) == 0 {
+		return nil, nil
+	}
+	return &commits[0], nil
+}
+
+// GetReleases returns the repository's releases published since since,
+// leaving out drafts. Releases are listed newest first, so paging stops at the
+// first one published before since.
+func (r *Repository) GetReleases(client Client, since time.Time) ([]Release, error) {
+	published := []Release{}
+	var parseErr error
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/releases", r.Name), func(body []byte) (int, error) {
+		page := []Release{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return 0, err
+		}
+		for _, release := range page {
+			if release.Draft || release.PublishedAt == "" {
+				continue
+			}
+			at, err := time.Parse(time.RFC3339, release.PublishedAt)
+			if err != nil {
+				parseErr = fmt.Errorf("could not parse publish time of %s %s: %s", r.Name, release.TagName, err)
+				return 0, nil
+			}
+			if at.Before(since) {
+				return 0, nil
+			}
+			published = append(published, release)
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting releases: %s", err)
+	}
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return published, nil
+}
+
+// GetTags returns the repository's tags whose commits were made since since,
+// as releases published when their commit was. Tags carry no dates of their
+// own, so each tag's commit is fetched, one request per tag. Tags are listed
+// by name, which says nothing about their age, so every tag is looked at.
+func (r *Repository) GetTags(client Client, since time.Time) ([]Release, error) {
+	releases := []Release{}
+	var commitErr error
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/tags", r.Name), func(body []byte) (int, error) {
+		page := []Tag{}
+		err := json.Unmarshal(body, &page)
+		if err != nil {
+			return 0, err
+		}
+		for _, tag := range page {
+			commit, err := r.GetLastCommit(client, tag.Name)
+			if err != nil {
+				commitErr = fmt.Errorf("getting commit of tag %s: %s", tag.Name, err)
+				return 0, nil
+			}
+			if commit == nil {
+				continue
+			}
+			at, err := commit.Time()
+			if err != nil {
+				commitErr = err
+				return 0, nil
+			}
+			if at.Before(since) {
+				continue
+			}
+			releases = append(releases, Release{TagName: tag.Name, PublishedAt: commit.Commit.Committer.Date})
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting tags: %s", err)
+	}
+	if commitErr != nil {
+		return nil, commitErr
+	}
+	return releases, nil
+}
+
+// emptyRepository reports whether err is the error GitHub answers requests
+// for the commits of a repository without any with, a 409 Conflict.
+func emptyRepository(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict &&
+		statusErr.Message() == "Git Repository is empty."
+}
+
+// getAllPages requests path a page of 100 items at a time and hands each
+// page's body to read, which returns how many items it held, until a page
+// comes back short. read stops the paging early by returning 0.
+func getAllPages(client Client, path string, read func(body []byte) (int, error), params ...string) error {
+	for page := 1; ; page++ {
+		body, err := client.Get(path, append([]string{"per_page=100"}, append(params, fmt.Sprintf("page=%d", page))...)...)
+		if err != nil {
+			return err
+		}
+
+		count, err := read(body)
+		if err != nil {
+			return fmt.Errorf("could not unmarshal JSON '%s' : %s", string(body), err)
+		}
+		if count < 100 {
+			return nil
+		}
+	}
+}
+
+// GetFirstContactTimes measures each issue's time to first contact and sends
+// it to output, closing output when done. An issue that cannot be measured is
+// sent with StatusFailed and its error, and ends the run unless
+// options.ContinueOnError is set.
+func (r *Repository) GetFirstContactTimes(client Client, issues []CommentGetter, clock Clock, options ContactOptions, output chan FirstContact) {
 	defer close(output)
 
 	for _, issue := range issues {
-		// TODO: add the option to ignore issues by User type Bot
-		// TODO: add the option to ignore issues created by a specific set of users
-		if strings.Contains(issue.GetUserLogin(), "bot") {
+		ref := IssueRef{Repo: r.Name, Number: issue.GetNumber(), PullRequest: issue.IsPullRequest(), MergeRequest: issue.IsMergeRequest()}
+
+		if ignoredAuthor(issue.GetUserLogin(), issue.GetUserType(), options) {
+			options.Logger.Debug("skipping issue opened by an ignored user or bot", "issue", ref, "author", issue.GetUserLogin())
 			continue
 		}
-		// TODO: pass a set of ignored users here
-		comment, err := issue.GetFirstReply(client)
 
+		contact, err := r.firstContact(client, issue, clock, options)
+		contact.Issue = ref
 		if err != nil {
-			output <- TimeContainer{Error: fmt.Errorf("could not get first reply: %s", err)}
-			return
-		}
-		// TODO: decide whether to actually include issues without comments on them
-		var replyCreated time.Time
-		if comment.CreatedAt == "" {
-			replyCreated = clock.Now().UTC()
-		} else {
-			replyCreated, err = time.Parse(time.RFC3339, comment.CreatedAt)
-			if err != nil {
-				output <- TimeContainer{Error: fmt.Errorf("could not parse first reply time: %s", err)}
+			contact.Status = StatusFailed
+			contact.Error = err
+			output <- contact
+			if !options.ContinueOnError {
 				return
 			}
+			options.Logger.Warn("could not measure issue", "issue", ref, "error", err)
+			continue
 		}
 
-		issueCreated, err := time.Parse(time.RFC3339, issue.GetCreatedAt())
+		options.Logger.Debug("measured first contact", "issue", ref, "author", issue.GetUserLogin(),
+			"status", string(contact.Status), "responder", contact.Responder(), "kind", contact.ResponseKind(), "elapsed", contact.Elapsed)
+		output <- contact
+	}
+}
+
+func (r *Repository) firstContact(client Client, issue CommentGetter, clock Clock, options ContactOptions) (FirstContact, error) {
+	contact := FirstContact{Labels: issue.GetLabels()}
+
+	response, err := issue.GetFirstResponse(client, options.IgnoredUsers...)
+	if err != nil {
+		return contact, fmt.Errorf("could not get first response: %s", err)
+	}
+
+	// TODO: decide whether to actually include issues without comments on them
+	var replyCreated time.Time
+	if response == nil {
+		replyCreated = clock.Now().UTC()
+		contact.Status = StatusAwaiting
+	} else {
+		replyCreated = response.At
+		contact.Status = StatusResponded
+		contact.Response = response
+	}
+
+	contact.CreatedAt, err = time.Parse(time.RFC3339, issue.GetCreatedAt())
+	if err != nil {
+		return contact, fmt.Errorf("could not parse issue creation time: %s", err)
+	}
+
+	contact.Elapsed = replyCreated.Sub(contact.CreatedAt)
+	if options.BusinessHours.Enabled() {
+		contact.Elapsed, err = options.BusinessHours.Elapsed(contact.CreatedAt, replyCreated)
 		if err != nil {
-			output <- TimeContainer{Error: fmt.Errorf("could not parse issue creation time: %s", err)}
-			return
+			return contact, fmt.Errorf("could not measure business hours: %s", err)
 		}
-		replyTime := math.Round(replyCreated.Sub(issueCreated).Minutes())
-		output <- TimeContainer{Time: replyTime, Error: nil}
 	}
+	return contact, nil
 }
@@ -1,7 +1,9 @@
 package internal_test
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +25,84 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 	repo = Repository{
 		Name: "example-org/example-repo",
 	}
+	context("GetRepos", func() {
+		var org Organization
+
+		it.Before(func() {
+			org = Organization{Name: "example-org"}
+			clock.NowCall.Returns.Time = time.Date(2001, time.February, 1, 0, 0, 0, 0, time.UTC)
+			apiClient.GetCall.Returns.ByteSlice = []byte(`[
+{
+	"full_name": "example-org/example-repo",
+	"topics": ["go"],
+	"language": "Go",
+	"pushed_at": "2001-01-01T00:00:00Z"
+},
+{
+	"full_name": "example-org/archived-repo",
+	"archived": true
+},
+{
+	"full_name": "example-org/forked-repo",
+	"fork": true
+}]`)
+		})
+
+		it("returns the repositories that pass the filter", func() {
+			repos, err := org.GetRepos(apiClient, clock, RepoFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apiClient.GetCall.Receives.Path).To(Equal("orgs/example-org/repos"))
+
+			expected := Repository{
+				Name:     "example-org/example-repo",
+				Topics:   []string{"go"},
+				Language: "Go",
+				PushedAt: "2001-01-01T00:00:00Z",
+			}
+			Expect(repos).To(Equal([]Repository{expected}))
+		})
+
+		it("pages through orgs with more than 100 repositories", func() {
+			client := &fakes.Client{}
+			client.GetCall.Stub = func(path string, params ...string) ([]byte, error) {
+				count := 100
+				if params[len(params)-1] == "page=2" {
+					count = 1
+				}
+				repos := make([]string, count)
+				for i := range repos {
+					repos[i] = fmt.Sprintf(`{"full_name": "example-org/repo-%s-%d"}`, params[len(params)-1], i)
+				}
+				return []byte("[" + strings.Join(repos, ",") + "]"), nil
+			}
+
+			repos, err := org.GetRepos(client, clock, RepoFilter{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(101))
+			Expect(client.GetCall.CallCount).To(Equal(2))
+		})
+
+		it("includes the repositories the filter asks for", func() {
+			repos, err := org.GetRepos(apiClient, clock, RepoFilter{Archived: true, Exclude: []string{"example-repo"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(repos).To(HaveLen(1))
+			Expect(repos[0].Name).To(Equal("example-org/archived-repo"))
+		})
+
+		context("failure cases", func() {
+			context("when get request fails", func() {
+				it.Before(func() {
+					apiClient.GetCall.Returns.Error = fmt.Errorf("something went wrong with HTTP GET")
+				})
+
+				it("returns the error", func() {
+					_, err := org.GetRepos(apiClient, clock, RepoFilter{})
+					Expect(err).To(MatchError("failed getting org repos: something went wrong with HTTP GET"))
+				})
+			})
+		})
+	})
+
 	context("GetRecentIssues", func() {
 		it.Before(func() {
 			clock.NowCall.Returns.Time = time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC).Add(30 * 24 * time.Hour)
@@ -35,7 +115,7 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 		})
 
 		it("returns the issues from the repo", func() {
-			issues, err := repo.GetRecentIssues(apiClient, clock)
+			issues, err := repo.GetRecentIssues(apiClient, clock, 30*24*time.Hour)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(apiClient.GetCall.Receives.Path).To(Equal("/repos/example-org/example-repo/issues"))
 			Expect(apiClient.GetCall.Receives.Params).To(ContainElement("per_page=100"))
@@ -49,6 +129,12 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 			Expect(issues).To(ContainElement(testIssue))
 		})
 
+		it("only asks for issues updated within the window", func() {
+			_, err := repo.GetRecentIssues(apiClient, clock, 24*time.Hour)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apiClient.GetCall.Receives.Params).To(ContainElement("since=2001-01-30T20:20:20Z"))
+		})
+
 		context("failure cases", func() {
 			context("when get request fails", func() {
 
@@ -56,7 +142,7 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 					apiClient.GetCall.Returns.Error = fmt.Errorf("something went wrong with HTTP GET")
 				})
 				it("returns the error", func() {
-					_, err := repo.GetRecentIssues(apiClient, clock)
+					_, err := repo.GetRecentIssues(apiClient, clock, 30*24*time.Hour)
 					Expect(err).To(MatchError("getting recent issues: something went wrong with HTTP GET"))
 				})
 			})
@@ -67,7 +153,7 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 					apiClient.GetCall.Returns.ByteSlice = []byte("{invalidJSON")
 				})
 				it("returns the error", func() {
-					_, err := repo.GetRecentIssues(apiClient, clock)
+					_, err := repo.GetRecentIssues(apiClient, clock, 30*24*time.Hour)
 					Expect(err).To(MatchError("getting recent issues: could not unmarshal JSON '{invalidJSON' : invalid character 'i' looking for beginning of object key string"))
 				})
 			})
@@ -75,31 +161,70 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 	})
 
 	context("GetFirstContactTimes", func() {
-		var timeChan chan TimeContainer
+		var timeChan chan FirstContact
 		var issues []CommentGetter
+		var options ContactOptions
+
+		it.Before(func() {
+			options = ContactOptions{Bots: BotPolicy{Patterns: []string{"bot"}}}
+		})
+
 		context("when given a set of issues", func() {
 			it.Before(func() {
 				issue := &fakes.CommentGetter{}
-				issue.GetFirstReplyCall.Returns.Comment = Comment{CreatedAt: "2001-01-01T21:20:20Z"}
+				issue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC)}
 				issue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
 
 				issues = []CommentGetter{issue}
 			})
 			it("writes the first reply time for an issue to the output channel", func() {
-				timeChan = make(chan TimeContainer)
-				go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
 
-				Eventually(<-timeChan).Should(Equal(TimeContainer{Time: 60, Error: nil}))
+				Expect((<-timeChan).Minutes()).To(Equal(60.0))
+			})
+		})
+		context("when an issue has labels and a reply", func() {
+			it.Before(func() {
+				issue := &fakes.CommentGetter{}
+				issue.GetFirstResponseCall.Returns.Response = &Response{
+					At:    time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC),
+					Actor: "replyGuy",
+					Kind:  ResponseComment,
+				}
+				issue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+				issue.GetLabelsCall.Returns.StringSlice = []string{"bug"}
+				issue.GetNumberCall.Returns.Int = 7
+				issue.IsPullRequestCall.Returns.Bool = true
+
+				issues = []CommentGetter{issue}
+			})
+			it("reports the issue, its labels and the first response", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Expect(<-timeChan).To(Equal(FirstContact{
+					Issue:     IssueRef{Repo: "example-org/example-repo", Number: 7, PullRequest: true},
+					CreatedAt: time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC),
+					Response: &Response{
+						At:    time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC),
+						Actor: "replyGuy",
+						Kind:  ResponseComment,
+					},
+					Elapsed: time.Hour,
+					Labels:  []string{"bug"},
+					Status:  StatusResponded,
+				}))
 			})
 		})
 		context("when an issue has been opened by a bot", func() {
 			it.Before(func() {
 				realIssue := &fakes.CommentGetter{}
-				realIssue.GetFirstReplyCall.Returns.Comment = Comment{CreatedAt: "2001-01-01T21:20:20Z"}
+				realIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC)}
 				realIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
 
 				botIssue := &fakes.CommentGetter{}
-				botIssue.GetFirstReplyCall.Returns.Comment = Comment{CreatedAt: "2001-01-01T20:21:20Z"}
+				botIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 20, 21, 20, 0, time.UTC)}
 				botIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
 				botIssue.GetUserLoginCall.Returns.String = "paketo-bot"
 
@@ -107,59 +232,209 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 			})
 
 			it("does not include reply time for the bot issue", func() {
-				timeChan = make(chan TimeContainer)
-				go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
 
-				Eventually(<-timeChan).Should(Equal(TimeContainer{Time: 60, Error: nil}))
-				Consistently(<-timeChan).ShouldNot(Equal(TimeContainer{Time: 1, Error: nil}))
+				Expect((<-timeChan).Minutes()).To(Equal(60.0))
+				Consistently(timeChan).Should(BeClosed())
+			})
+		})
+
+		context("when an issue has been opened by an account GitHub marks as a bot", func() {
+			it.Before(func() {
+				botIssue := &fakes.CommentGetter{}
+				botIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 20, 21, 20, 0, time.UTC)}
+				botIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+				botIssue.GetUserLoginCall.Returns.String = "renovate"
+				botIssue.GetUserTypeCall.Returns.String = "Bot"
+
+				issues = []CommentGetter{botIssue}
+			})
+
+			it("does not include reply time for the bot issue", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Eventually(timeChan).Should(BeClosed())
+			})
+
+			context("when bots are included", func() {
+				it.Before(func() {
+					options.Bots.Include = true
+				})
+
+				it("includes reply time for the bot issue", func() {
+					timeChan = make(chan FirstContact)
+					go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+					Expect((<-timeChan).Minutes()).To(Equal(1.0))
+				})
+			})
+		})
+
+		context("when bots are included", func() {
+			it.Before(func() {
+				options.Bots.Include = true
+
+				botIssue := &fakes.CommentGetter{}
+				botIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 20, 21, 20, 0, time.UTC)}
+				botIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+				botIssue.GetUserLoginCall.Returns.String = "paketo-bot"
+
+				issues = []CommentGetter{botIssue}
+			})
+
+			it("includes reply time for the bot issue", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Expect((<-timeChan).Minutes()).To(Equal(1.0))
+			})
+		})
+
+		context("when users are ignored", func() {
+			var realIssue *fakes.CommentGetter
+
+			it.Before(func() {
+				options.IgnoredUsers = []string{"ignoredUser"}
+
+				realIssue = &fakes.CommentGetter{}
+				realIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC)}
+				realIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+
+				ignoredIssue := &fakes.CommentGetter{}
+				ignoredIssue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 20, 21, 20, 0, time.UTC)}
+				ignoredIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+				ignoredIssue.GetUserLoginCall.Returns.String = "ignoredUser"
+
+				issues = []CommentGetter{ignoredIssue, realIssue}
+			})
+
+			it("skips their issues and ignores their replies", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Expect((<-timeChan).Minutes()).To(Equal(60.0))
+				Expect(realIssue.GetFirstResponseCall.Receives.IgnoredUsers).To(Equal([]string{"ignoredUser"}))
+			})
+		})
+
+		context("when a logger is set", func() {
+			var buffer *bytes.Buffer
+
+			it.Before(func() {
+				buffer = &bytes.Buffer{}
+				logger, err := NewLogger(buffer, LevelDebug, "text", clock)
+				Expect(err).NotTo(HaveOccurred())
+				options.Logger = logger
+
+				botIssue := &fakes.CommentGetter{}
+				botIssue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+				botIssue.GetUserLoginCall.Returns.String = "paketo-bot"
+				botIssue.GetNumberCall.Returns.Int = 7
+
+				issues = []CommentGetter{botIssue}
+			})
+
+			it("logs why issues are skipped", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Eventually(timeChan).Should(BeClosed())
+				Expect(buffer.String()).To(ContainSubstring(`level=DEBUG msg="skipping issue opened by an ignored user or bot" issue=example-org/example-repo#7 author=paketo-bot`))
+			})
+		})
+
+		context("when business hours are configured", func() {
+			it.Before(func() {
+				options.BusinessHours = BusinessHours{Start: "09:00", End: "17:00", Timezone: "UTC"}
+
+				issue := &fakes.CommentGetter{}
+				issue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 2, 10, 0, 0, 0, time.UTC)}
+				issue.GetCreatedAtCall.Returns.String = "2001-01-01T16:00:00Z"
+
+				issues = []CommentGetter{issue}
+			})
+
+			it("only counts time within business hours", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				Expect((<-timeChan).Minutes()).To(Equal(120.0))
 			})
 		})
 
 		context("when an issue has no reply", func() {
 			it.Before(func() {
 				issue := &fakes.CommentGetter{}
-				issue.GetFirstReplyCall.Returns.Comment = Comment{}
 				issue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
 				clock.NowCall.Returns.Time = time.Date(2001, time.January, 1, 20, 20, 20, 0, time.UTC).Add(1 * time.Hour)
 
 				issues = []CommentGetter{issue}
 			})
 			it("returns the time between run time and issue opening", func() {
-				timeChan = make(chan TimeContainer)
-				go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
 
-				Eventually(<-timeChan).Should(Equal(TimeContainer{Time: 60, Error: nil}))
+				contact := <-timeChan
+				Expect(contact.Minutes()).To(Equal(60.0))
+				Expect(contact.Status).To(Equal(StatusAwaiting))
+				Expect(contact.Response).To(BeNil())
 			})
 		})
-		context("failure cases", func() {
-			context("when there is an error getting the first reply from an issue", func() {
-				it.Before(func() {
-					issue := &fakes.CommentGetter{}
-					issue.GetFirstReplyCall.Returns.Error = fmt.Errorf("some problem getting reply")
-					issues = []CommentGetter{issue}
-				})
+		context("when continuing past errors", func() {
+			it.Before(func() {
+				options.ContinueOnError = true
 
-				it("sends the error in a container in the channel", func() {
-					timeChan = make(chan TimeContainer)
-					go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				brokenIssue := &fakes.CommentGetter{}
+				brokenIssue.GetNumberCall.Returns.Int = 7
+				brokenIssue.GetFirstResponseCall.Returns.Error = fmt.Errorf("not found")
 
-					Eventually((<-timeChan).Error).Should(MatchError(fmt.Errorf("could not get first reply: some problem getting reply")))
+				malformedIssue := &fakes.CommentGetter{}
+				malformedIssue.GetNumberCall.Returns.Int = 8
+				malformedIssue.GetCreatedAtCall.Returns.String = "yesterday"
 
-				})
+				issue := &fakes.CommentGetter{}
+				issue.GetFirstResponseCall.Returns.Response = &Response{At: time.Date(2001, time.January, 1, 21, 20, 20, 0, time.UTC)}
+				issue.GetCreatedAtCall.Returns.String = "2001-01-01T20:20:20Z"
+
+				issues = []CommentGetter{brokenIssue, malformedIssue, issue}
+			})
+
+			it("reports each failed issue and measures the rest", func() {
+				timeChan = make(chan FirstContact)
+				go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
+
+				var results []FirstContact
+				for result := range timeChan {
+					results = append(results, result)
+				}
+				Expect(results).To(HaveLen(3))
+				Expect(results[0].Status).To(Equal(StatusFailed))
+				Expect(results[0].Issue.Number).To(Equal(7))
+				Expect(results[0].Error).To(MatchError("could not get first response: not found"))
+				Expect(results[1].Status).To(Equal(StatusFailed))
+				Expect(results[1].Issue.Number).To(Equal(8))
+				Expect(results[1].Error).To(MatchError(ContainSubstring("could not parse issue creation time:")))
+				Expect(results[2].Status).To(Equal(StatusResponded))
+				Expect(results[2].Minutes()).To(Equal(60.0))
 			})
+		})
 
-			context("when there is an error parsing the first comment creation time", func() {
+		context("failure cases", func() {
+			context("when there is an error getting the first reply from an issue", func() {
 				it.Before(func() {
 					issue := &fakes.CommentGetter{}
-					issue.GetFirstReplyCall.Returns.Comment = Comment{CreatedAt: "some-garbage"}
+					issue.GetFirstResponseCall.Returns.Error = fmt.Errorf("some problem getting reply")
 					issues = []CommentGetter{issue}
 				})
 
-				it("sends the error in a container in the channel", func() {
-					timeChan = make(chan TimeContainer)
-					go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				it("sends the failed issue with its error and stops", func() {
+					timeChan = make(chan FirstContact)
+					go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
 
-					Eventually((<-timeChan).Error).Should(MatchError(fmt.Errorf(`could not parse first reply time: parsing time "some-garbage" as "2006-01-02T15:04:05Z07:00": cannot parse "some-garbage" as "2006"`)))
+					Eventually((<-timeChan).Error).Should(MatchError(fmt.Errorf("could not get first response: some problem getting reply")))
+					Eventually(timeChan).Should(BeClosed())
 
 				})
 			})
@@ -167,16 +442,16 @@ func testRepository(t *testing.T, context spec.G, it spec.S) {
 			context("when there is an error parsing the issue's creation time", func() {
 				it.Before(func() {
 					issue := &fakes.CommentGetter{}
-					issue.GetFirstReplyCall.Returns.Comment = Comment{}
 					issue.GetCreatedAtCall.Returns.String = "some-garbage"
 					issues = []CommentGetter{issue}
 				})
 
-				it("sends the error in a container in the channel", func() {
-					timeChan = make(chan TimeContainer)
-					go repo.GetFirstContactTimes(apiClient, issues, clock, timeChan)
+				it("sends the failed issue with its error and stops", func() {
+					timeChan = make(chan FirstContact)
+					go repo.GetFirstContactTimes(apiClient, issues, clock, options, timeChan)
 
 					Eventually((<-timeChan).Error).Should(MatchError(fmt.Errorf(`could not parse issue creation time: parsing time "some-garbage" as "2006-01-02T15:04:05Z07:00": cannot parse "some-garbage" as "2006"`)))
+					Eventually(timeChan).Should(BeClosed())
 				})
 			})
 		})
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ResponderReport describes how many issues one person was first to reply to,
+// how quickly, and which share of all first contacts that is.
+type ResponderReport struct {
+	Login              string  `json:"login"`
+	Issues             int     `json:"issues"`
+	Share              float64 `json:"share"`
+	MedianFirstContact float64 `json:"median_first_contact_minutes"`
+}
+
+// NewResponderReports breaks results down by responder, busiest responder
+// first. Issues nobody has replied to yet are left out.
+func NewResponderReports(results []FirstContact) []ResponderReport {
+	times := make(map[string][]float64)
+	answered := 0
+	for _, result := range results {
+		if result.Responder() == "" {
+			continue
+		}
+		times[result.Responder()] = append(times[result.Responder()], result.Minutes())
+		answered++
+	}
+
+	reports := make([]ResponderReport, 0, len(times))
+	for login, responderTimes := range times {
+		reports = append(reports, ResponderReport{
+			Login:              login,
+			Issues:             len(responderTimes),
+			Share:              float64(len(responderTimes)) / float64(answered),
+			MedianFirstContact: Median(responderTimes),
+		})
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].Issues != reports[j].Issues {
+			return reports[i].Issues > reports[j].Issues
+		}
+		return reports[i].Login < reports[j].Login
+	})
+	return reports
+}
+
+// anonymizeResponders replaces responder logins throughout the report with
+// stable pseudonyms, numbered by position in the org-wide breakdown so the
+// same person has the same pseudonym in every repository.
+func (r *Report) anonymizeResponders() {
+	pseudonyms := make(map[string]string)
+	for i := range r.Responders {
+		pseudonyms[r.Responders[i].Login] = fmt.Sprintf("responder-%d", i+1)
+		r.Responders[i].Login = pseudonyms[r.Responders[i].Login]
+	}
+	for _, repo := range r.Repositories {
+		for i := range repo.Responders {
+			repo.Responders[i].Login = pseudonyms[repo.Responders[i].Login]
+		}
+	}
+}
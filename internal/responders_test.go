@@ -0,0 +1,50 @@
+package internal_test
+
+import (
+	"testing"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testResponders(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("NewResponderReports", func() {
+		it("breaks results down by responder, busiest first", func() {
+			reports := NewResponderReports([]FirstContact{
+				contactAfter(10, "carol"),
+				contactAfter(30, "alice"),
+				contactAfter(50, "alice"),
+				contactAfter(20, "bob"),
+			})
+
+			Expect(reports).To(Equal([]ResponderReport{
+				{Login: "alice", Issues: 2, Share: 0.5, MedianFirstContact: 40},
+				{Login: "bob", Issues: 1, Share: 0.25, MedianFirstContact: 20},
+				{Login: "carol", Issues: 1, Share: 0.25, MedianFirstContact: 10},
+			}))
+		})
+
+		context("when an issue has no reply", func() {
+			it("leaves it out of the breakdown and the shares", func() {
+				reports := NewResponderReports([]FirstContact{
+					contactAfter(10, "alice"),
+					contactAfter(600, ""),
+				})
+
+				Expect(reports).To(Equal([]ResponderReport{
+					{Login: "alice", Issues: 1, Share: 1, MedianFirstContact: 10},
+				}))
+			})
+		})
+
+		context("when there are no replies at all", func() {
+			it("returns an empty breakdown", func() {
+				Expect(NewResponderReports([]FirstContact{contactAfter(600, "")})).To(BeEmpty())
+			})
+		})
+	})
+}
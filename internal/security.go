@@ -0,0 +1,349 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// SecurityConfig holds the labels of security issues and the SLOs security
+// reports are held to: how soon they should be acknowledged, and how soon
+// advisories should be published.
+type SecurityConfig struct {
+	Labels      []string      `yaml:"labels"`
+	Acknowledge time.Duration `yaml:"acknowledge"`
+	Publish     time.Duration `yaml:"publish"`
+}
+
+func (s SecurityConfig) Validate() error {
+	if len(s.Labels) == 0 {
+		return fmt.Errorf("labels: must not be empty")
+	}
+	if s.Acknowledge <= 0 {
+		return fmt.Errorf("acknowledge: must be positive, got %s", s.Acknowledge)
+	}
+	if s.Publish <= 0 {
+		return fmt.Errorf("publish: must be positive, got %s", s.Publish)
+	}
+	return nil
+}
+
+// Advisory is a repository security advisory. Its State is triage while a
+// private vulnerability report waits to be accepted, then draft until it is
+// published, or closed if it is not.
+type Advisory struct {
+	GHSAID      string `json:"ghsa_id"`
+	Summary     string `json:"summary"`
+	Severity    string `json:"severity"`
+	State       string `json:"state"`
+	CreatedAt   string `json:"created_at"`
+	PublishedAt string `json:"published_at"`
+	ClosedAt    string `json:"closed_at"`
+}
+
+// SecurityItem is a security advisory or an issue with a security label. An
+// issue is acknowledged by its first reply, as first contact is measured. An
+// advisory is acknowledged once it leaves triage; GitHub does not say when,
+// so its AcknowledgedAt is zero.
+type SecurityItem struct {
+	Repo           string    `json:"repo"`
+	ID             string    `json:"id"`
+	Advisory       bool      `json:"advisory"`
+	Severity       string    `json:"severity,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	Open           bool      `json:"open"`
+	Acknowledged   bool      `json:"acknowledged"`
+	AcknowledgedAt time.Time `json:"acknowledged_at"`
+	PublishedAt    time.Time `json:"published_at"`
+}
+
+// Name is the repository and GHSA ID of an advisory, such as
+// "org/repo GHSA-xxxx-xxxx-xxxx", or the repository and number of an issue,
+// such as "org/repo#7".
+func (i SecurityItem) Name() string {
+	if i.Advisory {
+		return fmt.Sprintf("%s %s", i.Repo, i.ID)
+	}
+	return i.Repo + i.ID
+}
+
+// SecurityReport summarizes a repository's security advisories and issues.
+// Times to publish and advisory ages are in days, times to acknowledge in
+// hours. Breaches lists the items that missed an SLO, or have already waited
+// longer than it.
+type SecurityReport struct {
+	Name                    string           `json:"name"`
+	Advisories              int              `json:"advisories"`
+	AwaitingTriage          int              `json:"awaiting_triage"`
+	Published               int              `json:"published"`
+	MedianTimeToPublish     float64          `json:"median_time_to_publish_days"`
+	OpenAdvisories          int              `json:"open_advisories"`
+	OldestOpenAdvisory      float64          `json:"oldest_open_advisory_days"`
+	Issues                  int              `json:"issues"`
+	Acknowledged            int              `json:"acknowledged"`
+	MedianTimeToAcknowledge float64          `json:"median_time_to_acknowledge_hours"`
+	OpenIssues              int              `json:"open_issues"`
+	Breaches                []SecurityBreach `json:"breaches"`
+}
+
+// SecurityBreach is an item that took, or has waited, Hours against the
+// acknowledge or publish SLO.
+type SecurityBreach struct {
+	Item  string  `json:"item"`
+	SLO   string  `json:"slo"`
+	Hours float64 `json:"hours"`
+}
+
+type SecuritySummary struct {
+	AcknowledgeSLO float64          `json:"acknowledge_slo_hours"`
+	PublishSLO     float64          `json:"publish_slo_hours"`
+	Repositories   []SecurityReport `json:"repositories"`
+}
+
+// GetSecurityAdvisories returns the repository's security advisories, most
+// recently created first, following the Link header since the endpoint pages
+// by cursor. Repositories whose advisories the token may not see, or which are
+// not found, have none, with a warning logged; any other error, including
+// running out of rate limit, is returned.
+func GetSecurityAdvisories(client Client, repo Repository, logger *Logger) ([]Advisory, error) {
+	path := fmt.Sprintf("/repos/%s/security-advisories", repo.Name)
+	params := []string{"per_page=100", "sort=created", "direction=desc"}
+
+	advisories := []Advisory{}
+	for {
+		body, next, err := client.GetNext(path, params...)
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && forbidden(statusErr) {
+			logger.Warn("skipping security advisories", "repo", repo.Name, "status", statusErr.StatusCode, "reason", statusErr.Message())
+			return advisories, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("getting security advisories: %s", err)
+		}
+		page := []Advisory{}
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, fmt.Errorf("getting security advisories: could not unmarshal JSON '%s' : %s", string(body), err)
+		}
+		advisories = append(advisories, page...)
+		if len(next) == 0 {
+			return advisories, nil
+		}
+		params = next
+	}
+}
+
+// forbidden reports whether err is a 404 Not Found or a 403 Forbidden that is
+// about permissions rather than the rate limit, which GitHub also answers
+// with 403.
+func forbidden(statusErr *StatusError) bool {
+	switch statusErr.StatusCode {
+	case http.StatusNotFound:
+		return true
+	case http.StatusForbidden:
+		return !strings.Contains(strings.ToLower(statusErr.Message()), "rate limit")
+	}
+	return false
+}
+
+// GetSecurityItems returns the repository's security advisories and issues
+// with any of the labels that are open or were opened within the window.
+// Issues opened by bots and ignored users are left out, as they are for first
+// contact times.
+func GetSecurityItems(client Client, repo Repository, clock Clock, window time.Duration, labels []string, options ContactOptions) ([]SecurityItem, error) {
+	since := clock.Now().UTC().Add(-window)
+
+	advisories, err := GetSecurityAdvisories(client, repo, options.Logger)
+	if err != nil {
+		return nil, err
+	}
+	var items []SecurityItem
+	for _, advisory := range advisories {
+		item, err := NewAdvisoryItem(repo.Name, advisory)
+		if err != nil {
+			return nil, err
+		}
+		if item.Open || !item.CreatedAt.Before(since) {
+			items = append(items, item)
+		}
+	}
+
+	issues, err := repo.GetLabeledIssues(client, labels, since)
+	if err != nil {
+		return nil, err
+	}
+	for i := range issues {
+		issue := &issues[i]
+		if ignoredAuthor(issue.User.Login, issue.User.Type, options) {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse creation time of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		open := issue.State != "closed"
+		if !open && created.Before(since) {
+			continue
+		}
+
+		contact, err := repo.firstContact(client, issue, clock, options)
+		if err != nil {
+			return nil, fmt.Errorf("measuring %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		item := SecurityItem{
+			Repo:      repo.Name,
+			ID:        fmt.Sprintf("#%d", issue.Number),
+			CreatedAt: created,
+			Open:      open,
+		}
+		if contact.Response != nil {
+			item.Acknowledged = true
+			item.AcknowledgedAt = contact.Response.At
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// NewAdvisoryItem reads an advisory. Advisories in triage or draft are open.
+func NewAdvisoryItem(repo string, advisory Advisory) (SecurityItem, error) {
+	item := SecurityItem{
+		Repo:         repo,
+		ID:           advisory.GHSAID,
+		Advisory:     true,
+		Severity:     advisory.Severity,
+		Open:         advisory.State == "triage" || advisory.State == "draft",
+		Acknowledged: advisory.State != "triage",
+	}
+	var err error
+	item.CreatedAt, err = time.Parse(time.RFC3339, advisory.CreatedAt)
+	if err != nil {
+		return SecurityItem{}, fmt.Errorf("could not parse creation time of %s %s: %s", repo, advisory.GHSAID, err)
+	}
+	if advisory.PublishedAt != "" {
+		item.PublishedAt, err = time.Parse(time.RFC3339, advisory.PublishedAt)
+		if err != nil {
+			return SecurityItem{}, fmt.Errorf("could not parse publication time of %s %s: %s", repo, advisory.GHSAID, err)
+		}
+	}
+	return item, nil
+}
+
+// NewSecuritySummary reports on each repository's security items against the
+// configured SLOs.
+func NewSecuritySummary(items []SecurityItem, config SecurityConfig, now time.Time) SecuritySummary {
+	byRepo := make(map[string][]SecurityItem)
+	for _, item := range items {
+		byRepo[item.Repo] = append(byRepo[item.Repo], item)
+	}
+
+	summary := SecuritySummary{
+		AcknowledgeSLO: config.Acknowledge.Hours(),
+		PublishSLO:     config.Publish.Hours(),
+		Repositories:   []SecurityReport{},
+	}
+	for name, repoItems := range byRepo {
+		summary.Repositories = append(summary.Repositories, NewSecurityReport(name, repoItems, config, now))
+	}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+	return summary
+}
+
+func NewSecurityReport(name string, items []SecurityItem, config SecurityConfig, now time.Time) SecurityReport {
+	report := SecurityReport{Name: name, Breaches: []SecurityBreach{}}
+	var publishTimes, acknowledgeTimes []float64
+	breach := func(item SecurityItem, slo string, took time.Duration) {
+		report.Breaches = append(report.Breaches, SecurityBreach{Item: item.Name(), SLO: slo, Hours: took.Hours()})
+	}
+
+	for _, item := range items {
+		if item.Advisory {
+			report.Advisories++
+			if !item.Acknowledged {
+				report.AwaitingTriage++
+				if waited := now.Sub(item.CreatedAt); waited > config.Acknowledge {
+					breach(item, "acknowledge", waited)
+				}
+			}
+			if !item.PublishedAt.IsZero() {
+				report.Published++
+				took := item.PublishedAt.Sub(item.CreatedAt)
+				publishTimes = append(publishTimes, took.Hours()/24)
+				if took > config.Publish {
+					breach(item, "publish", took)
+				}
+			}
+			if item.Open {
+				report.OpenAdvisories++
+				age := now.Sub(item.CreatedAt)
+				if days := age.Hours() / 24; days > report.OldestOpenAdvisory {
+					report.OldestOpenAdvisory = days
+				}
+				if age > config.Publish {
+					breach(item, "publish", age)
+				}
+			}
+			continue
+		}
+
+		report.Issues++
+		if item.Open {
+			report.OpenIssues++
+		}
+		switch {
+		case item.Acknowledged:
+			report.Acknowledged++
+			took := item.AcknowledgedAt.Sub(item.CreatedAt)
+			acknowledgeTimes = append(acknowledgeTimes, took.Hours())
+			if took > config.Acknowledge {
+				breach(item, "acknowledge", took)
+			}
+		case item.Open:
+			if waited := now.Sub(item.CreatedAt); waited > config.Acknowledge {
+				breach(item, "acknowledge", waited)
+			}
+		}
+	}
+
+	report.MedianTimeToPublish = Median(publishTimes)
+	report.MedianTimeToAcknowledge = Median(acknowledgeTimes)
+	sort.SliceStable(report.Breaches, func(i, j int) bool { return report.Breaches[i].Item < report.Breaches[j].Item })
+	return report
+}
+
+func (s SecuritySummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "REPOSITORY\tADVISORIES\tAWAITING TRIAGE\tPUBLISHED\tMEDIAN TIME TO PUBLISH (DAYS)\tOPEN\tOLDEST OPEN (DAYS)\tISSUES\tACKNOWLEDGED\tMEDIAN TIME TO ACKNOWLEDGE (H)\tOPEN ISSUES\tSLO BREACHES")
+		for _, repo := range s.Repositories {
+			fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%.1f\t%d\t%.0f\t%d\t%d\t%.1f\t%d\t%d\n", repo.Name, repo.Advisories, repo.AwaitingTriage, repo.Published,
+				repo.MedianTimeToPublish, repo.OpenAdvisories, repo.OldestOpenAdvisory, repo.Issues, repo.Acknowledged, repo.MedianTimeToAcknowledge,
+				repo.OpenIssues, len(repo.Breaches))
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+
+		slos := map[string]float64{"acknowledge": s.AcknowledgeSLO, "publish": s.PublishSLO}
+		for _, repo := range s.Repositories {
+			for _, breach := range repo.Breaches {
+				fmt.Fprintf(w, "%s exceeds the %s SLO of %gh: %.0fh\n", breach.Item, breach.SLO, slos[breach.SLO], breach.Hours)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
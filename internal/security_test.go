@@ -0,0 +1,171 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testSecurity(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var server *fakegithub.Server
+	var client APIClient
+	var clock *fakes.Clock
+	var config SecurityConfig
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		config = SecurityConfig{Labels: []string{"security"}, Acknowledge: 48 * time.Hour, Publish: 14 * 24 * time.Hour}
+
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "security.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+
+		client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+		Expect(err).NotTo(HaveOccurred())
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = now
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("GetSecurityAdvisories", func() {
+		it("follows the Link header to every page", func() {
+			pages := &fakes.Client{}
+			pages.GetNextCall.Stub = func(path string, params ...string) ([]byte, []string, error) {
+				if params[len(params)-1] == "after=Y3Vyc29y" {
+					return []byte(`[{"ghsa_id": "GHSA-bbbb-bbbb-bbbb"}]`), nil, nil
+				}
+				return []byte(`[{"ghsa_id": "GHSA-aaaa-aaaa-aaaa"}]`), []string{"per_page=100", "after=Y3Vyc29y"}, nil
+			}
+
+			advisories, err := GetSecurityAdvisories(pages, Repository{Name: "example-org/example-repo"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(advisories).To(Equal([]Advisory{{GHSAID: "GHSA-aaaa-aaaa-aaaa"}, {GHSAID: "GHSA-bbbb-bbbb-bbbb"}}))
+			Expect(pages.GetNextCall.CallCount).To(Equal(2))
+			Expect(pages.GetNextCall.Receives.Path).To(Equal("/repos/example-org/example-repo/security-advisories"))
+		})
+
+		it("skips repositories whose advisories cannot be listed with a warning", func() {
+			var buffer bytes.Buffer
+			logger, err := NewLogger(&buffer, LevelWarn, "text", clock)
+			Expect(err).NotTo(HaveOccurred())
+
+			advisories, err := GetSecurityAdvisories(&client, Repository{Name: "example-org/missing-repo"}, logger)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(advisories).To(BeEmpty())
+			Expect(buffer.String()).To(ContainSubstring(`level=WARN msg="skipping security advisories" repo=example-org/missing-repo status=404 reason="Not Found"`))
+		})
+
+		it("skips repositories whose advisories the token may not see", func() {
+			forbidden := &fakes.Client{}
+			forbidden.GetNextCall.Returns.Error = &StatusError{StatusCode: 403, Body: []byte(`{"message":"Resource not accessible by integration"}`)}
+
+			advisories, err := GetSecurityAdvisories(forbidden, Repository{Name: "example-org/example-repo"}, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(advisories).To(BeEmpty())
+		})
+
+		context("failure cases", func() {
+			context("when the rate limit runs out", func() {
+				it("returns the error", func() {
+					limited := &fakes.Client{}
+					limited.GetNextCall.Returns.Error = &StatusError{Path: "/repos/example-org/example-repo/security-advisories",
+						StatusCode: 403, Body: []byte(`{"message":"API rate limit exceeded for installation ID 1."}`)}
+
+					_, err := GetSecurityAdvisories(limited, Repository{Name: "example-org/example-repo"}, nil)
+					Expect(err).To(MatchError(HavePrefix("getting security advisories: /repos/example-org/example-repo/security-advisories returned 403 Forbidden")))
+				})
+			})
+
+			context("when the server fails", func() {
+				it("returns the error", func() {
+					failing := &fakes.Client{}
+					failing.GetNextCall.Returns.Error = &StatusError{Path: "/repos/example-org/example-repo/security-advisories",
+						StatusCode: 502, Body: []byte(`{"message":"Server Error"}`)}
+
+					_, err := GetSecurityAdvisories(failing, Repository{Name: "example-org/example-repo"}, nil)
+					Expect(err).To(MatchError(`getting security advisories: /repos/example-org/example-repo/security-advisories returned 502 Bad Gateway: {"message":"Server Error"}`))
+				})
+			})
+		})
+	})
+
+	context("GetSecurityItems", func() {
+		it("finds open advisories and issues and those opened within the window", func() {
+			items, err := GetSecurityItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, config.Labels, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, item := range items {
+				names = append(names, item.Name())
+			}
+			Expect(names).To(ConsistOf(
+				"example-org/example-repo GHSA-aaaa-aaaa-aaaa",
+				"example-org/example-repo GHSA-bbbb-bbbb-bbbb",
+				"example-org/example-repo GHSA-cccc-cccc-cccc",
+				"example-org/example-repo GHSA-dddd-dddd-dddd",
+				"example-org/example-repo#1",
+				"example-org/example-repo#2",
+				"example-org/example-repo#3",
+				"example-org/example-repo#5",
+			))
+
+			byName := make(map[string]SecurityItem)
+			for _, item := range items {
+				byName[item.Name()] = item
+			}
+			Expect(byName["example-org/example-repo GHSA-cccc-cccc-cccc"].Acknowledged).To(BeFalse())
+			Expect(byName["example-org/example-repo GHSA-dddd-dddd-dddd"].Open).To(BeTrue())
+			Expect(byName["example-org/example-repo#1"].AcknowledgedAt).To(Equal(time.Date(2001, time.January, 11, 1, 20, 20, 0, time.UTC)))
+			Expect(byName["example-org/example-repo#5"].Acknowledged).To(BeFalse())
+		})
+	})
+
+	context("NewSecuritySummary", func() {
+		it("reports times to acknowledge and publish against the SLOs", func() {
+			items, err := GetSecurityItems(&client, Repository{Name: "example-org/example-repo"}, clock, 30*24*time.Hour, config.Labels, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			summary := NewSecuritySummary(items, config, now)
+			Expect(summary.Repositories).To(Equal([]SecurityReport{{
+				Name:                    "example-org/example-repo",
+				Advisories:              4,
+				AwaitingTriage:          1,
+				Published:               2,
+				MedianTimeToPublish:     13.5,
+				OpenAdvisories:          2,
+				OldestOpenAdvisory:      61,
+				Issues:                  4,
+				Acknowledged:            2,
+				MedianTimeToAcknowledge: 38.5,
+				OpenIssues:              3,
+				Breaches: []SecurityBreach{
+					{Item: "example-org/example-repo GHSA-bbbb-bbbb-bbbb", SLO: "publish", Hours: 480},
+					{Item: "example-org/example-repo GHSA-cccc-cccc-cccc", SLO: "acknowledge", Hours: 72},
+					{Item: "example-org/example-repo GHSA-dddd-dddd-dddd", SLO: "publish", Hours: 1464},
+					{Item: "example-org/example-repo#2", SLO: "acknowledge", Hours: 72},
+					{Item: "example-org/example-repo#5", SLO: "acknowledge", Hours: 1128},
+				},
+			}}))
+
+			var output bytes.Buffer
+			Expect(summary.Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  4           1                2          13.5                           2     61                  4       2             38.5                            3            5"))
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo#5 exceeds the acknowledge SLO of 48h: 1128h"))
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo GHSA-bbbb-bbbb-bbbb exceeds the publish SLO of 336h: 480h"))
+		})
+	})
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Segmentation groups issues into segments by their labels, either one
+// segment per label (ByLabel) or one per configured category, where each
+// category lists the labels that belong to it. An issue lands in every
+// segment one of its labels maps to, or in the Unmatched segment if none do.
+type Segmentation struct {
+	ByLabel    bool                `yaml:"by_label"`
+	Categories map[string][]string `yaml:"categories"`
+	Unmatched  string              `yaml:"unmatched"`
+}
+
+const defaultUnmatchedSegment = "unmatched"
+
+func (s Segmentation) Enabled() bool {
+	return s.ByLabel || len(s.Categories) > 0
+}
+
+func (s Segmentation) Validate() error {
+	if s.ByLabel && len(s.Categories) > 0 {
+		return fmt.Errorf("by_label and categories cannot be used together")
+	}
+	for category, labels := range s.Categories {
+		if len(labels) == 0 {
+			return fmt.Errorf("category %q has no labels", category)
+		}
+	}
+	return nil
+}
+
+// Segments returns the sorted names of the segments an issue with the given
+// labels belongs to.
+func (s Segmentation) Segments(labels []string) []string {
+	found := make(map[string]struct{})
+	for _, label := range labels {
+		if s.ByLabel {
+			found[label] = struct{}{}
+			continue
+		}
+		for category, categoryLabels := range s.Categories {
+			if overlaps(categoryLabels, []string{label}) {
+				found[category] = struct{}{}
+			}
+		}
+	}
+
+	if len(found) == 0 {
+		return []string{s.unmatched()}
+	}
+
+	segments := make([]string, 0, len(found))
+	for segment := range found {
+		segments = append(segments, segment)
+	}
+	sort.Strings(segments)
+	return segments
+}
+
+func (s Segmentation) unmatched() string {
+	if strings.TrimSpace(s.Unmatched) == "" {
+		return defaultUnmatchedSegment
+	}
+	return s.Unmatched
+}
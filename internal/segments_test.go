@@ -0,0 +1,60 @@
+package internal_test
+
+import (
+	"testing"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testSegments(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var segmentation Segmentation
+
+	context("Segments", func() {
+		context("when segmenting by label", func() {
+			it.Before(func() {
+				segmentation = Segmentation{ByLabel: true}
+			})
+
+			it("returns the issue's labels", func() {
+				Expect(segmentation.Segments([]string{"question", "bug"})).To(Equal([]string{"bug", "question"}))
+			})
+
+			it("returns the unmatched segment for unlabeled issues", func() {
+				Expect(segmentation.Segments(nil)).To(Equal([]string{"unmatched"}))
+			})
+		})
+
+		context("when segmenting by category", func() {
+			it.Before(func() {
+				segmentation = Segmentation{
+					Categories: map[string][]string{"bugs": {"bug", "Kind/Bug"}, "features": {"enhancement"}},
+					Unmatched:  "other",
+				}
+			})
+
+			it("returns the categories the labels belong to", func() {
+				Expect(segmentation.Segments([]string{"kind/bug", "enhancement"})).To(Equal([]string{"bugs", "features"}))
+			})
+
+			it("returns the configured unmatched segment when no label belongs to a category", func() {
+				Expect(segmentation.Segments([]string{"question"})).To(Equal([]string{"other"}))
+			})
+		})
+	})
+
+	context("Validate", func() {
+		it("rejects segmenting by label and by category at once", func() {
+			segmentation = Segmentation{ByLabel: true, Categories: map[string][]string{"bugs": {"bug"}}}
+			Expect(segmentation.Validate()).To(MatchError("by_label and categories cannot be used together"))
+		})
+
+		it("rejects categories without labels", func() {
+			segmentation = Segmentation{Categories: map[string][]string{"bugs": {}}}
+			Expect(segmentation.Validate()).To(MatchError(`category "bugs" has no labels`))
+		})
+	})
+}
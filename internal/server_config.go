@@ -0,0 +1,127 @@
+package internal
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ServerConfig picks the forge to measure, "github" (the default), "gitlab"
+// or "gitea" (which also covers Forgejo), and points gloss at a self-hosted
+// instance of it instead of github.com, gitlab.com or gitea.com, either by
+// Host (whose API lives at https://HOST/api/v3 for GitHub Enterprise Server,
+// https://HOST/api/v4 for GitLab and https://HOST/api/v1 for Gitea) or by the
+// full API URL. CABundle adds PEM encoded certificates
+// to the system's trusted roots and Proxy overrides the proxy taken from the
+// environment.
+type ServerConfig struct {
+	Forge    string `yaml:"forge"`
+	Host     string `yaml:"host"`
+	URL      string `yaml:"url"`
+	CABundle string `yaml:"ca_bundle"`
+	Proxy    string `yaml:"proxy"`
+}
+
+var forges = map[string]struct {
+	apiURL   string
+	apiPath  string
+	tokenEnv string
+}{
+	"github": {apiURL: "https://api.github.com", apiPath: "/api/v3", tokenEnv: "GITHUB_TOKEN"},
+	"gitlab": {apiURL: "https://gitlab.com/api/v4", apiPath: "/api/v4", tokenEnv: "GITLAB_TOKEN"},
+	"gitea":  {apiURL: "https://gitea.com/api/v1", apiPath: "/api/v1", tokenEnv: "GITEA_TOKEN"},
+}
+
+// ForgeName returns the configured forge, defaulting to "github".
+func (s ServerConfig) ForgeName() string {
+	if s.Forge == "" {
+		return "github"
+	}
+	return s.Forge
+}
+
+func (s ServerConfig) APIURL() string {
+	switch {
+	case s.URL != "":
+		return s.URL
+	case s.Host != "":
+		return fmt.Sprintf("https://%s%s", s.Host, forges[s.ForgeName()].apiPath)
+	default:
+		return forges[s.ForgeName()].apiURL
+	}
+}
+
+// TokenEnv is the environment variable the forge's token is read from unless
+// the auth config says otherwise.
+func (s ServerConfig) TokenEnv() string {
+	return forges[s.ForgeName()].tokenEnv
+}
+
+func (s ServerConfig) IsGitHub() bool {
+	return s.ForgeName() == "github"
+}
+
+// GraphQLURL returns the GraphQL endpoint that belongs to the REST API at
+// APIURL: https://api.github.com/graphql for github.com, and
+// https://HOST/api/graphql for GitHub Enterprise Server.
+func (s ServerConfig) GraphQLURL() (string, error) {
+	uri, err := ParseServerURL(s.APIURL())
+	if err != nil {
+		return "", err
+	}
+	uri.Path = strings.TrimSuffix(strings.TrimSuffix(uri.Path, "/"), "/v3")
+	return JoinPath(uri, "/graphql").String(), nil
+}
+
+func (s ServerConfig) Validate() error {
+	if _, ok := forges[s.ForgeName()]; !ok {
+		return fmt.Errorf("forge %q is not one of github, gitlab, gitea", s.Forge)
+	}
+	if s.Host != "" && s.URL != "" {
+		return fmt.Errorf("only one of host and url may be set")
+	}
+	if _, err := ParseServerURL(s.APIURL()); err != nil {
+		return err
+	}
+	if s.Proxy != "" {
+		if _, err := url.Parse(s.Proxy); err != nil {
+			return fmt.Errorf("could not parse proxy URL: %s", err)
+		}
+	}
+	return nil
+}
+
+// HTTPClient builds an HTTP client that trusts the configured CA bundle and
+// uses the configured proxy.
+func (s ServerConfig) HTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if s.CABundle != "" {
+		bundle, err := ioutil.ReadFile(s.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA bundle: %s", err)
+		}
+		roots, err := x509.SystemCertPool()
+		if err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("reading CA bundle: no certificates found in %s", s.CABundle)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+
+	if s.Proxy != "" {
+		proxy, err := url.Parse(s.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse proxy URL: %s", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
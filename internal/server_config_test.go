@@ -0,0 +1,157 @@
+package internal_test
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testServerConfig(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("APIURL", func() {
+		it("defaults to github.com", func() {
+			Expect(ServerConfig{}.APIURL()).To(Equal("https://api.github.com"))
+		})
+
+		it("uses the v3 API of the configured host", func() {
+			Expect(ServerConfig{Host: "ghe.example.com"}.APIURL()).To(Equal("https://ghe.example.com/api/v3"))
+		})
+
+		it("uses the configured URL", func() {
+			Expect(ServerConfig{URL: "https://example.com/github/api/v3"}.APIURL()).To(Equal("https://example.com/github/api/v3"))
+		})
+
+		it("defaults to gitlab.com for GitLab", func() {
+			Expect(ServerConfig{Forge: "gitlab"}.APIURL()).To(Equal("https://gitlab.com/api/v4"))
+		})
+
+		it("uses the v4 API of the configured GitLab host", func() {
+			Expect(ServerConfig{Forge: "gitlab", Host: "gitlab.example.com"}.APIURL()).To(Equal("https://gitlab.example.com/api/v4"))
+		})
+
+		it("uses the v1 API of the configured Gitea host", func() {
+			Expect(ServerConfig{Forge: "gitea", Host: "codeberg.org"}.APIURL()).To(Equal("https://codeberg.org/api/v1"))
+		})
+	})
+
+	context("TokenEnv", func() {
+		it("reads GITHUB_TOKEN for GitHub", func() {
+			Expect(ServerConfig{}.TokenEnv()).To(Equal("GITHUB_TOKEN"))
+		})
+
+		it("reads GITLAB_TOKEN for GitLab", func() {
+			Expect(ServerConfig{Forge: "gitlab"}.TokenEnv()).To(Equal("GITLAB_TOKEN"))
+		})
+
+		it("reads GITEA_TOKEN for Gitea", func() {
+			Expect(ServerConfig{Forge: "gitea"}.TokenEnv()).To(Equal("GITEA_TOKEN"))
+		})
+	})
+
+	context("GraphQLURL", func() {
+		it("returns the github.com GraphQL endpoint by default", func() {
+			Expect(ServerConfig{}.GraphQLURL()).To(Equal("https://api.github.com/graphql"))
+		})
+
+		it("returns the GitHub Enterprise Server GraphQL endpoint for a host", func() {
+			Expect(ServerConfig{Host: "ghe.example.com"}.GraphQLURL()).To(Equal("https://ghe.example.com/api/graphql"))
+		})
+	})
+
+	context("Validate", func() {
+		it("rejects an unknown forge", func() {
+			Expect(ServerConfig{Forge: "bitbucket"}.Validate()).To(MatchError(`forge "bitbucket" is not one of github, gitlab, gitea`))
+		})
+
+		it("rejects setting both a host and a URL", func() {
+			Expect(ServerConfig{Host: "ghe.example.com", URL: "https://ghe.example.com/api/v3"}.Validate()).To(MatchError("only one of host and url may be set"))
+		})
+
+		it("rejects a URL without a host", func() {
+			Expect(ServerConfig{URL: "api/v3"}.Validate()).To(MatchError(`could not parse server URL: "api/v3" needs a scheme and host`))
+		})
+	})
+
+	context("HTTPClient", func() {
+		var dir string
+		var server *httptest.Server
+
+		it.Before(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "server-config")
+			Expect(err).NotTo(HaveOccurred())
+
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(w, "ok")
+			}))
+		})
+
+		it.After(func() {
+			server.Close()
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		context("when a CA bundle is configured", func() {
+			it.Before(func() {
+				bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+				Expect(ioutil.WriteFile(filepath.Join(dir, "ca.pem"), bundle, 0644)).To(Succeed())
+			})
+
+			it("trusts certificates from the bundle", func() {
+				client, err := ServerConfig{CABundle: filepath.Join(dir, "ca.pem")}.HTTPClient()
+				Expect(err).NotTo(HaveOccurred())
+
+				response, err := client.Get(server.URL)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(response.StatusCode).To(Equal(http.StatusOK))
+				Expect(response.Body.Close()).To(Succeed())
+			})
+		})
+
+		context("when no CA bundle is configured", func() {
+			it("does not trust the server's certificate", func() {
+				client, err := ServerConfig{}.HTTPClient()
+				Expect(err).NotTo(HaveOccurred())
+
+				_, err = client.Get(server.URL)
+				Expect(err).To(MatchError(ContainSubstring("certificate")))
+			})
+		})
+
+		context("when a proxy is configured", func() {
+			it("sends requests through the proxy", func() {
+				client, err := ServerConfig{Proxy: "http://proxy.example.com:3128"}.HTTPClient()
+				Expect(err).NotTo(HaveOccurred())
+
+				request, _ := http.NewRequest("GET", "https://ghe.example.com/api/v3", nil)
+				proxy, err := client.Transport.(*http.Transport).Proxy(request)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(proxy.String()).To(Equal("http://proxy.example.com:3128"))
+			})
+		})
+
+		context("failure cases", func() {
+			context("when the CA bundle has no certificates", func() {
+				it.Before(func() {
+					Expect(ioutil.WriteFile(filepath.Join(dir, "ca.pem"), []byte("nothing here"), 0644)).To(Succeed())
+				})
+
+				it("returns the error", func() {
+					_, err := ServerConfig{CABundle: filepath.Join(dir, "ca.pem")}.HTTPClient()
+					Expect(err).To(MatchError(fmt.Sprintf("reading CA bundle: no certificates found in %s", filepath.Join(dir, "ca.pem"))))
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,278 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// StaleConfig sets how long an open issue or pull request must go without
+// activity to be reported as stale.
+type StaleConfig struct {
+	After time.Duration `yaml:"after"`
+}
+
+func (s StaleConfig) Validate() error {
+	if s.After <= 0 {
+		return fmt.Errorf("after: must be positive, got %s", s.After)
+	}
+	return nil
+}
+
+// WaitingOn says whose turn it is on a stale issue.
+type WaitingOn string
+
+const (
+	// WaitingOnMaintainers issues were last acted on by their author, or by
+	// nobody else at all.
+	WaitingOnMaintainers WaitingOn = "maintainers"
+	// WaitingOnAuthor issues were last acted on by someone other than their
+	// author.
+	WaitingOnAuthor WaitingOn = "author"
+)
+
+// passiveEvents are issue events that record something happening to a user
+// rather than the user doing something.
+var passiveEvents = []string{"mentioned", "subscribed", "unsubscribed"}
+
+type StaleIssue struct {
+	Issue        IssueRef  `json:"issue"`
+	Title        string    `json:"title"`
+	Author       string    `json:"author"`
+	LastActor    string    `json:"last_actor"`
+	LastActivity time.Time `json:"last_activity"`
+	IdleDays     float64   `json:"idle_days"`
+	WaitingOn    WaitingOn `json:"waiting_on"`
+}
+
+// StaleReport lists the stale issues of the selected repositories, most idle
+// first, split by whose turn it is.
+type StaleReport struct {
+	After                string       `json:"after"`
+	WaitingOnMaintainers []StaleIssue `json:"waiting_on_maintainers"`
+	WaitingOnAuthor      []StaleIssue `json:"waiting_on_author"`
+}
+
+type restIssueEvent struct {
+	Event string `json:"event"`
+	Actor struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	} `json:"actor"`
+	Label struct {
+		Name string `json:"name"`
+	} `json:"label"`
+	CreatedAt string `json:"created_at"`
+}
+
+// GetStaleIssues returns the repository's open issues and pull requests that
+// nobody but ignored users and bots has acted on for at least after. Bots can
+// bump an issue's update time without acting on it, so the comments and
+// events of every open issue are fetched, and the reviews and commits of
+// every open pull request. Issues opened by bots and ignored users are left
+// out, as they are for first contact times.
+func GetStaleIssues(client Client, repo Repository, clock Clock, after time.Duration, options ContactOptions) ([]StaleIssue, error) {
+	issues, err := repo.GetIssues(client, "open", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	now := clock.Now().UTC()
+	var stale []StaleIssue
+	for _, issue := range issues {
+		if ignoredAuthor(issue.User.Login, issue.User.Type, options) {
+			continue
+		}
+
+		comments := []Comment{}
+		err = getAllPages(client, fmt.Sprintf("/repos/%s/issues/%d/comments", repo.Name, issue.Number), func(body []byte) (int, error) {
+			page := []Comment{}
+			err := json.Unmarshal(body, &page)
+			comments = append(comments, page...)
+			return len(page), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting comments of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+
+		events := []IssueEvent{}
+		err = getAllPages(client, fmt.Sprintf("/repos/%s/issues/%d/events", repo.Name, issue.Number), func(body []byte) (int, error) {
+			page := []restIssueEvent{}
+			err := json.Unmarshal(body, &page)
+			for _, item := range page {
+				event := IssueEvent{Type: item.Event, CreatedAt: item.CreatedAt}
+				event.Actor.Login, event.Actor.Type = item.Actor.Login, item.Actor.Type
+				events = append(events, event)
+			}
+			return len(page), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting events of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+
+		if issue.IsPullRequest() {
+			work, err := getPullRequestWork(client, repo, issue)
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, work...)
+		}
+
+		issue.Comments, issue.Events = comments, events
+		result, err := NewStaleIssue(repo.Name, issue, now, options.IgnoredUsers)
+		if err != nil {
+			return nil, err
+		}
+		if now.Sub(result.LastActivity) >= after {
+			stale = append(stale, result)
+		}
+	}
+	return stale, nil
+}
+
+// getPullRequestWork returns the submitted reviews and the commits of a pull
+// request as "reviewed" and "committed" events, as the REST timeline names
+// them. A commit GitHub cannot match to a user counts as the author's.
+func getPullRequestWork(client Client, repo Repository, issue Issue) ([]IssueEvent, error) {
+	var events []IssueEvent
+	err := getAllPages(client, fmt.Sprintf("/repos/%s/pulls/%d/reviews", repo.Name, issue.Number), func(body []byte) (int, error) {
+		page := []restReview{}
+		err := json.Unmarshal(body, &page)
+		for _, review := range page {
+			if review.State == "PENDING" || review.SubmittedAt == "" {
+				continue
+			}
+			event := IssueEvent{Type: "reviewed", CreatedAt: review.SubmittedAt}
+			event.Actor.Login, event.Actor.Type = review.User.Login, review.User.Type
+			events = append(events, event)
+		}
+		return len(page), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting reviews of %s#%d: %s", repo.Name, issue.Number, err)
+	}
+
+	err = getAllPages(client, fmt.Sprintf("/repos/%s/pulls/%d/commits", repo.Name, issue.Number), func(body []byte) (int, error) {
+		page := []Commit{}
+		err := json.Unmarshal(body, &page)
+		for _, commit := range page {
+			event := IssueEvent{Type: "committed", CreatedAt: commit.Commit.Committer.Date}
+			event.Actor.Login, event.Actor.Type = issue.User.Login, issue.User.Type
+			if commit.Author != nil {
+				event.Actor.Login, event.Actor.Type = commit.Author.Login, commit.Author.Type
+			}
+			events = append(events, event)
+		}
+		return len(page), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("getting commits of %s#%d: %s", repo.Name, issue.Number, err)
+	}
+	return events, nil
+}
+
+// NewStaleIssue finds who acted on the issue last, among its author and the
+// users whose comments and events count the same way GetFirstReply counts
+// replies: everyone but ignored users and bots. An issue nobody has acted on
+// since it was opened was last acted on by its author.
+func NewStaleIssue(repo string, issue Issue, now time.Time, ignoredUsers []string) (StaleIssue, error) {
+	created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+	if err != nil {
+		return StaleIssue{}, fmt.Errorf("could not parse creation time of %s#%d: %s", repo, issue.Number, err)
+	}
+	result := StaleIssue{
+		Issue:        IssueRef{Repo: repo, Number: issue.Number, PullRequest: issue.IsPullRequest()},
+		Title:        issue.Title,
+		Author:       issue.User.Login,
+		LastActor:    issue.User.Login,
+		LastActivity: created,
+	}
+
+	act := func(login, userType, at string) error {
+		if ignoredActor(login, userType, ignoredUsers) {
+			return nil
+		}
+		acted, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return fmt.Errorf("could not parse activity time on %s#%d: %s", repo, issue.Number, err)
+		}
+		if !acted.Before(result.LastActivity) {
+			result.LastActor, result.LastActivity = login, acted
+		}
+		return nil
+	}
+	for _, comment := range issue.Comments {
+		if err := act(comment.User.Login, comment.User.Type, comment.CreatedAt); err != nil {
+			return StaleIssue{}, err
+		}
+	}
+	for _, event := range issue.Events {
+		if contains(passiveEvents, event.Type) {
+			continue
+		}
+		if err := act(event.Actor.Login, event.Actor.Type, event.CreatedAt); err != nil {
+			return StaleIssue{}, err
+		}
+	}
+
+	result.IdleDays = now.Sub(result.LastActivity).Hours() / 24
+	result.WaitingOn = WaitingOnAuthor
+	if result.LastActor == result.Author {
+		result.WaitingOn = WaitingOnMaintainers
+	}
+	return result, nil
+}
+
+func NewStaleReport(issues []StaleIssue, after time.Duration) StaleReport {
+	report := StaleReport{After: after.String(), WaitingOnMaintainers: []StaleIssue{}, WaitingOnAuthor: []StaleIssue{}}
+	for _, issue := range issues {
+		if issue.WaitingOn == WaitingOnMaintainers {
+			report.WaitingOnMaintainers = append(report.WaitingOnMaintainers, issue)
+		} else {
+			report.WaitingOnAuthor = append(report.WaitingOnAuthor, issue)
+		}
+	}
+	for _, list := range [][]StaleIssue{report.WaitingOnMaintainers, report.WaitingOnAuthor} {
+		list := list
+		sort.SliceStable(list, func(i, j int) bool { return list[i].LastActivity.Before(list[j].LastActivity) })
+	}
+	return report
+}
+
+func (r StaleReport) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(r)
+	case "text":
+		sections := []struct {
+			title  string
+			issues []StaleIssue
+		}{
+			{"WAITING ON MAINTAINERS", r.WaitingOnMaintainers},
+			{"WAITING ON AUTHOR", r.WaitingOnAuthor},
+		}
+		for i, section := range sections {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s (%d)\n", section.title, len(section.issues))
+			table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(table, "ISSUE\tAUTHOR\tLAST ACTOR\tIDLE (DAYS)\tTITLE")
+			for _, issue := range section.issues {
+				fmt.Fprintf(table, "%s\t%s\t%s\t%.0f\t%s\n", issue.Issue, issue.Author, issue.LastActor, issue.IdleDays, issue.Title)
+			}
+			err := table.Flush()
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
@@ -0,0 +1,156 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testStale(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var issue Issue
+
+	comment := func(login, userType, createdAt string) Comment {
+		var comment Comment
+		comment.User.Login, comment.User.Type, comment.CreatedAt = login, userType, createdAt
+		return comment
+	}
+
+	event := func(eventType, login, createdAt string) IssueEvent {
+		event := IssueEvent{Type: eventType, CreatedAt: createdAt}
+		event.Actor.Login = login
+		return event
+	}
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		issue = Issue{Number: 7, Title: "Build fails on arm64", CreatedAt: "2000-12-01T20:20:20Z"}
+		issue.User.Login = "some-user"
+	})
+
+	context("NewStaleIssue", func() {
+		it("waits on maintainers when nobody else has acted", func() {
+			result, err := NewStaleIssue("example-org/example-repo", issue, now, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.WaitingOn).To(Equal(WaitingOnMaintainers))
+			Expect(result.LastActor).To(Equal("some-user"))
+			Expect(result.IdleDays).To(Equal(61.0))
+		})
+
+		it("waits on the author when a maintainer acted last", func() {
+			issue.Comments = []Comment{
+				comment("some-maintainer", "User", "2000-12-02T20:20:20Z"),
+				comment("some-user", "User", "2000-12-03T20:20:20Z"),
+			}
+			issue.Events = []IssueEvent{event("labeled", "other-maintainer", "2000-12-11T20:20:20Z")}
+
+			result, err := NewStaleIssue("example-org/example-repo", issue, now, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.WaitingOn).To(Equal(WaitingOnAuthor))
+			Expect(result.LastActor).To(Equal("other-maintainer"))
+			Expect(result.LastActivity).To(Equal(time.Date(2000, time.December, 11, 20, 20, 20, 0, time.UTC)))
+		})
+
+		it("ignores bots, ignored users and passive events", func() {
+			issue.Comments = []Comment{
+				comment("some-user", "User", "2000-12-02T20:20:20Z"),
+				comment("dependabot[bot]", "Bot", "2000-12-03T20:20:20Z"),
+				comment("paketo-automation", "User", "2000-12-04T20:20:20Z"),
+			}
+			issue.Events = []IssueEvent{event("subscribed", "some-maintainer", "2000-12-05T20:20:20Z")}
+
+			result, err := NewStaleIssue("example-org/example-repo", issue, now, []string{"paketo-automation"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.WaitingOn).To(Equal(WaitingOnMaintainers))
+			Expect(result.LastActivity).To(Equal(time.Date(2000, time.December, 2, 20, 20, 20, 0, time.UTC)))
+		})
+	})
+
+	context("GetStaleIssues", func() {
+		var server *fakegithub.Server
+		var client APIClient
+		var clock *fakes.Clock
+
+		it.Before(func() {
+			fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "example_org.json"))
+			Expect(err).NotTo(HaveOccurred())
+			server = fakegithub.NewServer(fixture)
+
+			client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+			Expect(err).NotTo(HaveOccurred())
+			clock = &fakes.Clock{}
+			clock.NowCall.Returns.Time = now
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("finds open issues without recent activity", func() {
+			issues, err := GetStaleIssues(&client, Repository{Name: "example-org/example-repo"}, clock, 14*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			report := NewStaleReport(issues, 14*24*time.Hour)
+			Expect(report.WaitingOnMaintainers).To(HaveLen(2))
+			Expect(report.WaitingOnMaintainers[0].Issue.Number).To(Equal(4))
+			Expect(report.WaitingOnAuthor).To(HaveLen(1))
+			Expect(report.WaitingOnAuthor[0].Issue.Number).To(Equal(1))
+			Expect(report.WaitingOnAuthor[0].LastActor).To(Equal("maintainer"))
+		})
+
+		it("counts commits and reviews on pull requests as activity", func() {
+			issues, err := GetStaleIssues(&client, Repository{Name: "example-org/example-repo"}, clock, 14*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			report := NewStaleReport(issues, 14*24*time.Hour)
+			pullRequest := report.WaitingOnMaintainers[1]
+			Expect(pullRequest.Issue.Number).To(Equal(2))
+			Expect(pullRequest.Issue.PullRequest).To(BeTrue())
+			Expect(pullRequest.LastActor).To(Equal("contributor"))
+			Expect(pullRequest.LastActivity).To(Equal(time.Date(2001, time.January, 10, 20, 20, 20, 0, time.UTC)))
+
+			Expect(server.Handler.Requests()).To(ContainElement("GET /repos/example-org/example-repo/pulls/2/reviews?per_page=100&page=1"))
+			Expect(server.Handler.Requests()).NotTo(ContainElement("GET /repos/example-org/example-repo/pulls/1/reviews?per_page=100&page=1"))
+		})
+
+		it("fetches the activity of issues however recently they were updated", func() {
+			clock.NowCall.Returns.Time = time.Date(2001, time.January, 16, 20, 20, 20, 0, time.UTC)
+			_, err := GetStaleIssues(&client, Repository{Name: "example-org/example-repo"}, clock, 14*24*time.Hour, ContactOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.Handler.Requests()).To(ContainElement("GET /repos/example-org/example-repo/issues/1/events?per_page=100&page=1"))
+			Expect(server.Handler.Requests()).To(ContainElement("GET /repos/example-org/example-repo/issues/2/events?per_page=100&page=1"))
+		})
+
+		it("leaves out issues opened by ignored users", func() {
+			issues, err := GetStaleIssues(&client, Repository{Name: "example-org/example-repo"}, clock, 14*24*time.Hour,
+				ContactOptions{IgnoredUsers: []string{"originalPoster"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(issues).To(HaveLen(1))
+			Expect(issues[0].Issue.Number).To(Equal(2))
+		})
+	})
+
+	context("StaleReport", func() {
+		it("writes a text report", func() {
+			result, err := NewStaleIssue("example-org/example-repo", issue, now, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			var output bytes.Buffer
+			Expect(NewStaleReport([]StaleIssue{result}, 14*24*time.Hour).Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("WAITING ON MAINTAINERS (1)"))
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo#7  some-user  some-user   61           Build fails on arm64"))
+			Expect(output.String()).To(ContainSubstring("WAITING ON AUTHOR (0)"))
+		})
+	})
+}
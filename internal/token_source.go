@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+//go:generate faux --interface TokenSource --output fakes/token_source.go
+
+// TokenSource provides the credential APIClient sends with every request. It
+// is asked again for each request, so implementations can rotate or refresh
+// their token. An empty token means requests are made unauthenticated.
+type TokenSource interface {
+	Token() (string, error)
+}
+
+// StaticToken always provides the same token, e.g. a personal access token.
+type StaticToken string
+
+func (t StaticToken) Token() (string, error) {
+	return string(t), nil
+}
+
+// EnvToken reads the token from an environment variable.
+type EnvToken struct {
+	Name string
+}
+
+func (t EnvToken) Token() (string, error) {
+	return os.Getenv(t.Name), nil
+}
+
+// FileToken reads the token from a file, so that whatever writes the file can
+// rotate the token without restarting gloss.
+type FileToken struct {
+	Path string
+}
+
+func (t FileToken) Token() (string, error) {
+	contents, err := ioutil.ReadFile(t.Path)
+	if err != nil {
+		return "", fmt.Errorf("reading token file: %s", err)
+	}
+	return strings.TrimSpace(string(contents)), nil
+}
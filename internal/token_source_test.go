@@ -0,0 +1,78 @@
+package internal_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gloss/internal"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testTokenSource(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("StaticToken", func() {
+		it("returns the token", func() {
+			token, err := StaticToken("some-token").Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("some-token"))
+		})
+	})
+
+	context("EnvToken", func() {
+		it.Before(func() {
+			Expect(os.Setenv("GLOSS_TEST_TOKEN", "some-token")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("GLOSS_TEST_TOKEN")).To(Succeed())
+		})
+
+		it("returns the value of the environment variable", func() {
+			token, err := EnvToken{Name: "GLOSS_TEST_TOKEN"}.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("some-token"))
+		})
+	})
+
+	context("FileToken", func() {
+		var dir string
+
+		it.Before(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "token")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it.After(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		it("returns the current contents of the file", func() {
+			source := FileToken{Path: filepath.Join(dir, "token")}
+
+			Expect(ioutil.WriteFile(source.Path, []byte("first-token\n"), 0600)).To(Succeed())
+			token, err := source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("first-token"))
+
+			Expect(ioutil.WriteFile(source.Path, []byte("rotated-token\n"), 0600)).To(Succeed())
+			token, err = source.Token()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(token).To(Equal("rotated-token"))
+		})
+
+		context("failure cases", func() {
+			context("when the file does not exist", func() {
+				it("returns the error", func() {
+					_, err := FileToken{Path: filepath.Join(dir, "missing")}.Token()
+					Expect(err).To(MatchError(ContainSubstring("reading token file:")))
+				})
+			})
+		})
+	})
+}
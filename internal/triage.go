@@ -0,0 +1,423 @@
+package internal
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// TriageConfig says how soon new issues should be triaged: labeled at all,
+// and labeled with one of the labels of each of Categories, such as kind and
+// priority, within Within of being opened.
+type TriageConfig struct {
+	Within     time.Duration       `yaml:"within"`
+	Categories map[string][]string `yaml:"categories"`
+}
+
+func (t TriageConfig) Validate() error {
+	if t.Within <= 0 {
+		return fmt.Errorf("within: must be positive, got %s", t.Within)
+	}
+	for category, labels := range t.Categories {
+		if len(labels) == 0 {
+			return fmt.Errorf("categories: category %q has no labels", category)
+		}
+	}
+	return nil
+}
+
+// IssueTemplate is an issue template from .github/ISSUE_TEMPLATE, by the
+// headings an issue opened from it has: a Markdown template's own headings,
+// or the labels of an issue form's fields, which GitHub renders as headings.
+type IssueTemplate struct {
+	Name     string
+	Headings []string
+}
+
+// TriagedIssue is an issue opened within the window with when it first had a
+// label, and when it first had a label of every category, if it ever did.
+// FollowsTemplate is only meaningful when the repository has templates.
+type TriagedIssue struct {
+	Issue           IssueRef  `json:"issue"`
+	Title           string    `json:"title"`
+	CreatedAt       time.Time `json:"created_at"`
+	Labeled         bool      `json:"labeled"`
+	LabeledAt       time.Time `json:"labeled_at"`
+	CategorizedAt   time.Time `json:"categorized_at"`
+	FollowsTemplate bool      `json:"follows_template"`
+}
+
+// TriageReport says how well a repository's new issues are triaged. Shares
+// are over the issues opened at least Within ago, the rest being too new to
+// tell; Unlabeled counts those still without any label. Template compliance is
+// over all of the issues, and only reported for repositories with templates.
+type TriageReport struct {
+	Name             string  `json:"name"`
+	Issues           int     `json:"issues"`
+	Due              int     `json:"due"`
+	LabeledInTime    int     `json:"labeled_in_time"`
+	LabeledShare     float64 `json:"labeled_share"`
+	CategorizedShare float64 `json:"categorized_share"`
+	Unlabeled        int     `json:"unlabeled"`
+	Templates        int     `json:"templates"`
+	FollowsTemplate  int     `json:"follows_template"`
+	TemplateShare    float64 `json:"template_share"`
+	OffTemplate      []int   `json:"off_template"`
+}
+
+type TriageSummary struct {
+	Within       float64        `json:"within_hours"`
+	Categories   []string       `json:"categories"`
+	Repositories []TriageReport `json:"repositories"`
+}
+
+type contentEntry struct {
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Type     string `json:"type"`
+	Encoding string `json:"encoding"`
+	Content  string `json:"content"`
+}
+
+// getContents returns the entries of the repository's directory at dir,
+// or nothing if there is no such directory.
+func getContents(client Client, repo Repository, dir string) ([]contentEntry, error) {
+	body, err := client.Get(fmt.Sprintf("/repos/%s/contents/%s", repo.Name, dir))
+	if statusCode(err) == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing %s: %s", dir, err)
+	}
+	entries := []contentEntry{}
+	err = json.Unmarshal(body, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal JSON '%s' : %s", string(body), err)
+	}
+	return entries, nil
+}
+
+// GetIssueTemplates returns the repository's issue templates with headings,
+// leaving out the template chooser's config.yml. The contents API lists a
+// directory without the contents of its files, so each template takes a
+// request.
+func GetIssueTemplates(client Client, repo Repository) ([]IssueTemplate, error) {
+	entries, err := getContents(client, repo, ".github/ISSUE_TEMPLATE")
+	if err != nil {
+		return nil, fmt.Errorf("getting issue templates: %s", err)
+	}
+
+	var templates []IssueTemplate
+	for _, entry := range entries {
+		ext := strings.ToLower(path.Ext(entry.Name))
+		if entry.Type != "file" || strings.TrimSuffix(entry.Name, ext) == "config" {
+			continue
+		}
+		if ext != ".md" && ext != ".yml" && ext != ".yaml" {
+			continue
+		}
+
+		body, err := client.Get(fmt.Sprintf("/repos/%s/contents/%s", repo.Name, entry.Path))
+		if err != nil {
+			return nil, fmt.Errorf("getting issue template %s: %s", entry.Path, err)
+		}
+		var file contentEntry
+		err = json.Unmarshal(body, &file)
+		if err != nil {
+			return nil, fmt.Errorf("getting issue template %s: could not unmarshal JSON '%s' : %s", entry.Path, string(body), err)
+		}
+		contents, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+		if err != nil {
+			return nil, fmt.Errorf("getting issue template %s: could not decode content: %s", entry.Path, err)
+		}
+
+		template := IssueTemplate{Name: entry.Name}
+		if ext == ".md" {
+			template.Headings = markdownHeadings(stripFrontMatter(string(contents)))
+		} else {
+			template.Headings, err = issueFormHeadings(contents)
+			if err != nil {
+				return nil, fmt.Errorf("getting issue template %s: %s", entry.Path, err)
+			}
+		}
+		if len(template.Headings) > 0 {
+			templates = append(templates, template)
+		}
+	}
+	return templates, nil
+}
+
+func stripFrontMatter(contents string) string {
+	contents = strings.ReplaceAll(contents, "\r\n", "\n")
+	if !strings.HasPrefix(contents, "---\n") {
+		return contents
+	}
+	end := strings.Index(contents[4:], "\n---")
+	if end < 0 {
+		return contents
+	}
+	return contents[4+end+4:]
+}
+
+// markdownHeadings returns the ATX headings of a Markdown document, lower
+// cased so that issues are not held to the template's capitalization.
+func markdownHeadings(contents string) []string {
+	var headings []string
+	for _, line := range strings.Split(contents, "\n") {
+		line = strings.TrimSpace(line)
+		text := strings.TrimLeft(line, "#")
+		if level := len(line) - len(text); level == 0 || level > 6 || (text != "" && text[0] != ' ' && text[0] != '\t') {
+			continue
+		}
+		text = strings.TrimSpace(strings.TrimRight(text, "# "))
+		if text != "" {
+			headings = append(headings, strings.ToLower(text))
+		}
+	}
+	return headings
+}
+
+func issueFormHeadings(contents []byte) ([]string, error) {
+	var form struct {
+		Body []struct {
+			Type       string `yaml:"type"`
+			Attributes struct {
+				Label string `yaml:"label"`
+			} `yaml:"attributes"`
+		} `yaml:"body"`
+	}
+	err := yaml.Unmarshal(contents, &form)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse issue form: %s", err)
+	}
+	var headings []string
+	for _, field := range form.Body {
+		if field.Type != "markdown" && field.Attributes.Label != "" {
+			headings = append(headings, strings.ToLower(strings.TrimSpace(field.Attributes.Label)))
+		}
+	}
+	return headings, nil
+}
+
+// FollowsTemplate reports whether the body has every heading of any of the
+// templates.
+func FollowsTemplate(body string, templates []IssueTemplate) bool {
+	headings := markdownHeadings(strings.ReplaceAll(body, "\r\n", "\n"))
+	for _, template := range templates {
+		if containsAll(headings, template.Headings) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(values, wanted []string) bool {
+	for _, value := range wanted {
+		if !contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetTriagedIssues returns the repository's issues opened since since, leaving
+// out pull requests and issues opened by bots and ignored users as first
+// contact does. When labels were first added is read from each issue's events,
+// taking a request per issue, since an issue without labels now may have had
+// some removed.
+func GetTriagedIssues(client Client, repo Repository, since time.Time, categories map[string][]string, templates []IssueTemplate, options ContactOptions) ([]TriagedIssue, error) {
+	issues, err := repo.GetIssues(client, "all", since)
+	if err != nil {
+		return nil, err
+	}
+
+	var triaged []TriagedIssue
+	for _, issue := range issues {
+		if issue.IsPullRequest() {
+			continue
+		}
+		if ignoredAuthor(issue.User.Login, issue.User.Type, options) {
+			continue
+		}
+		created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse creation time of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+		if created.Before(since) {
+			continue
+		}
+
+		var events []restIssueEvent
+		err = getAllPages(client, fmt.Sprintf("/repos/%s/issues/%d/events", repo.Name, issue.Number), func(body []byte) (int, error) {
+			page := []restIssueEvent{}
+			err := json.Unmarshal(body, &page)
+			events = append(events, page...)
+			return len(page), err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("getting events of %s#%d: %s", repo.Name, issue.Number, err)
+		}
+
+		result, err := NewTriagedIssue(repo.Name, issue, created, events, categories)
+		if err != nil {
+			return nil, err
+		}
+		result.FollowsTemplate = FollowsTemplate(issue.Body, templates)
+		triaged = append(triaged, result)
+	}
+	return triaged, nil
+}
+
+// NewTriagedIssue replays the issue's label events, in order, to find when it
+// was first labeled and when it first had a label of every category. Labels
+// removed since still count as triage.
+func NewTriagedIssue(repo string, issue Issue, created time.Time, events []restIssueEvent, categories map[string][]string) (TriagedIssue, error) {
+	result := TriagedIssue{
+		Issue:     IssueRef{Repo: repo, Number: issue.Number},
+		Title:     issue.Title,
+		CreatedAt: created,
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt < events[j].CreatedAt })
+	labels := make(map[string]bool)
+	for _, event := range events {
+		if event.Event != "labeled" && event.Event != "unlabeled" {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, event.CreatedAt)
+		if err != nil {
+			return TriagedIssue{}, fmt.Errorf("could not parse event time of %s#%d: %s", repo, issue.Number, err)
+		}
+		labels[event.Label.Name] = event.Event == "labeled"
+		if event.Event != "labeled" {
+			continue
+		}
+
+		if !result.Labeled {
+			result.Labeled = true
+			result.LabeledAt = at
+		}
+		if result.CategorizedAt.IsZero() && len(categories) > 0 && hasEveryCategory(labels, categories) {
+			result.CategorizedAt = at
+		}
+	}
+	return result, nil
+}
+
+func hasEveryCategory(labels map[string]bool, categories map[string][]string) bool {
+	for _, categoryLabels := range categories {
+		found := false
+		for _, label := range categoryLabels {
+			found = found || labels[label]
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// NewTriageSummary reports on each repository's issues. templates holds the
+// number of issue templates of each repository.
+func NewTriageSummary(issues []TriagedIssue, templates map[string]int, config TriageConfig, now time.Time) TriageSummary {
+	byRepo := make(map[string][]TriagedIssue)
+	for name := range templates {
+		byRepo[name] = nil
+	}
+	for _, issue := range issues {
+		byRepo[issue.Issue.Repo] = append(byRepo[issue.Issue.Repo], issue)
+	}
+
+	summary := TriageSummary{Within: config.Within.Hours(), Categories: []string{}, Repositories: []TriageReport{}}
+	for category := range config.Categories {
+		summary.Categories = append(summary.Categories, category)
+	}
+	sort.Strings(summary.Categories)
+	for name, repoIssues := range byRepo {
+		summary.Repositories = append(summary.Repositories, NewTriageReport(name, repoIssues, templates[name], config, now))
+	}
+	sort.Slice(summary.Repositories, func(i, j int) bool { return summary.Repositories[i].Name < summary.Repositories[j].Name })
+	return summary
+}
+
+func NewTriageReport(name string, issues []TriagedIssue, templates int, config TriageConfig, now time.Time) TriageReport {
+	report := TriageReport{Name: name, Issues: len(issues), Templates: templates, OffTemplate: []int{}}
+	categorized := 0
+	for _, issue := range issues {
+		if templates > 0 {
+			if issue.FollowsTemplate {
+				report.FollowsTemplate++
+			} else {
+				report.OffTemplate = append(report.OffTemplate, issue.Issue.Number)
+			}
+		}
+
+		if now.Sub(issue.CreatedAt) < config.Within {
+			continue
+		}
+		report.Due++
+		if !issue.Labeled {
+			report.Unlabeled++
+		}
+		if issue.Labeled && issue.LabeledAt.Sub(issue.CreatedAt) <= config.Within {
+			report.LabeledInTime++
+		}
+		if !issue.CategorizedAt.IsZero() && issue.CategorizedAt.Sub(issue.CreatedAt) <= config.Within {
+			categorized++
+		}
+	}
+	sort.Ints(report.OffTemplate)
+
+	if report.Due > 0 {
+		report.LabeledShare = float64(report.LabeledInTime) / float64(report.Due)
+		report.CategorizedShare = float64(categorized) / float64(report.Due)
+	}
+	if templates > 0 && report.Issues > 0 {
+		report.TemplateShare = float64(report.FollowsTemplate) / float64(report.Issues)
+	}
+	return report
+}
+
+func (s TriageSummary) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(s)
+	case "text":
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintf(table, "REPOSITORY\tISSUES\tDUE\tLABELED IN %gH\tCATEGORIZED IN %gH\tUNLABELED\tFOLLOW A TEMPLATE\n", s.Within, s.Within)
+		for _, repo := range s.Repositories {
+			categorized, templates := "-", "-"
+			if len(s.Categories) > 0 {
+				categorized = fmt.Sprintf("%.0f%%", repo.CategorizedShare*100)
+			}
+			if repo.Templates > 0 {
+				templates = fmt.Sprintf("%d of %d", repo.FollowsTemplate, repo.Issues)
+			}
+			fmt.Fprintf(table, "%s\t%d\t%d\t%d (%.0f%%)\t%s\t%d\t%s\n", repo.Name, repo.Issues, repo.Due, repo.LabeledInTime, repo.LabeledShare*100, categorized, repo.Unlabeled, templates)
+		}
+		err := table.Flush()
+		if err != nil {
+			return err
+		}
+
+		for _, repo := range s.Repositories {
+			for _, number := range repo.OffTemplate {
+				fmt.Fprintf(w, "%s#%d does not follow an issue template\n", repo.Name, number)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
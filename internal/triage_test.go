@@ -0,0 +1,164 @@
+package internal_test
+
+import (
+	"bytes"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testTriage(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var now time.Time
+	var server *fakegithub.Server
+	var client APIClient
+	var config TriageConfig
+
+	it.Before(func() {
+		now = time.Date(2001, time.January, 31, 20, 20, 20, 0, time.UTC)
+		config = TriageConfig{
+			Within: 48 * time.Hour,
+			Categories: map[string][]string{
+				"kind":     {"bug", "enhancement"},
+				"priority": {"p1", "p2"},
+			},
+		}
+
+		fixture, err := fakegithub.LoadFixture(filepath.Join("testdata", "fakegithub", "triage.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+
+		client, err = NewAPIClient(server.URL, http.DefaultClient, StaticToken(""))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("GetIssueTemplates", func() {
+		it("reads the headings of Markdown templates and issue forms", func() {
+			templates, err := GetIssueTemplates(&client, Repository{Name: "example-org/example-repo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(templates).To(Equal([]IssueTemplate{
+				{Name: "bug_report.md", Headings: []string{"describe the bug", "steps to reproduce", "expected behavior"}},
+				{Name: "feature_request.yml", Headings: []string{"problem", "proposal"}},
+			}))
+		})
+
+		it("returns none for repositories without templates", func() {
+			templates, err := GetIssueTemplates(&client, Repository{Name: "example-org/other-repo"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(templates).To(BeEmpty())
+		})
+	})
+
+	context("FollowsTemplate", func() {
+		it("requires every heading of one of the templates, in any case", func() {
+			templates := []IssueTemplate{{Name: "bug.md", Headings: []string{"describe the bug", "expected behavior"}}}
+			Expect(FollowsTemplate("## Describe the bug ##\nit broke\n## EXPECTED BEHAVIOR\nit works", templates)).To(BeTrue())
+			Expect(FollowsTemplate("## Describe the bug\nit broke", templates)).To(BeFalse())
+			Expect(FollowsTemplate("#describe the bug\n#expected behavior", templates)).To(BeFalse())
+		})
+	})
+
+	context("GetTriagedIssues", func() {
+		var templates []IssueTemplate
+
+		it.Before(func() {
+			var err error
+			templates, err = GetIssueTemplates(&client, Repository{Name: "example-org/example-repo"})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		it("finds when issues opened within the window were labeled and categorized", func() {
+			issues, err := GetTriagedIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), config.Categories, templates, ContactOptions{Bots: BotPolicy{Patterns: []string{"[bot]"}}})
+			Expect(err).NotTo(HaveOccurred())
+
+			byNumber := make(map[int]TriagedIssue)
+			for _, issue := range issues {
+				byNumber[issue.Issue.Number] = issue
+			}
+			Expect(byNumber).To(HaveLen(5))
+			Expect(byNumber).NotTo(HaveKey(7))
+
+			Expect(byNumber[1].LabeledAt).To(Equal(time.Date(2001, time.January, 5, 20, 20, 20, 0, time.UTC)))
+			Expect(byNumber[1].CategorizedAt).To(Equal(time.Date(2001, time.January, 6, 20, 20, 20, 0, time.UTC)))
+			Expect(byNumber[1].FollowsTemplate).To(BeTrue())
+			Expect(byNumber[2].CategorizedAt.IsZero()).To(BeTrue())
+			Expect(byNumber[2].FollowsTemplate).To(BeTrue())
+			Expect(byNumber[3].Labeled).To(BeFalse())
+			Expect(byNumber[4].CategorizedAt).To(Equal(time.Date(2001, time.January, 20, 22, 20, 20, 0, time.UTC)))
+			Expect(byNumber[4].FollowsTemplate).To(BeFalse())
+			Expect(byNumber[5].Labeled).To(BeTrue())
+			Expect(byNumber[5].LabeledAt).To(Equal(time.Date(2001, time.January, 31, 12, 20, 20, 0, time.UTC)))
+		})
+
+		it("leaves out issues opened by bot accounts and ignored users", func() {
+			issues, err := GetTriagedIssues(&client, Repository{Name: "example-org/example-repo"}, now.Add(-30*24*time.Hour), config.Categories, templates, ContactOptions{IgnoredUsers: []string{"other-user"}})
+			Expect(err).NotTo(HaveOccurred())
+
+			numbers := []int{}
+			for _, issue := range issues {
+				numbers = append(numbers, issue.Issue.Number)
+			}
+			Expect(numbers).To(ConsistOf(1, 2, 5))
+		})
+	})
+
+	context("NewTriageSummary", func() {
+		it("reports label and template compliance per repository", func() {
+			var issues []TriagedIssue
+			templates := make(map[string]int)
+			for _, name := range []string{"example-org/example-repo", "example-org/other-repo"} {
+				repo := Repository{Name: name}
+				repoTemplates, err := GetIssueTemplates(&client, repo)
+				Expect(err).NotTo(HaveOccurred())
+				repoIssues, err := GetTriagedIssues(&client, repo, now.Add(-30*24*time.Hour), config.Categories, repoTemplates, ContactOptions{Bots: BotPolicy{Patterns: []string{"[bot]"}}})
+				Expect(err).NotTo(HaveOccurred())
+				templates[name] = len(repoTemplates)
+				issues = append(issues, repoIssues...)
+			}
+
+			summary := NewTriageSummary(issues, templates, config, now)
+			Expect(summary.Categories).To(Equal([]string{"kind", "priority"}))
+			Expect(summary.Repositories).To(Equal([]TriageReport{
+				{
+					Name:             "example-org/example-repo",
+					Issues:           5,
+					Due:              4,
+					LabeledInTime:    2,
+					LabeledShare:     0.5,
+					CategorizedShare: 0.5,
+					Unlabeled:        1,
+					Templates:        2,
+					FollowsTemplate:  3,
+					TemplateShare:    0.6,
+					OffTemplate:      []int{3, 4},
+				},
+				{
+					Name:          "example-org/other-repo",
+					Issues:        1,
+					Due:           1,
+					LabeledInTime: 1,
+					LabeledShare:  1,
+					OffTemplate:   []int{},
+				},
+			}))
+
+			var output bytes.Buffer
+			Expect(summary.Write(&output, "text")).To(Succeed())
+			Expect(output.String()).To(ContainSubstring("REPOSITORY                ISSUES  DUE  LABELED IN 48H  CATEGORIZED IN 48H  UNLABELED  FOLLOW A TEMPLATE"))
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo  5       4    2 (50%)         50%                 1          3 of 5"))
+			Expect(output.String()).To(ContainSubstring("example-org/other-repo    1       1    1 (100%)        0%                  0          -"))
+			Expect(output.String()).To(ContainSubstring("example-org/example-repo#3 does not follow an issue template"))
+		})
+	})
+}
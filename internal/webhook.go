@@ -0,0 +1,270 @@
+package internal
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxPayload is the largest webhook payload GitHub delivers.
+const maxPayload = 25 << 20
+
+// Webhook receives GitHub webhook deliveries on /webhook and keeps the first
+// contact state of issues and pull requests up to date as they are opened,
+// commented on and reviewed, instead of polling the API. The current report is
+// served on /metrics.
+//
+// Issues that were opened before the webhook was installed are tracked from
+// the first event that mentions them, and issues opened before the window are
+// dropped from the store.
+type Webhook struct {
+	secret  []byte
+	store   *ContactStore
+	clock   Clock
+	window  time.Duration
+	format  string
+	options ContactOptions
+	report  ReportOptions
+	logger  *Logger
+}
+
+func NewWebhook(secret []byte, store *ContactStore, config Config, clock Clock, logger *Logger) *Webhook {
+	options := config.ContactOptions()
+	options.Logger = logger
+	return &Webhook{
+		secret:  secret,
+		store:   store,
+		clock:   clock,
+		window:  config.Window,
+		format:  config.Output.Format,
+		options: options,
+		report:  config.ReportOptions(),
+		logger:  logger,
+	}
+}
+
+type webhookEvent struct {
+	Action     string `json:"action"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	Issue       *Issue   `json:"issue"`
+	PullRequest *Issue   `json:"pull_request"`
+	Comment     *Comment `json:"comment"`
+	Review      *struct {
+		User struct {
+			Login string `json:"login"`
+			Type  string `json:"type"`
+		} `json:"user"`
+		SubmittedAt string `json:"submitted_at"`
+	} `json:"review"`
+}
+
+func (h *Webhook) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/webhook":
+		if req.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.receive(w, req)
+	case "/metrics":
+		if req.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		h.metrics(w, req)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func (h *Webhook) receive(w http.ResponseWriter, req *http.Request) {
+	name := req.Header.Get("X-GitHub-Event")
+	delivery := req.Header.Get("X-GitHub-Delivery")
+
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, maxPayload))
+	if err != nil {
+		http.Error(w, "could not read payload", http.StatusBadRequest)
+		return
+	}
+
+	err = VerifySignature(h.secret, body, req.Header.Get("X-Hub-Signature-256"))
+	if err != nil {
+		h.logger.Warn("rejected webhook delivery", "delivery", delivery, "event", name, "error", err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	var event webhookEvent
+	err = json.Unmarshal(body, &event)
+	if err != nil {
+		h.logger.Warn("rejected webhook delivery", "delivery", delivery, "event", name, "error", err)
+		http.Error(w, fmt.Sprintf("could not parse payload: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	err = h.handle(name, event)
+	if err == nil {
+		err = h.store.Prune(h.clock.Now().UTC().Add(-h.window))
+	}
+	if err != nil {
+		h.logger.Error("could not handle webhook delivery", "delivery", delivery, "event", name,
+			"action", event.Action, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("handled webhook delivery", "delivery", delivery, "event", name, "action", event.Action,
+		"repo", event.Repository.FullName)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handle applies an event to the store. Events and actions that cannot change
+// a first contact time, such as ping, are accepted and ignored.
+func (h *Webhook) handle(name string, event webhookEvent) error {
+	switch name {
+	case "issues":
+		if event.Issue == nil {
+			return fmt.Errorf("issues event without an issue")
+		}
+		tracked, err := trackedIssue(event.Repository.FullName, event.Issue)
+		if err != nil {
+			return err
+		}
+		switch event.Action {
+		case "opened", "reopened", "edited", "labeled", "unlabeled":
+			return h.store.Track(tracked)
+		case "deleted", "transferred":
+			return h.store.Forget(tracked.Issue)
+		}
+
+	case "pull_request":
+		if event.PullRequest == nil {
+			return fmt.Errorf("pull_request event without a pull request")
+		}
+		event.PullRequest.PullRequest = &PullRequestLinks{}
+		tracked, err := trackedIssue(event.Repository.FullName, event.PullRequest)
+		if err != nil {
+			return err
+		}
+		switch event.Action {
+		case "opened", "reopened", "edited", "labeled", "unlabeled":
+			return h.store.Track(tracked)
+		}
+
+	case "issue_comment":
+		if event.Action != "created" {
+			return nil
+		}
+		if event.Issue == nil || event.Comment == nil {
+			return fmt.Errorf("issue_comment event without an issue or comment")
+		}
+		return h.respond(event.Repository.FullName, event.Issue, event.Comment.User.Login,
+			event.Comment.User.Type, event.Comment.CreatedAt, ResponseComment)
+
+	case "pull_request_review":
+		if event.Action != "submitted" {
+			return nil
+		}
+		if event.PullRequest == nil || event.Review == nil {
+			return fmt.Errorf("pull_request_review event without a pull request or review")
+		}
+		event.PullRequest.PullRequest = &PullRequestLinks{}
+		return h.respond(event.Repository.FullName, event.PullRequest, event.Review.User.Login,
+			event.Review.User.Type, event.Review.SubmittedAt, ResponseReview)
+	}
+	return nil
+}
+
+// respond tracks the issue and records the activity as its response, unless it
+// came from the issue's author, an ignored user or a bot.
+func (h *Webhook) respond(repo string, issue *Issue, actor, actorType, at string, kind ResponseKind) error {
+	tracked, err := trackedIssue(repo, issue)
+	if err != nil {
+		return err
+	}
+	err = h.store.Track(tracked)
+	if err != nil {
+		return err
+	}
+
+	if actor == tracked.Author || ignoredActor(actor, actorType, h.options.IgnoredUsers) {
+		h.logger.Debug("skipping activity that is not a response", "issue", tracked.Issue, "actor", actor)
+		return nil
+	}
+
+	responded, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return fmt.Errorf("could not parse response time: %s", err)
+	}
+	return h.store.Respond(tracked.Issue, Response{At: responded, Actor: actor, Kind: kind})
+}
+
+func trackedIssue(repo string, issue *Issue) (TrackedIssue, error) {
+	created, err := time.Parse(time.RFC3339, issue.CreatedAt)
+	if err != nil {
+		return TrackedIssue{}, fmt.Errorf("could not parse issue creation time: %s", err)
+	}
+	return TrackedIssue{
+		Issue:      IssueRef{Repo: repo, Number: issue.Number, PullRequest: issue.IsPullRequest()},
+		CreatedAt:  created,
+		Author:     issue.User.Login,
+		AuthorType: issue.User.Type,
+		Labels:     issue.GetLabels(),
+	}, nil
+}
+
+// metrics writes the report for the tracked issues, in the configured output
+// format unless the format query parameter asks for another.
+func (h *Webhook) metrics(w http.ResponseWriter, req *http.Request) {
+	format := h.format
+	if requested := req.URL.Query().Get("format"); requested != "" {
+		format = requested
+	}
+	if !contains(outputFormats, format) {
+		http.Error(w, fmt.Sprintf("format %q is not one of %s", format, strings.Join(outputFormats, ", ")),
+			http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.store.Results(h.clock.Now().UTC(), h.window, h.options)
+	if err != nil {
+		h.logger.Error("could not measure tracked issues", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "json" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	NewReport(results, h.report).Write(w, format)
+}
+
+// VerifySignature checks the X-Hub-Signature-256 header GitHub signs each
+// delivery with: the HMAC-SHA256 of the body under the webhook's secret.
+func VerifySignature(secret, body []byte, signature string) error {
+	if !strings.HasPrefix(signature, "sha256=") {
+		return fmt.Errorf("missing sha256 signature")
+	}
+	digest, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return fmt.Errorf("malformed signature: %s", err)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	if !hmac.Equal(digest, mac.Sum(nil)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
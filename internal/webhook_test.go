@@ -0,0 +1,185 @@
+package internal_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "gloss/internal"
+	"gloss/internal/fakes"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+)
+
+func testWebhook(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var secret []byte
+	var store *ContactStore
+	var clock *fakes.Clock
+	var webhook *Webhook
+
+	sign := func(body []byte) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(body)
+		return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+
+	deliver := func(event, payload string) *httptest.ResponseRecorder {
+		body, err := ioutil.ReadFile(filepath.Join("testdata", "webhook", payload))
+		Expect(err).NotTo(HaveOccurred())
+
+		req := httptest.NewRequest("POST", "/webhook", bytes.NewReader(body))
+		req.Header.Set("X-GitHub-Event", event)
+		req.Header.Set("X-Hub-Signature-256", sign(body))
+		recorder := httptest.NewRecorder()
+		webhook.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	metrics := func() Report {
+		recorder := httptest.NewRecorder()
+		webhook.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics?format=json", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+
+		var report Report
+		Expect(json.Unmarshal(recorder.Body.Bytes(), &report)).To(Succeed())
+		return report
+	}
+
+	it.Before(func() {
+		var err error
+		secret = []byte("some-secret")
+		store, err = NewContactStore("")
+		Expect(err).NotTo(HaveOccurred())
+		clock = &fakes.Clock{}
+		clock.NowCall.Returns.Time = time.Date(2001, time.February, 1, 20, 20, 20, 0, time.UTC)
+		webhook = NewWebhook(secret, store, DefaultConfig(), clock, nil)
+	})
+
+	context("VerifySignature", func() {
+		it("accepts the HMAC-SHA256 of the body", func() {
+			Expect(VerifySignature(secret, []byte("some-body"), sign([]byte("some-body")))).To(Succeed())
+		})
+
+		it("rejects other signatures", func() {
+			Expect(VerifySignature(secret, []byte("other-body"), sign([]byte("some-body")))).To(MatchError("signature does not match"))
+			Expect(VerifySignature(secret, []byte("some-body"), "")).To(MatchError("missing sha256 signature"))
+			Expect(VerifySignature(secret, []byte("some-body"), "sha256=zz")).To(MatchError(ContainSubstring("malformed signature")))
+		})
+	})
+
+	context("deliveries", func() {
+		it("tracks opened issues as awaiting a response", func() {
+			Expect(deliver("issues", "issues_opened.json").Code).To(Equal(http.StatusNoContent))
+
+			tracked, ok := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 7})
+			Expect(ok).To(BeTrue())
+			Expect(tracked.Author).To(Equal("some-user"))
+			Expect(tracked.Labels).To(Equal([]string{"bug"}))
+			Expect(tracked.Response).To(BeNil())
+		})
+
+		it("records the first comment by someone else as the response", func() {
+			deliver("issues", "issues_opened.json")
+			Expect(deliver("issue_comment", "issue_comment_created.json").Code).To(Equal(http.StatusNoContent))
+
+			tracked, _ := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 7})
+			Expect(tracked.Response).To(Equal(&Response{
+				At:    time.Date(2001, time.January, 31, 21, 20, 20, 0, time.UTC),
+				Actor: "some-maintainer",
+				Kind:  ResponseComment,
+			}))
+		})
+
+		it("does not count comments by bots", func() {
+			deliver("issues", "issues_opened.json")
+			deliver("issue_comment", "issue_comment_bot.json")
+
+			tracked, _ := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 7})
+			Expect(tracked.Response).To(BeNil())
+		})
+
+		it("records reviews on pull requests it has not seen opened", func() {
+			Expect(deliver("pull_request_review", "pull_request_review_submitted.json").Code).To(Equal(http.StatusNoContent))
+
+			tracked, ok := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 8, PullRequest: true})
+			Expect(ok).To(BeTrue())
+			Expect(tracked.Response.Kind).To(Equal(ResponseReview))
+			Expect(tracked.Response.Actor).To(Equal("some-maintainer"))
+		})
+
+		it("tracks opened pull requests as awaiting a response", func() {
+			Expect(deliver("pull_request", "pull_request_opened.json").Code).To(Equal(http.StatusNoContent))
+
+			tracked, ok := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 9, PullRequest: true})
+			Expect(ok).To(BeTrue())
+			Expect(tracked.Author).To(Equal("some-contributor"))
+			Expect(tracked.Labels).To(Equal([]string{"documentation"}))
+			Expect(tracked.Response).To(BeNil())
+		})
+
+		it("drops issues opened before the window", func() {
+			deliver("issues", "issues_opened.json")
+
+			clock.NowCall.Returns.Time = clock.NowCall.Returns.Time.Add(DefaultConfig().Window - 12*time.Hour)
+			deliver("pull_request", "pull_request_opened.json")
+
+			_, ok := store.Get(IssueRef{Repo: "example-org/example-repo", Number: 7})
+			Expect(ok).To(BeFalse())
+			_, ok = store.Get(IssueRef{Repo: "example-org/example-repo", Number: 9, PullRequest: true})
+			Expect(ok).To(BeTrue())
+		})
+
+		it("accepts events it does not use", func() {
+			Expect(deliver("ping", "issues_opened.json").Code).To(Equal(http.StatusNoContent))
+			Expect(metrics().Repositories).To(BeEmpty())
+		})
+
+		it("rejects deliveries with a bad signature", func() {
+			req := httptest.NewRequest("POST", "/webhook", bytes.NewReader([]byte("{}")))
+			req.Header.Set("X-GitHub-Event", "issues")
+			req.Header.Set("X-Hub-Signature-256", sign([]byte("something else")))
+			recorder := httptest.NewRecorder()
+			webhook.ServeHTTP(recorder, req)
+
+			Expect(recorder.Code).To(Equal(http.StatusUnauthorized))
+		})
+	})
+
+	context("metrics", func() {
+		it("reports first contact times of the tracked issues", func() {
+			deliver("issues", "issues_opened.json")
+			deliver("issue_comment", "issue_comment_created.json")
+			deliver("pull_request_review", "pull_request_review_submitted.json")
+
+			report := metrics()
+			Expect(report.Repositories).To(HaveLen(1))
+			Expect(report.Repositories[0].Name).To(Equal("example-org/example-repo"))
+			Expect(report.Repositories[0].Issues).To(Equal(2))
+			Expect(report.Repositories[0].MedianFirstContact).To(Equal(300.0))
+		})
+
+		it("keeps awaiting issues up to date", func() {
+			deliver("issues", "issues_opened.json")
+			Expect(metrics().Repositories[0].MedianFirstContact).To(Equal(1440.0))
+
+			clock.NowCall.Returns.Time = clock.NowCall.Returns.Time.Add(time.Hour)
+			Expect(metrics().Repositories[0].MedianFirstContact).To(Equal(1500.0))
+		})
+
+		it("rejects unknown formats", func() {
+			recorder := httptest.NewRecorder()
+			webhook.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics?format=xml", nil))
+			Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+		})
+	})
+}
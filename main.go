@@ -1,7 +1,216 @@
 package main
 
-import "fmt"
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"backlog":           backlog,
+	"bus-factor":        busFactor,
+	"cadence":           cadence,
+	"contact-times":     contactTimes,
+	"contributors":      contributors,
+	"good-first-issues": goodFirstIssues,
+	"newcomers":         newcomers,
+	"security":          security,
+	"stale":             stale,
+	"triage":            triage,
+	"webhook":           webhook,
+}
 
 func main() {
-	fmt.Println("vim-go")
+	err := run(os.Args[1:], os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "gloss: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+// run dispatches to the named subcommand, defaulting to contact-times when
+// the first argument is a flag or there are no arguments at all.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return contactTimes(args, stdout)
+	}
+
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+	return command(args[1:], stdout)
+}
+
+// reportFlags are the flags shared by the commands that report on the
+// selected repositories other than contact-times, which has its own.
+type reportFlags struct {
+	configPath *string
+	orgs       *string
+	repoNames  *string
+	window     *time.Duration
+	format     *string
+	record     *string
+	replay     *string
+	verbose    *bool
+	logLevel   *string
+	logFormat  *string
+}
+
+func addReportFlags(flags *flag.FlagSet) *reportFlags {
+	return &reportFlags{
+		configPath: flags.String("config", "", "path to a YAML or JSON config file"),
+		orgs:       flags.String("orgs", "", "comma-separated organizations to report on"),
+		repoNames:  flags.String("repos", "", "comma-separated globs; only report on repositories matching one"),
+		window:     flags.Duration("window", 0, "how far back to look, e.g. 720h"),
+		format:     flags.String("output", "", "output format: text or json"),
+		record:     flags.String("record", "", "record every API request and response to this cassette file"),
+		replay:     flags.String("replay", "", "answer API requests from this cassette file instead of the network"),
+		verbose:    flags.Bool("verbose", false, "log every API request; short for --log-level debug"),
+		logLevel:   flags.String("log-level", "warn", "lowest level logged to stderr: debug, info, warn or error"),
+		logFormat:  flags.String("log-format", "text", "format of the log written to stderr: text or json"),
+	}
+}
+
+// loadConfig reads the config file, if any, and overrides it with the shared
+// flags given on the command line.
+func (r *reportFlags) loadConfig(flags *flag.FlagSet) (internal.Config, error) {
+	config := internal.DefaultConfig()
+	if *r.configPath != "" {
+		var err error
+		config, err = internal.LoadConfig(*r.configPath)
+		if err != nil {
+			return internal.Config{}, err
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "orgs":
+			config.Orgs = splitList(*r.orgs)
+		case "repos":
+			config.Repos.Names = splitList(*r.repoNames)
+		case "window":
+			config.Window = *r.window
+		case "output":
+			config.Output.Format = *r.format
+		}
+	})
+	return config, nil
+}
+
+// connect validates the config and connects to its forge, returning the
+// repositories to report on.
+func (r *reportFlags) connect(config internal.Config) (internal.Forge, internal.Clock, *internal.Logger, []internal.Repository, error) {
+	err := config.Validate()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	logger, err := newLogger(*r.logLevel, *r.verbose, *r.logFormat)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	httpClient, clock, err := transport(config, *r.record, *r.replay, logger)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	forge, err := newForge(config, httpClient, clock)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	repos, err := selectRepos(forge, config)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+	logger.Info("selected repositories", "count", len(repos))
+	return forge, clock, logger, repos, nil
+}
+
+// transport picks how gloss talks to the forge: over the network, recording
+// every exchange to a cassette file, or replaying one instead of the network.
+// A recording pins the clock to when it started, and a replay turns the clock
+// back to that time, so both ask for the same requests. Every request is
+// logged.
+func transport(config internal.Config, record, replay string, logger *internal.Logger) (internal.HTTPClient, internal.Clock, error) {
+	if record != "" && replay != "" {
+		return nil, nil, fmt.Errorf("only one of --record and --replay may be set")
+	}
+
+	if replay != "" {
+		cassette, err := internal.LoadCassette(replay)
+		if err != nil {
+			return nil, nil, err
+		}
+		replayer := internal.NewReplayingClient(cassette)
+		return internal.NewLoggingClient(replayer, logger, internal.SystemClock{}), internal.FixedClock(cassette.RecordedAt), nil
+	}
+
+	httpClient, err := config.Server.HTTPClient()
+	if err != nil {
+		return nil, nil, err
+	}
+	if record != "" {
+		now := time.Now()
+		recorder := internal.NewRecordingClient(httpClient, record, now)
+		return internal.NewLoggingClient(recorder, logger, internal.SystemClock{}), internal.FixedClock(now), nil
+	}
+	clock := internal.SystemClock{}
+	return internal.NewLoggingClient(httpClient, logger, clock), clock, nil
+}
+
+// newLogger logs to stderr from the named level up, or from debug up when
+// verbose.
+func newLogger(level string, verbose bool, format string) (*internal.Logger, error) {
+	if verbose {
+		level = "debug"
+	}
+	parsed, err := internal.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	return internal.NewLogger(os.Stderr, parsed, format, internal.SystemClock{})
+}
+
+// newForge connects to the forge the config points at.
+func newForge(config internal.Config, httpClient internal.HTTPClient, clock internal.Clock) (internal.Forge, error) {
+	tokens, err := config.Auth.TokenSource(config.Server, httpClient, clock)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := internal.NewAPIClient(config.Server.APIURL(), httpClient, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	switch config.Server.ForgeName() {
+	case "gitlab":
+		client.AuthScheme = "Bearer"
+		return internal.NewGitLabForge(&client, clock), nil
+	case "gitea":
+		return internal.NewGiteaForge(&client, clock), nil
+	}
+
+	forge := internal.NewGitHubForge(&client, clock)
+	if config.Backend == "graphql" || config.Discussions.Enabled {
+		graphqlURL, err := config.Server.GraphQLURL()
+		if err != nil {
+			return nil, err
+		}
+		graphql := internal.NewGraphQLClient(graphqlURL, httpClient, tokens)
+		if config.Backend == "graphql" {
+			forge.GraphQL = &graphql
+		}
+		if config.Discussions.Enabled {
+			forge.Discussions = &graphql
+		}
+	}
+	return forge, nil
 }
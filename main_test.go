@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"gloss/internal"
+	"gloss/internal/fakegithub"
+
+	. "github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestGloss(t *testing.T) {
+	spec.Run(t, "gloss", testRun, spec.Report(report.Terminal{}))
+}
+
+func testRun(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+	var server *fakegithub.Server
+	var dir string
+	var configPath string
+
+	it.Before(func() {
+		fixture, err := fakegithub.LoadFixture(filepath.Join("internal", "testdata", "fakegithub", "example_org.json"))
+		Expect(err).NotTo(HaveOccurred())
+		server = fakegithub.NewServer(fixture)
+
+		dir, err = ioutil.TempDir("", "gloss")
+		Expect(err).NotTo(HaveOccurred())
+		configPath = filepath.Join(dir, "gloss.yml")
+		Expect(ioutil.WriteFile(configPath, []byte(`
+orgs: [example-org]
+window: 220000h
+triage:
+  categories:
+    kind: [bug, enhancement]
+server:
+  url: `+server.URL+`
+auth:
+  token_env: GLOSS_TEST_TOKEN
+`), 0644)).To(Succeed())
+		Expect(os.Setenv("GLOSS_TEST_TOKEN", "some-token")).To(Succeed())
+	})
+
+	it.After(func() {
+		server.Close()
+		Expect(os.Unsetenv("GLOSS_TEST_TOKEN")).To(Succeed())
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	it("dispatches every report command and writes its JSON report", func() {
+		var names []string
+		for name := range commands {
+			if name != "webhook" {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		Expect(names).To(HaveLen(len(commands) - 1))
+
+		for _, name := range names {
+			var stdout bytes.Buffer
+			err := run([]string{name, "--config", configPath, "--output", "json"}, &stdout)
+			Expect(err).NotTo(HaveOccurred(), name)
+
+			var report map[string]interface{}
+			Expect(json.Unmarshal(stdout.Bytes(), &report)).To(Succeed(), name)
+		}
+	})
+
+	it("runs contact-times when given only flags", func() {
+		var stdout bytes.Buffer
+		err := run([]string{"--config", configPath, "--output", "json"}, &stdout)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(stdout.String()).To(ContainSubstring("example-org/example-repo"))
+	})
+
+	it("dispatches the webhook command", func() {
+		err := run([]string{"webhook", "--config", configPath, "--secret-env", "GLOSS_TEST_UNSET_SECRET"}, ioutil.Discard)
+		Expect(err).To(MatchError("webhook secret is empty; set GLOSS_TEST_UNSET_SECRET"))
+	})
+
+	it("replays a recorded run", func() {
+		cassette := filepath.Join(dir, "run.json")
+		var recorded bytes.Buffer
+		err := run([]string{"contact-times", "--config", configPath, "--output", "json", "--record", cassette}, &recorded)
+		Expect(err).NotTo(HaveOccurred())
+		server.Close()
+
+		var replayed bytes.Buffer
+		err = run([]string{"contact-times", "--config", configPath, "--output", "json", "--replay", cassette}, &replayed)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(replayed.String()).To(MatchJSON(recorded.String()))
+	})
+
+	context("eachRepo", func() {
+		var repos []internal.Repository
+
+		it.Before(func() {
+			repos = []internal.Repository{{Name: "example-org/a"}, {Name: "example-org/b"}, {Name: "example-org/c"}, {Name: "example-org/d"}}
+		})
+
+		it("measures no more repositories at a time than the concurrency", func() {
+			var mutex sync.Mutex
+			running, most := 0, 0
+			measured := make([]bool, len(repos))
+			err := eachRepo(repos, 2, func(i int) error {
+				mutex.Lock()
+				running++
+				if running > most {
+					most = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+				measured[i] = true
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(measured).To(Equal([]bool{true, true, true, true}))
+			Expect(most).To(BeNumerically("<=", 2))
+		})
+
+		it("returns the error of the first repository that failed", func() {
+			err := eachRepo(repos, 4, func(i int) error {
+				if i == 0 {
+					return nil
+				}
+				return fmt.Errorf("measuring %s: failed", repos[i].Name)
+			})
+			Expect(err).To(MatchError("measuring example-org/b: failed"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the command is unknown", func() {
+			it("returns the error", func() {
+				err := run([]string{"some-command"}, ioutil.Discard)
+				Expect(err).To(MatchError(`unknown command "some-command"`))
+			})
+		})
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// newcomers compares how quickly newcomers' issues and pull requests get a
+// response and are merged with how established contributors' fare.
+func newcomers(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("newcomers", flag.ContinueOnError)
+	storePath := flags.String("store", "", "webhook store whose activity tells established contributors apart")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose issues and replies are ignored")
+
+	var store *internal.ContactStore
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		prepare: func(config internal.Config) error {
+			if *storePath == "" {
+				return nil
+			}
+			var err error
+			store, err = internal.NewContactStore(*storePath)
+			return err
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			items, err := internal.GetNewcomerItems(run.client, repo, run.clock, run.config.Window, run.options, store)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("measured newcomer experience", "repo", repo.Name, "issues", len(items))
+			return items, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var items []internal.NewcomerItem
+			for _, result := range results {
+				items = append(items, result.([]internal.NewcomerItem)...)
+			}
+			return internal.NewNewcomerReport(items)
+		},
+	}.run(args, stdout)
+}
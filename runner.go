@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+	"sync"
+	"time"
+)
+
+// repoReport is a GitHub-only command that measures each selected repository
+// on its own and then reports on all of them together.
+type repoReport struct {
+	// flags holds the command's own flags; the shared ones are added to it.
+	flags *flag.FlagSet
+
+	// overrides says how each of the command's own flags, when given,
+	// overrides the config.
+	overrides map[string]func(config *internal.Config)
+
+	// prepare, if set, runs once the config is loaded, before connecting.
+	prepare func(config internal.Config) error
+
+	// measure measures one repository. It runs for up to config.Concurrency
+	// repositories at a time.
+	measure func(run reportRun, repo internal.Repository) (interface{}, error)
+
+	// report builds the report from what measure returned for each
+	// repository, in the order the repositories were selected.
+	report func(run reportRun, results []interface{}) reportWriter
+}
+
+type reportWriter interface {
+	Write(w io.Writer, format string) error
+}
+
+// reportRun is what a repoReport measures and reports with.
+type reportRun struct {
+	config  internal.Config
+	options internal.ContactOptions
+	forge   internal.Forge
+	client  internal.Client
+	clock   internal.Clock
+	now     time.Time
+	logger  *internal.Logger
+}
+
+// run parses the shared and the command's own flags, connects to the forge
+// and writes the report on the selected repositories to stdout.
+func (r repoReport) run(args []string, stdout io.Writer) error {
+	flags := r.flags
+	shared := addReportFlags(flags)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	config, err := shared.loadConfig(flags)
+	if err != nil {
+		return err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		if override, ok := r.overrides[f.Name]; ok {
+			override(&config)
+		}
+	})
+	if !config.Server.IsGitHub() {
+		return fmt.Errorf("%s is only available for GitHub", flags.Name())
+	}
+
+	if r.prepare != nil {
+		err = r.prepare(config)
+		if err != nil {
+			return err
+		}
+	}
+
+	forge, clock, logger, repos, err := shared.connect(config)
+	if err != nil {
+		return err
+	}
+
+	run := reportRun{
+		config:  config,
+		options: config.ContactOptions(),
+		forge:   forge,
+		client:  forge.Client(),
+		clock:   clock,
+		now:     clock.Now().UTC(),
+		logger:  logger,
+	}
+	run.options.Logger = logger
+
+	results := make([]interface{}, len(repos))
+	err = eachRepo(repos, config.Concurrency, func(i int) error {
+		var err error
+		results[i], err = r.measure(run, repos[i])
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return r.report(run, results).Write(stdout, config.Output.Format)
+}
+
+// eachRepo calls measure for the index of every repository, for up to
+// concurrency of them at a time, and returns the error of the first
+// repository that failed.
+func eachRepo(repos []internal.Repository, concurrency int, measure func(i int) error) error {
+	errs := make([]error, len(repos))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i := range repos {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			errs[i] = measure(i)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// security reports how quickly security advisories and security issues are
+// acknowledged and published, against their own SLOs.
+func security(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("security", flag.ContinueOnError)
+	labels := flags.String("labels", "", "comma-separated labels that mark security issues")
+	acknowledge := flags.Duration("acknowledge", 0, "time security reports should be acknowledged within, e.g. 72h")
+	publish := flags.Duration("publish", 0, "time advisories should be published within, e.g. 2160h")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose issues and replies are ignored")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"labels":        func(config *internal.Config) { config.Security.Labels = splitList(*labels) },
+			"acknowledge":   func(config *internal.Config) { config.Security.Acknowledge = *acknowledge },
+			"publish":       func(config *internal.Config) { config.Security.Publish = *publish },
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			items, err := internal.GetSecurityItems(run.client, repo, run.clock, run.config.Window, run.config.Security.Labels, run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("measured security reports", "repo", repo.Name, "items", len(items))
+			return items, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var items []internal.SecurityItem
+			for _, result := range results {
+				items = append(items, result.([]internal.SecurityItem)...)
+			}
+			return internal.NewSecuritySummary(items, run.config.Security, run.now)
+		},
+	}.run(args, stdout)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// stale lists open issues and pull requests nobody has acted on for a while.
+func stale(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("stale", flag.ContinueOnError)
+	after := flags.Duration("after", 0, "how long without activity makes an issue stale, e.g. 2160h")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose issues and activity are ignored")
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"after":         func(config *internal.Config) { config.Stale.After = *after },
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			issues, err := internal.GetStaleIssues(run.client, repo, run.clock, run.config.Stale.After, run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("found stale issues", "repo", repo.Name, "stale", len(issues))
+			return issues, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			var issues []internal.StaleIssue
+			for _, result := range results {
+				issues = append(issues, result.([]internal.StaleIssue)...)
+			}
+			return internal.NewStaleReport(issues, run.config.Stale.After)
+		},
+	}.run(args, stdout)
+}
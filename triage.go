@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+)
+
+// triage reports how soon new issues get labeled and whether they follow the
+// repositories' issue templates.
+func triage(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("triage", flag.ContinueOnError)
+	within := flags.Duration("within", 0, "time new issues should be labeled within, e.g. 48h")
+	ignoredUsers := flags.String("ignored-users", "", "comma-separated logins whose issues are ignored")
+
+	type repoTriage struct {
+		name      string
+		templates int
+		issues    []internal.TriagedIssue
+	}
+
+	return repoReport{
+		flags: flags,
+		overrides: map[string]func(config *internal.Config){
+			"within":        func(config *internal.Config) { config.Triage.Within = *within },
+			"ignored-users": func(config *internal.Config) { config.IgnoredUsers = splitList(*ignoredUsers) },
+		},
+		measure: func(run reportRun, repo internal.Repository) (interface{}, error) {
+			templates, err := internal.GetIssueTemplates(run.client, repo)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			issues, err := internal.GetTriagedIssues(run.client, repo, run.now.Add(-run.config.Window), run.config.Triage.Categories, templates, run.options)
+			if err != nil {
+				return nil, fmt.Errorf("measuring %s: %s", repo.Name, err)
+			}
+			run.logger.Info("measured triage", "repo", repo.Name, "issues", len(issues), "templates", len(templates))
+			return repoTriage{name: repo.Name, templates: len(templates), issues: issues}, nil
+		},
+		report: func(run reportRun, results []interface{}) reportWriter {
+			templates := make(map[string]int)
+			var issues []internal.TriagedIssue
+			for _, result := range results {
+				triaged := result.(repoTriage)
+				templates[triaged.name] = triaged.templates
+				issues = append(issues, triaged.issues...)
+			}
+			return internal.NewTriageSummary(issues, templates, run.config.Triage, run.now)
+		},
+	}.run(args, stdout)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"gloss/internal"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// webhook serves a GitHub webhook receiver that tracks first contact times as
+// events arrive, and the resulting report on /metrics.
+func webhook(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("webhook", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path to a YAML or JSON config file")
+	addr := flags.String("addr", ":8080", "address to listen on")
+	storePath := flags.String("store", "gloss-webhook.json", "file to keep first contact state in across restarts")
+	secretEnv := flags.String("secret-env", "GLOSS_WEBHOOK_SECRET", "environment variable holding the webhook secret")
+	window := flags.Duration("window", 0, "how far back /metrics reports issues, e.g. 720h")
+	format := flags.String("output", "", "default /metrics format: text or json")
+	verbose := flags.Bool("verbose", false, "log every skipped event; short for --log-level debug")
+	logLevel := flags.String("log-level", "info", "lowest level logged to stderr: debug, info, warn or error")
+	logFormat := flags.String("log-format", "text", "format of the log written to stderr: text or json")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	config := internal.DefaultConfig()
+	if *configPath != "" {
+		config, err = internal.LoadConfig(*configPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "window":
+			config.Window = *window
+		case "output":
+			config.Output.Format = *format
+		}
+	})
+
+	err = config.ValidateWebhook()
+	if err != nil {
+		return err
+	}
+
+	secret := os.Getenv(*secretEnv)
+	if secret == "" {
+		return fmt.Errorf("webhook secret is empty; set %s", *secretEnv)
+	}
+
+	logger, err := newLogger(*logLevel, *verbose, *logFormat)
+	if err != nil {
+		return err
+	}
+
+	store, err := internal.NewContactStore(*storePath)
+	if err != nil {
+		return err
+	}
+
+	handler := internal.NewWebhook([]byte(secret), store, config, internal.SystemClock{}, logger)
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Minute,
+		WriteTimeout:      time.Minute,
+	}
+	logger.Info("listening for webhook deliveries", "addr", *addr)
+	return server.ListenAndServe()
+}